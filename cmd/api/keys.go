@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/util"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+)
+
+const keysUsage = `usage: api keys <command>
+
+commands:
+  generate [-alg EdDSA|RS256] <dir>  write a new access token signing key to dir
+`
+
+// runKeys runs the keys subcommand and returns the exit code. It needs no
+// configuration, so new keys can be made before the server is set up.
+func runKeys(args []string) int {
+	if len(args) == 0 || args[0] != "generate" {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("keys generate", flag.ContinueOnError)
+	alg := flags.String("alg", util.AlgEdDSA, "signing algorithm, EdDSA or RS256")
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 1 {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return 2
+	}
+	dir := flags.Arg(0)
+
+	key, err := util.GenerateKeyPEM(*alg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	kid, err := util.NewKeyID()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	path := filepath.Join(dir, kid+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if _, err := file.Write(key); err != nil {
+		file.Close()
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("wrote %s; its key ID is %s\n", path, kid)
+	return 0
+}
+
+// newKeySet loads the access token keys, or makes a throwaway one if none
+// are configured
+func newKeySet(cfg config.JWTConfig) (*util.KeySet, error) {
+	if cfg.KeysDir == "" {
+		log.Println("WARNING: JWT_KEYS_DIR is not set, so access tokens are signed with a temporary key. " +
+			"They stop working on restart and other instances won't accept them.")
+		return util.NewEphemeralKeySet()
+	}
+	keys, err := util.LoadKeySet(cfg.KeysDir, cfg.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Signing access tokens with key %s", keys.SigningID())
+	return keys, nil
+}
+
+// reloadKeysOnHangup rereads the keys on SIGHUP until ctx is done, so they
+// can be rotated without a restart. A set that fails to load is ignored.
+func reloadKeysOnHangup(ctx context.Context, cfg config.JWTConfig, jwt *util.JWTManager) {
+	if cfg.KeysDir == "" {
+		return
+	}
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			keys, err := util.LoadKeySet(cfg.KeysDir, cfg.SigningKey)
+			if err != nil {
+				log.Printf("Keeping the current keys; reloading failed: %v", err)
+				continue
+			}
+			jwt.SetKeys(keys)
+			log.Printf("Reloaded keys; signing access tokens with key %s", keys.SigningID())
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/mail"
+)
+
+func newMailer(cfg config.MailConfig) mail.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPOptions{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+		})
+	case "file":
+		return mail.NewDevMailer(cfg.From, cfg.Dir)
+	default:
+		return mail.NewDevMailer(cfg.From, "")
+	}
+}
@@ -1,84 +1,230 @@
 package main
 
 import (
+	"context"
+	stderrors "errors"
+	"flag"
 	"fledge-restapi/internal/config"
-	"fledge-restapi/internal/domain/repository"
 	"fledge-restapi/internal/handler"
 	"fledge-restapi/internal/middleware"
+	"fledge-restapi/internal/migrations"
+	"fledge-restapi/internal/pricing"
+	"fledge-restapi/internal/router"
+	"fledge-restapi/internal/server"
 	"fledge-restapi/internal/service"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/internal/worker"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	_ "time/tzdata" // airport timezones must resolve even without system zoneinfo
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("Error loading .env file")
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: api [flags] [migrate <command> | keys <command>]\n\nflags:\n")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
+	if flag.Arg(0) == "keys" {
+		os.Exit(runKeys(flag.Args()[1:]))
+	}
+
+	// Load environment variables from .env if there is one
+	if err := godotenv.Load(); err != nil && !stderrors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var repos repositories
+	switch cfg.Storage {
+	case "memory":
+		if flag.Arg(0) == "migrate" {
+			log.Fatal("migrations only apply to database storage; unset STORAGE=memory")
+		}
+		repos, err = memoryRepositories(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to load demo data: %v", err)
+		}
+	default:
+		// Initialize database
+		db := config.InitDB(cfg.Database)
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("Failed to get database instance: %v", err)
+		}
+		defer sqlDB.Close()
 
-	// Initialize database
-	db := config.InitDB()
+		if flag.Arg(0) == "migrate" {
+			code := runMigrate(context.Background(), db, flag.Args()[1:])
+			sqlDB.Close()
+			os.Exit(code)
+		}
 
-	userRepo := repository.NewUserRepository(db)
-	flightRepo := repository.NewFlightRepository(db)
-	hotelRepo := repository.NewHotelRepository(db)
-	bookingRepo := repository.NewBookingRepository(db)
+		// Refuse to serve against a schema the code wasn't written for
+		migrator, err := migrations.New(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := migrator.Check(context.Background()); err != nil {
+			log.Fatalf("%v; run `api migrate up` first", err)
+		}
+
+		repos = databaseRepositories(db)
+	}
+
+	// Services, the pricing engine and the workers share one clock, so
+	// price alerts are evaluated against the same time that search
+	// validity, quotes and holds are judged by
+	var clock util.Clock = util.SystemClock{}
+
+	// Initialize pricing
+	var rules pricing.RuleStore = repos.pricingRules
+	if !cfg.Features.DynamicPricing {
+		rules = pricing.NoRules{}
+	}
+	pricer := pricing.NewEngine(rules, clock)
+	signingKeys, err := newKeySet(cfg.JWT)
+	if err != nil {
+		log.Fatalf("Failed to load access token keys: %v", err)
+	}
+	jwtManager := util.NewJWTManager(signingKeys, cfg.JWT.Issuer, cfg.JWT.Audience, cfg.JWT.AccessTokenTTL)
 
 	// Initialize services
-	userService := service.NewUserService(userRepo)
-	flightService := service.NewFlightService(flightRepo, bookingRepo)
-	hotelService := service.NewHotelService(hotelRepo, bookingRepo)
-	bookingService := service.NewBookingService(bookingRepo)
+	loginGuard := service.NewLoginGuard(repos.loginThrottles, service.LoginPolicy{
+		FreeAttempts:       cfg.Login.FreeAttempts,
+		BaseDelay:          cfg.Login.BaseDelay,
+		MaxDelay:           cfg.Login.MaxDelay,
+		LockoutThreshold:   cfg.Login.LockoutThreshold,
+		LockoutDuration:    cfg.Login.LockoutDuration,
+		IPLockoutThreshold: cfg.Login.IPLockoutThreshold,
+		FailureWindow:      cfg.Login.FailureWindow,
+	}, service.LogLockoutNotifier{}, clock)
+	passwordPolicy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		log.Fatalf("Failed to load the breached password list: %v", err)
+	}
+	accountTokens := service.NewAccountTokens(repos.userTokens, cfg.JWT.Secret, clock)
+	totpSecrets, err := util.NewSecretBox(cfg.JWT.Secret, "totp")
+	if err != nil {
+		log.Fatalf("Failed to set up two-factor secrets: %v", err)
+	}
+	twoFactor := service.NewTwoFactor(repos.users, repos.recoveryCodes, totpSecrets, cfg.MFA.Issuer, clock)
+	sessions := service.NewSessions(repos.sessions, jwtManager, clock)
+	sso := service.NewSingleSignOn(newOIDCProviders(cfg.OIDC, cfg.Accounts.AppURL), repos.oidcLogins, repos.userIdentities, repos.users, cfg.OIDC.LoginTTL, clock)
+	jobs := worker.NewJobQueue(cfg.Workers.JobQueue, cfg.Workers.JobWorkers, cfg.Server.ShutdownTimeout)
+	userService := service.NewUserService(repos.users, sessions, loginGuard, accountTokens, twoFactor, sso, newMailer(cfg.Mail), jobs, service.AccountPolicy{
+		Passwords:            passwordPolicy,
+		RequireVerifiedEmail: cfg.Accounts.RequireVerifiedEmail,
+		VerificationTTL:      cfg.Accounts.VerificationTTL,
+		PasswordResetTTL:     cfg.Accounts.PasswordResetTTL,
+		MFAChallengeTTL:      cfg.MFA.ChallengeTTL,
+		AppURL:               cfg.Accounts.AppURL,
+	}, clock)
+	seatService := service.NewSeatService(repos.seats, repos.flights, repos.bookings, repos.users)
+	travellerService := service.NewTravellerService(repos.savedTravellers, clock)
+	flightService := service.NewFlightService(repos.flights, repos.bookings, seatService, travellerService, pricer, cfg.Workers.HoldTTL, clock)
+	hotelService := service.NewHotelService(repos.hotels, repos.bookings, travellerService, pricer, cfg.Workers.HoldTTL, clock)
+	pricingService := service.NewPricingService(repos.pricingRules)
+	savedSearchService := service.NewSavedSearchService(repos.savedSearches, repos.priceAlerts, flightService, hotelService, clock)
+	apiKeyService := service.NewAPIKeyService(repos.apiKeys, repos.users, clock)
+	bookingService := service.NewBookingService(repos.bookings, repos.seats, repos.flights, repos.hotels, clock)
 
 	// Initialize handlers
 	userHandler := handler.NewUserHandler(userService)
 	flightHandler := handler.NewFlightHandler(flightService)
 	hotelHandler := handler.NewHotelHandler(hotelService)
 	bookingHandler := handler.NewBookingHandler(bookingService)
+	seatHandler := handler.NewSeatHandler(seatService)
+	travellerHandler := handler.NewTravellerHandler(travellerService)
+	pricingHandler := handler.NewPricingHandler(pricingService)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+
+	// Stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+	if cfg.Features.PriceAlerts {
+		startWorker(worker.NewPriceAlertEvaluator(savedSearchService, cfg.Workers.PriceAlertInterval).Run)
+	}
+	startWorker(worker.NewHoldReaper(bookingService, cfg.Workers.HoldReaperInterval).Run)
+	startWorker(func(ctx context.Context) { reloadKeysOnHangup(ctx, cfg.JWT, jwtManager) })
+	startWorker(jobs.Run)
+
 	// Setup router
+	gin.SetMode(cfg.Server.Mode)
 	r := gin.Default()
 
+	// Only believe X-Forwarded-For from our own proxies, or clients could
+	// pick their IP and dodge rate limits
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+
 	// Middleware
-	r.Use(middleware.RateLimiter())
-	r.Use(middleware.Cors())
-
-	// Public routes
-	r.POST("/auth/signup", userHandler.Signup)
-	r.POST("/auth/login", userHandler.Login)
-	//r.POST("/auth/refresh", userHandler.RefreshToken)
-
-	r.POST("/api/flights/search", flightHandler.SearchFlights)
-	r.GET("/api/flights/get-all", flightHandler.ListAllFlights)
-	r.GET("/api/flights/search/origin", flightHandler.ListFlightsByOrigin)
-	// API routes
-	api := r.Group("/api")
-	api.Use(middleware.AuthMiddleware())
-	{
-		// Flight routes
-
-		api.GET("/flights/:id", flightHandler.GetFlight)
-		api.POST("/flights/:id/book", flightHandler.BookFlight)
-
-		// Hotel routes
-		api.GET("/hotels/search", hotelHandler.SearchHotels)
-		api.GET("/hotels/:id", hotelHandler.GetHotel)
-		api.POST("/hotels/:id/book", hotelHandler.BookHotel)
-
-		// Booking routes
-		api.GET("/bookings", bookingHandler.ListBookings)
-		api.GET("/bookings/:id", bookingHandler.GetBooking)
-		api.PATCH("/bookings/:id", bookingHandler.UpdateBooking)
-		api.DELETE("/bookings/:id", bookingHandler.CancelBooking)
-
-		// Profile routes
-		api.GET("/profile", userHandler.GetProfile)
-		/*api.PUT("/profile", userHandler.UpdateProfile)
-		api.GET("/profile/preferences", userHandler.GetPreferences)
-		api.PUT("/profile/preferences", userHandler.UpdatePreferences)*/
+	cors := middleware.NewCORS(middleware.CORSPolicy{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	r.Use(cors.Handle)
+	rateLimitStore := newRateLimitStore(cfg.RateLimit)
+
+	// Routes
+	err = router.Register(r, router.Handlers{
+		User:        userHandler,
+		Flight:      flightHandler,
+		Hotel:       hotelHandler,
+		Booking:     bookingHandler,
+		Seat:        seatHandler,
+		Traveller:   travellerHandler,
+		Pricing:     pricingHandler,
+		SavedSearch: savedSearchHandler,
+		Keys:        handler.NewKeysHandler(jwtManager),
+		APIKey:      apiKeyHandler,
+	}, router.Options{
+		Auth: middleware.AuthMiddleware(jwtManager, sessions),
+		APIKey: func(scope string) gin.HandlerFunc {
+			return middleware.APIKeyAuth(apiKeyService, scope)
+		},
+		IPRateLimit:  middleware.IPRateLimiter(rateLimitStore, ipRateLimitPolicy(cfg.RateLimit), clock),
+		RateLimit:    middleware.RateLimiter(rateLimitStore, rateLimitPolicies(cfg.RateLimit), clock),
+		LegacySunset: cfg.API.LegacySunset,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	cors.AllowRoutes(r.Routes())
+
+	// Start server, draining in-flight requests on shutdown
+	if err := server.New(cfg.Server, r).Run(ctx); err != nil {
+		log.Printf("Server error: %v", err)
 	}
 
-	// Start server
-	r.Run(":8080")
+	// Stop workers before the deferred database close
+	stopWorkers()
+	workers.Wait()
+	log.Println("Server stopped")
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/oidc"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// oidcTimeout bounds each request to a provider
+const oidcTimeout = 10 * time.Second
+
+// newOIDCProviders sets up the configured providers. Unless told
+// otherwise, each sends the browser back to the web app at appURL, which
+// posts the code to the API.
+func newOIDCProviders(cfg config.OIDCConfig, appURL string) []*oidc.Provider {
+	client := &http.Client{Timeout: oidcTimeout}
+	providers := make([]*oidc.Provider, 0, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		redirect := p.RedirectURL
+		if redirect == "" {
+			redirect = strings.TrimSuffix(appURL, "/") + "/auth/oidc/" + p.Name + "/callback"
+		}
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  redirect,
+			Scopes:       p.Scopes,
+			AuthURL:      p.AuthURL,
+			TokenURL:     p.TokenURL,
+			JWKSURL:      p.JWKSURL,
+		}, client))
+	}
+	return providers
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/password"
+	"log"
+)
+
+func newPasswordPolicy(cfg config.PasswordConfig) (password.Policy, error) {
+	policy := password.Policy{
+		MinLength:     cfg.MinLength,
+		RequireLower:  cfg.RequireLower,
+		RequireUpper:  cfg.RequireUpper,
+		RequireDigit:  cfg.RequireDigit,
+		RequireSymbol: cfg.RequireSymbol,
+	}
+	if cfg.BreachedList != "" {
+		list, err := password.LoadBreachedList(cfg.BreachedList)
+		if err != nil {
+			return password.Policy{}, err
+		}
+		log.Printf("Loaded %d breached password hashes", list.Len())
+		policy.Breached = list
+	}
+	return policy, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/middleware"
+	"fledge-restapi/internal/ratelimit"
+	"strings"
+)
+
+func newRateLimitStore(cfg config.RateLimitConfig) ratelimit.Store {
+	if cfg.Store == "redis" {
+		return ratelimit.NewRedisStore(ratelimit.RedisOptions{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+	}
+	return ratelimit.NewMemoryStore()
+}
+
+// ipRateLimitPolicy is the limit on every request from one address, checked
+// before authentication
+func ipRateLimitPolicy(cfg config.RateLimitConfig) ratelimit.Policy {
+	return ratelimit.Policy{Name: "address", Requests: cfg.IPRequests, Period: cfg.IPPeriod}
+}
+
+func rateLimitPolicies(cfg config.RateLimitConfig) middleware.RateLimitPolicies {
+	policies := middleware.RateLimitPolicies{
+		Anonymous: ratelimit.Policy{Name: "ip", Requests: cfg.Requests, Period: cfg.Period},
+		User:      ratelimit.Policy{Name: "user", Requests: cfg.UserRequests, Period: cfg.UserPeriod},
+		APIKey:    ratelimit.Policy{Name: "key", Requests: cfg.APIKeyRequests, Period: cfg.APIKeyPeriod},
+		Routes:    make(map[string]ratelimit.Policy, len(cfg.Routes)),
+	}
+	for _, route := range cfg.Routes {
+		key := strings.ToUpper(route.Method) + " " + route.Path
+		policies.Routes[key] = ratelimit.Policy{Name: "route:" + key, Requests: route.Requests, Period: route.Period}
+	}
+	return policies
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/domain/repository/memory"
+	"fledge-restapi/internal/seed"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// repositories is the storage the services are built on
+type repositories struct {
+	users           repository.UserRepository
+	loginThrottles  repository.LoginThrottleRepository
+	userTokens      repository.UserTokenRepository
+	recoveryCodes   repository.RecoveryCodeRepository
+	userIdentities  repository.UserIdentityRepository
+	oidcLogins      repository.OIDCLoginRepository
+	sessions        repository.SessionRepository
+	apiKeys         repository.APIKeyRepository
+	flights         repository.FlightRepository
+	hotels          repository.HotelRepository
+	bookings        repository.BookingRepository
+	seats           repository.SeatRepository
+	savedTravellers repository.SavedTravellerRepository
+	pricingRules    repository.PricingRuleRepository
+	savedSearches   repository.SavedSearchRepository
+	priceAlerts     repository.PriceAlertRepository
+}
+
+func databaseRepositories(db *gorm.DB) repositories {
+	return repositories{
+		users:           repository.NewUserRepository(db),
+		loginThrottles:  repository.NewLoginThrottleRepository(db),
+		userTokens:      repository.NewUserTokenRepository(db),
+		recoveryCodes:   repository.NewRecoveryCodeRepository(db),
+		userIdentities:  repository.NewUserIdentityRepository(db),
+		oidcLogins:      repository.NewOIDCLoginRepository(db),
+		sessions:        repository.NewSessionRepository(db),
+		apiKeys:         repository.NewAPIKeyRepository(db),
+		flights:         repository.NewFlightRepository(db),
+		hotels:          repository.NewHotelRepository(db),
+		bookings:        repository.NewBookingRepository(db),
+		seats:           repository.NewSeatRepository(db),
+		savedTravellers: repository.NewSavedTravellerRepository(db),
+		pricingRules:    repository.NewPricingRuleRepository(db),
+		savedSearches:   repository.NewSavedSearchRepository(db),
+		priceAlerts:     repository.NewPriceAlertRepository(db),
+	}
+}
+
+// memoryRepositories returns repositories backed by a fresh in-memory store,
+// filled with two weeks of demo flights and hotels so the API can be tried
+// out without a database
+func memoryRepositories(ctx context.Context) (repositories, error) {
+	store := memory.NewStore()
+	repos := repositories{
+		users:           memory.NewUserRepository(store),
+		loginThrottles:  memory.NewLoginThrottleRepository(store),
+		userTokens:      memory.NewUserTokenRepository(store),
+		recoveryCodes:   memory.NewRecoveryCodeRepository(store),
+		userIdentities:  memory.NewUserIdentityRepository(store),
+		oidcLogins:      memory.NewOIDCLoginRepository(store),
+		sessions:        memory.NewSessionRepository(store),
+		apiKeys:         memory.NewAPIKeyRepository(store),
+		flights:         memory.NewFlightRepository(store),
+		hotels:          memory.NewHotelRepository(store),
+		bookings:        memory.NewBookingRepository(store),
+		seats:           memory.NewSeatRepository(store),
+		savedTravellers: memory.NewSavedTravellerRepository(store),
+		pricingRules:    memory.NewPricingRuleRepository(store),
+		savedSearches:   memory.NewSavedSearchRepository(store),
+		priceAlerts:     memory.NewPriceAlertRepository(store),
+	}
+
+	generator, err := seed.NewGenerator(seed.Options{
+		Seed:            1,
+		Cities:          10,
+		StartDate:       time.Now().UTC().AddDate(0, 0, 1).Truncate(24 * time.Hour),
+		Days:            14,
+		FlightsPerRoute: 2,
+		HotelsPerCity:   5,
+	})
+	if err != nil {
+		return repositories{}, err
+	}
+
+	// There is no amenity repository, so number them as the database would
+	amenities := generator.Amenities()
+	for i := range amenities {
+		amenities[i].ID = uint(i + 1)
+	}
+	hotels := generator.Hotels(amenities)
+	for i := range hotels {
+		if err := repos.hotels.Create(ctx, &hotels[i]); err != nil {
+			return repositories{}, err
+		}
+	}
+
+	flights := 0
+	err = generator.Flights(func(day time.Time, batch []entity.Flight) error {
+		for i := range batch {
+			if err := repos.flights.Create(ctx, &batch[i]); err != nil {
+				return err
+			}
+		}
+		flights += len(batch)
+		return nil
+	})
+	if err != nil {
+		return repositories{}, err
+	}
+
+	log.Printf("Using in-memory storage with %d demo flights and %d hotels; nothing is kept after exit", flights, len(hotels))
+	return repos, nil
+}
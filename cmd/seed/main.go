@@ -0,0 +1,133 @@
+// Command seed fills a development database with deterministic synthetic
+// flights, hotels, amenities and vacation packages. Run it against a freshly
+// migrated database; to start over, run `api migrate to 0` and
+// `api migrate up` first.
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/migrations"
+	"fledge-restapi/internal/seed"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	seedValue := flag.Int64("seed", 1, "random seed; the same seed and options always produce the same data")
+	cities := flag.Int("cities", 10, "number of cities to connect with flights in both directions")
+	start := flag.String("start", "", "first departure date, YYYY-MM-DD (required; a default based on today would change the data daily)")
+	days := flag.Int("days", 30, "number of days with departures")
+	flightsPerRoute := flag.Int("flights-per-route", 2, "daily departures on each route")
+	hotelsPerCity := flag.Int("hotels-per-city", 10, "hotels in each city")
+	packages := flag.Int("packages", 50, "vacation packages")
+	batchSize := flag.Int("batch", 500, "rows per insert statement")
+	flag.Parse()
+
+	if *start == "" {
+		log.Fatal("-start is required, e.g. -start 2026-11-01; pick a date after today so the flights can be searched and booked")
+	}
+	startDate, err := time.Parse("2006-01-02", *start)
+	if err != nil {
+		log.Fatalf("Invalid start date %q: %v", *start, err)
+	}
+
+	generator, err := seed.NewGenerator(seed.Options{
+		Seed:            *seedValue,
+		Cities:          *cities,
+		StartDate:       startDate,
+		Days:            *days,
+		FlightsPerRoute: *flightsPerRoute,
+		HotelsPerCity:   *hotelsPerCity,
+		Packages:        *packages,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := godotenv.Load(); err != nil && !stderrors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Keep per-row SQL logging out of bulk inserts
+	cfg.Database.LogLevel = "warn"
+	db := config.InitDB(cfg.Database)
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get database instance: %v", err)
+	}
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		log.Fatalf("%v; run `api migrate up` first", err)
+	}
+
+	if err := run(db.WithContext(ctx), generator, *batchSize); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(db *gorm.DB, generator *seed.Generator, batchSize int) error {
+	started := time.Now()
+
+	amenities := generator.Amenities()
+	if err := db.CreateInBatches(amenities, batchSize).Error; err != nil {
+		return err
+	}
+	log.Printf("Inserted %d amenities", len(amenities))
+
+	hotels := generator.Hotels(amenities)
+	if len(hotels) > 0 {
+		if err := db.CreateInBatches(hotels, batchSize).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Inserted %d hotels", len(hotels))
+
+	packages := generator.Packages()
+	if len(packages) > 0 {
+		if err := db.CreateInBatches(packages, batchSize).Error; err != nil {
+			return err
+		}
+	}
+	log.Printf("Inserted %d vacation packages", len(packages))
+
+	flights, fares := 0, 0
+	err := generator.Flights(func(day time.Time, batch []entity.Flight) error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.CreateInBatches(batch, batchSize).Error; err != nil {
+			return err
+		}
+		flights += len(batch)
+		for i := range batch {
+			fares += len(batch[i].Fares)
+		}
+		log.Printf("Inserted %d flights departing %s", len(batch), day.Format("2006-01-02"))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Seeded %d flights with %d fares in %s", flights, fares, time.Since(started).Round(time.Millisecond))
+	return nil
+}
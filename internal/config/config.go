@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -13,76 +15,386 @@ import (
 
 // Config holds all configuration for our program
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
+	Storage   string          `yaml:"storage"` // database or memory
+	Database  DatabaseConfig  `yaml:"database"`
+	Server    ServerConfig    `yaml:"server"`
+	API       APIConfig       `yaml:"api"`
+	JWT       JWTConfig       `yaml:"jwt"`
+	Login     LoginConfig     `yaml:"login"`
+	Accounts  AccountsConfig  `yaml:"accounts"`
+	Password  PasswordConfig  `yaml:"password"`
+	MFA       MFAConfig       `yaml:"mfa"`
+	OIDC      OIDCConfig      `yaml:"oidc"`
+	Mail      MailConfig      `yaml:"mail"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	CORS      CORSConfig      `yaml:"cors"`
+	Workers   WorkersConfig   `yaml:"workers"`
+	Features  FeaturesConfig  `yaml:"features"`
 }
 
 // DatabaseConfig holds all database related configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Driver          string        `yaml:"driver"` // postgres or sqlite
+	Path            string        `yaml:"path"`   // sqlite database file, or :memory:
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	DBName          string        `yaml:"name"`
+	SSLMode         string        `yaml:"ssl_mode"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	LogLevel        string        `yaml:"log_level"` // silent, error, warn or info
+}
+
+// DSN returns the connection string for the configured driver
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == "sqlite" {
+		path := c.Path
+		if path == ":memory:" {
+			// A named shared cache keeps the database alive across pool
+			// connections
+			path = "file:fledge?mode=memory&cache=shared"
+		} else {
+			path = "file:" + path
+		}
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		return path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	}
+
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		c.Host,
+		c.User,
+		c.Password,
+		c.DBName,
+		c.Port,
+		c.SSLMode,
+	)
 }
 
 // ServerConfig holds all server related configuration
 type ServerConfig struct {
-	Port string
-	Mode string
+	Port              string        `yaml:"port"`
+	Mode              string        `yaml:"mode"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	MaxHeaderBytes    int           `yaml:"max_header_bytes"`
+	TLSCertFile       string        `yaml:"tls_cert_file"`
+	TLSKeyFile        string        `yaml:"tls_key_file"`
+	TrustedProxies    []string      `yaml:"trusted_proxies"` // addresses or CIDRs whose X-Forwarded-For is believed
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (c ServerConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// APIConfig holds configuration for the API's versions
+type APIConfig struct {
+	// LegacySunset is when the unversioned /api and /auth paths will be
+	// removed, announced in their Sunset header
+	LegacySunset time.Time `yaml:"legacy_sunset"`
+}
+
+// JWTConfig holds configuration for signing access tokens
+type JWTConfig struct {
+	// Secret keys emailed tokens and encrypted secrets. Access tokens are
+	// signed with the keys in KeysDir.
+	Secret         string        `yaml:"secret"`
+	Issuer         string        `yaml:"issuer"`
+	Audience       string        `yaml:"audience"`
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl"`
+	KeysDir        string        `yaml:"keys_dir"`    // <kid>.pem signing and verification keys
+	SigningKey     string        `yaml:"signing_key"` // kid of the key that signs; the newest if empty
+}
+
+// LoginConfig holds the protection against password guessing. Each
+// failed login for an email beyond FreeAttempts makes the next attempt
+// wait, starting at BaseDelay and doubling up to MaxDelay.
+type LoginConfig struct {
+	FreeAttempts       int           `yaml:"free_attempts"`
+	BaseDelay          time.Duration `yaml:"base_delay"`
+	MaxDelay           time.Duration `yaml:"max_delay"`
+	LockoutThreshold   int           `yaml:"lockout_threshold"` // failures that lock an email
+	LockoutDuration    time.Duration `yaml:"lockout_duration"`
+	IPLockoutThreshold int           `yaml:"ip_lockout_threshold"` // failures that lock a client IP
+	FailureWindow      time.Duration `yaml:"failure_window"`       // how long failures are counted
+}
+
+// AccountsConfig holds email verification and password reset
+type AccountsConfig struct {
+	RequireVerifiedEmail bool          `yaml:"require_verified_email"` // refuse logins until the email is verified
+	VerificationTTL      time.Duration `yaml:"verification_ttl"`
+	PasswordResetTTL     time.Duration `yaml:"password_reset_ttl"`
+	AppURL               string        `yaml:"app_url"` // web app that the links in emails open
+}
+
+// PasswordConfig holds the rules for new passwords
+type PasswordConfig struct {
+	MinLength     int    `yaml:"min_length"`
+	RequireLower  bool   `yaml:"require_lower"`
+	RequireUpper  bool   `yaml:"require_upper"`
+	RequireDigit  bool   `yaml:"require_digit"`
+	RequireSymbol bool   `yaml:"require_symbol"`
+	BreachedList  string `yaml:"breached_list"` // file of SHA-1 hashes of breached passwords
+}
+
+// MFAConfig holds two-factor authentication
+type MFAConfig struct {
+	Issuer       string        `yaml:"issuer"`        // name authenticator apps show for the account
+	ChallengeTTL time.Duration `yaml:"challenge_ttl"` // how long a login waits for its code
+}
+
+// OIDCConfig holds sign-in through OpenID Connect providers
+type OIDCConfig struct {
+	LoginTTL  time.Duration        `yaml:"login_ttl"` // how long a sign-in may take at the provider
+	Providers []OIDCProviderConfig `yaml:"providers"`
+}
+
+// OIDCProviderConfig describes one OpenID Connect provider. Its endpoints
+// are discovered from the issuer unless all three are given.
+type OIDCProviderConfig struct {
+	Name         string   `yaml:"name"` // used in the API's URLs
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"` // defaults to the web app's /auth/oidc/<name>/callback
+	Scopes       []string `yaml:"scopes"`
+	AuthURL      string   `yaml:"auth_url"`
+	TokenURL     string   `yaml:"token_url"`
+	JWKSURL      string   `yaml:"jwks_url"`
+}
+
+// MailConfig holds how emails are sent
+type MailConfig struct {
+	Driver string     `yaml:"driver"` // log, file or smtp
+	From   string     `yaml:"from"`
+	Dir    string     `yaml:"dir"` // where the file driver writes messages
+	SMTP   SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig holds the connection to an SMTP server
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// RateLimitConfig holds the request limits and where their state is kept
+type RateLimitConfig struct {
+	Store          string           `yaml:"store"`       // memory, or redis to share limits between instances
+	IPRequests     int              `yaml:"ip_requests"` // per client IP for every request, checked before authentication
+	IPPeriod       time.Duration    `yaml:"ip_period"`
+	Requests       int              `yaml:"requests"` // per client IP for anonymous requests
+	Period         time.Duration    `yaml:"period"`
+	UserRequests   int              `yaml:"user_requests"` // per authenticated user
+	UserPeriod     time.Duration    `yaml:"user_period"`
+	APIKeyRequests int              `yaml:"api_key_requests"` // per API key, unless the key sets its own
+	APIKeyPeriod   time.Duration    `yaml:"api_key_period"`
+	Routes         []RouteRateLimit `yaml:"routes"` // stricter limits on particular routes
+	Redis          RedisConfig      `yaml:"redis"`
 }
 
-// LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
+// RouteRateLimit limits each client's requests to one route, on top of
+// the general limit
+type RouteRateLimit struct {
+	Method   string        `yaml:"method"`
+	Path     string        `yaml:"path"` // route pattern, e.g. /v1/flights/:id
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+}
+
+// RedisConfig holds the connection to Redis
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+// CORSConfig holds the origins allowed to call the API from a browser
+type CORSConfig struct {
+	// AllowedOrigins are origins like https://app.example.com, patterns
+	// like https://*.example.com for any subdomain, or "*" for any origin
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowCredentials bool          `yaml:"allow_credentials"` // only needed for cookies; tokens go in headers
+	MaxAge           time.Duration `yaml:"max_age"`           // how long browsers cache preflight responses
+}
+
+// WorkersConfig holds configuration for background workers
+type WorkersConfig struct {
+	PriceAlertInterval time.Duration `yaml:"price_alert_interval"`
+	HoldTTL            time.Duration `yaml:"hold_ttl"`
+	HoldReaperInterval time.Duration `yaml:"hold_reaper_interval"`
+	// Account emails and the lookups behind them run after the request
+	// is answered, JobWorkers at a time with up to JobQueue waiting
+	JobWorkers int `yaml:"job_workers"`
+	JobQueue   int `yaml:"job_queue"`
+}
+
+// FeaturesConfig switches optional features on or off
+type FeaturesConfig struct {
+	PriceAlerts    bool `yaml:"price_alerts"`
+	DynamicPricing bool `yaml:"dynamic_pricing"`
+}
+
+// Default returns the configuration used for anything not set by the
+// config file or environment
+func Default() *Config {
 	return &Config{
+		Storage: "database",
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "fledge"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:          "postgres",
+			Path:            "fledge.db",
+			Host:            "localhost",
+			Port:            "5432",
+			User:            "postgres",
+			DBName:          "fledge",
+			SSLMode:         "disable",
+			MaxIdleConns:    10,
+			MaxOpenConns:    100,
+			ConnMaxLifetime: time.Hour,
+			LogLevel:        "info",
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Mode: getEnv("GIN_MODE", "debug"),
+			Port:              "8080",
+			Mode:              "debug",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+		},
+		API: APIConfig{
+			LegacySunset: time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+		},
+		JWT: JWTConfig{
+			Issuer:         "fledge",
+			Audience:       "fledge-api",
+			AccessTokenTTL: 24 * time.Hour,
+		},
+		Login: LoginConfig{
+			FreeAttempts:       3,
+			BaseDelay:          time.Second,
+			MaxDelay:           time.Minute,
+			LockoutThreshold:   10,
+			LockoutDuration:    15 * time.Minute,
+			IPLockoutThreshold: 100,
+			FailureWindow:      time.Hour,
+		},
+		Accounts: AccountsConfig{
+			VerificationTTL:  48 * time.Hour,
+			PasswordResetTTL: time.Hour,
+			AppURL:           "http://localhost:3000",
+		},
+		Password: PasswordConfig{
+			MinLength:    10,
+			RequireLower: true,
+			RequireUpper: true,
+			RequireDigit: true,
+		},
+		MFA: MFAConfig{
+			Issuer:       "Fledge",
+			ChallengeTTL: 5 * time.Minute,
+		},
+		OIDC: OIDCConfig{
+			LoginTTL: 10 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			From:   "Fledge <no-reply@fledge.local>",
+			Dir:    "mail",
+			SMTP: SMTPConfig{
+				Port: "587",
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Store:          "memory",
+			IPRequests:     1200,
+			IPPeriod:       time.Minute,
+			Requests:       100,
+			Period:         time.Minute,
+			UserRequests:   300,
+			UserPeriod:     time.Minute,
+			APIKeyRequests: 600,
+			APIKeyPeriod:   time.Minute,
+			Routes: []RouteRateLimit{
+				// Slow down password guessing
+				{Method: "POST", Path: "/v1/auth/login", Requests: 5, Period: time.Minute},
+				{Method: "POST", Path: "/auth/login", Requests: 5, Period: time.Minute},
+				// Stop the API being used to flood inboxes
+				{Method: "POST", Path: "/v1/auth/verify-email/request", Requests: 5, Period: 15 * time.Minute},
+				{Method: "POST", Path: "/v1/auth/password-reset/request", Requests: 5, Period: 15 * time.Minute},
+			},
+			Redis: RedisConfig{
+				Addr: "localhost:6379",
+			},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			MaxAge:         10 * time.Minute,
+		},
+		Workers: WorkersConfig{
+			PriceAlertInterval: 15 * time.Minute,
+			HoldTTL:            15 * time.Minute,
+			HoldReaperInterval: time.Minute,
+			JobWorkers:         4,
+			JobQueue:           256,
+		},
+		Features: FeaturesConfig{
+			PriceAlerts:    true,
+			DynamicPricing: true,
 		},
 	}
 }
 
-// InitDB initializes the database connection and performs auto-migration
-func InitDB(models ...interface{}) *gorm.DB {
-	config := LoadConfig()
-
-	// Create database connection string
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		config.Database.Host,
-		config.Database.User,
-		config.Database.Password,
-		config.Database.DBName,
-		config.Database.Port,
-		config.Database.SSLMode,
-	)
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
 
+// InitDB initializes the database connection. The schema is managed by
+// the migrations package.
+func InitDB(cfg DatabaseConfig) *gorm.DB {
 	// Configure GORM logger
 	gormLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
 		logger.Config{
 			SlowThreshold:             time.Second,
-			LogLevel:                  logger.Info,
+			LogLevel:                  logLevels[cfg.LogLevel],
 			IgnoreRecordNotFoundError: true,
 			Colorful:                  true,
 		},
 	)
 
+	dialector := postgres.Open(cfg.DSN())
+	if cfg.Driver == "sqlite" {
+		dialector = sqlite.Open(cfg.DSN())
+	}
+
 	// Open database connection
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger: gormLogger,
+		// Report constraint violations as gorm.ErrDuplicatedKey and the
+		// like, whichever driver is in use
+		TranslateError: true,
 	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	if err := storeTimesInUTC(db); err != nil {
+		log.Fatalf("Failed to configure database timestamps: %v", err)
+	}
 
 	// Get underlying SQL DB to configure connection pool
 	sqlDB, err := db.DB()
@@ -90,29 +402,14 @@ func InitDB(models ...interface{}) *gorm.DB {
 		log.Fatalf("Failed to get database instance: %v", err)
 	}
 
-	// Configure connection pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	// Perform auto-migration
-	if len(models) > 0 {
-		log.Println("Running database migrations...")
-		err := db.AutoMigrate(models...)
-		if err != nil {
-			log.Fatalf("Failed to auto-migrate database: %v", err)
-		}
-		log.Println("Database migrations completed successfully")
+	// Configure connection pool. SQLite allows a single writer, so it gets
+	// a single connection rather than failing with busy errors.
+	if cfg.Driver == "sqlite" {
+		cfg.MaxOpenConns, cfg.MaxIdleConns = 1, 1
 	}
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db
 }
-
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
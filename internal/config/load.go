@@ -0,0 +1,435 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load builds the configuration from the defaults, then the YAML file at
+// path if one is given, then environment variables, and validates the
+// result
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	// Reject unknown keys so typos don't silently fall back to defaults
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	env := &envLoader{}
+
+	env.string("STORAGE", &c.Storage)
+
+	env.string("DB_DRIVER", &c.Database.Driver)
+	env.string("DB_PATH", &c.Database.Path)
+	env.string("DB_HOST", &c.Database.Host)
+	env.string("DB_PORT", &c.Database.Port)
+	env.string("DB_USER", &c.Database.User)
+	env.string("DB_PASSWORD", &c.Database.Password)
+	env.string("DB_NAME", &c.Database.DBName)
+	env.string("DB_SSLMODE", &c.Database.SSLMode)
+	env.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	env.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	env.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	env.string("DB_LOG_LEVEL", &c.Database.LogLevel)
+
+	env.string("SERVER_PORT", &c.Server.Port)
+	env.string("GIN_MODE", &c.Server.Mode)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.int("SERVER_MAX_HEADER_BYTES", &c.Server.MaxHeaderBytes)
+	env.string("TLS_CERT_FILE", &c.Server.TLSCertFile)
+	env.string("TLS_KEY_FILE", &c.Server.TLSKeyFile)
+	env.list("SERVER_TRUSTED_PROXIES", &c.Server.TrustedProxies)
+
+	env.date("API_LEGACY_SUNSET", &c.API.LegacySunset)
+
+	// JWT_SECRET_KEY is the name older deployments used
+	env.string("JWT_SECRET_KEY", &c.JWT.Secret)
+	env.string("JWT_SECRET", &c.JWT.Secret)
+	env.string("JWT_ISSUER", &c.JWT.Issuer)
+	env.string("JWT_AUDIENCE", &c.JWT.Audience)
+	env.string("JWT_KEYS_DIR", &c.JWT.KeysDir)
+	env.string("JWT_SIGNING_KEY", &c.JWT.SigningKey)
+	env.duration("JWT_EXPIRATION", &c.JWT.AccessTokenTTL)
+
+	env.int("LOGIN_FREE_ATTEMPTS", &c.Login.FreeAttempts)
+	env.duration("LOGIN_BASE_DELAY", &c.Login.BaseDelay)
+	env.duration("LOGIN_MAX_DELAY", &c.Login.MaxDelay)
+	env.int("LOGIN_LOCKOUT_THRESHOLD", &c.Login.LockoutThreshold)
+	env.duration("LOGIN_LOCKOUT_DURATION", &c.Login.LockoutDuration)
+	env.int("LOGIN_IP_LOCKOUT_THRESHOLD", &c.Login.IPLockoutThreshold)
+	env.duration("LOGIN_FAILURE_WINDOW", &c.Login.FailureWindow)
+
+	env.bool("REQUIRE_VERIFIED_EMAIL", &c.Accounts.RequireVerifiedEmail)
+	env.duration("EMAIL_VERIFICATION_TTL", &c.Accounts.VerificationTTL)
+	env.duration("PASSWORD_RESET_TTL", &c.Accounts.PasswordResetTTL)
+	env.string("APP_URL", &c.Accounts.AppURL)
+
+	env.int("PASSWORD_MIN_LENGTH", &c.Password.MinLength)
+	env.bool("PASSWORD_REQUIRE_LOWER", &c.Password.RequireLower)
+	env.bool("PASSWORD_REQUIRE_UPPER", &c.Password.RequireUpper)
+	env.bool("PASSWORD_REQUIRE_DIGIT", &c.Password.RequireDigit)
+	env.bool("PASSWORD_REQUIRE_SYMBOL", &c.Password.RequireSymbol)
+	env.string("PASSWORD_BREACHED_LIST", &c.Password.BreachedList)
+
+	env.string("MFA_ISSUER", &c.MFA.Issuer)
+	env.duration("MFA_CHALLENGE_TTL", &c.MFA.ChallengeTTL)
+
+	// Providers named in OIDC_PROVIDERS are added to those in the file,
+	// and each can be set with OIDC_<NAME>_* variables
+	env.duration("OIDC_LOGIN_TTL", &c.OIDC.LoginTTL)
+	var providers []string
+	env.list("OIDC_PROVIDERS", &providers)
+	for _, name := range providers {
+		if c.OIDC.provider(name) == nil {
+			c.OIDC.Providers = append(c.OIDC.Providers, OIDCProviderConfig{Name: name})
+		}
+	}
+	for i := range c.OIDC.Providers {
+		p := &c.OIDC.Providers[i]
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(p.Name, "-", "_")) + "_"
+		env.string(prefix+"ISSUER", &p.Issuer)
+		env.string(prefix+"CLIENT_ID", &p.ClientID)
+		env.string(prefix+"CLIENT_SECRET", &p.ClientSecret)
+		env.string(prefix+"REDIRECT_URL", &p.RedirectURL)
+		env.list(prefix+"SCOPES", &p.Scopes)
+		env.string(prefix+"AUTH_URL", &p.AuthURL)
+		env.string(prefix+"TOKEN_URL", &p.TokenURL)
+		env.string(prefix+"JWKS_URL", &p.JWKSURL)
+	}
+
+	env.string("MAIL_DRIVER", &c.Mail.Driver)
+	env.string("MAIL_FROM", &c.Mail.From)
+	env.string("MAIL_DIR", &c.Mail.Dir)
+	env.string("SMTP_HOST", &c.Mail.SMTP.Host)
+	env.string("SMTP_PORT", &c.Mail.SMTP.Port)
+	env.string("SMTP_USERNAME", &c.Mail.SMTP.Username)
+	env.string("SMTP_PASSWORD", &c.Mail.SMTP.Password)
+
+	env.string("RATE_LIMIT_STORE", &c.RateLimit.Store)
+	env.int("RATE_LIMIT_IP", &c.RateLimit.IPRequests)
+	env.duration("RATE_LIMIT_IP_PERIOD", &c.RateLimit.IPPeriod)
+	env.int("RATE_LIMIT", &c.RateLimit.Requests)
+	env.duration("RATE_LIMIT_PERIOD", &c.RateLimit.Period)
+	env.int("RATE_LIMIT_USER", &c.RateLimit.UserRequests)
+	env.duration("RATE_LIMIT_USER_PERIOD", &c.RateLimit.UserPeriod)
+	env.int("RATE_LIMIT_API_KEY", &c.RateLimit.APIKeyRequests)
+	env.duration("RATE_LIMIT_API_KEY_PERIOD", &c.RateLimit.APIKeyPeriod)
+	env.string("REDIS_ADDR", &c.RateLimit.Redis.Addr)
+	env.string("REDIS_PASSWORD", &c.RateLimit.Redis.Password)
+	env.int("REDIS_DB", &c.RateLimit.Redis.DB)
+
+	env.list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	env.bool("CORS_ALLOW_CREDENTIALS", &c.CORS.AllowCredentials)
+	env.duration("CORS_MAX_AGE", &c.CORS.MaxAge)
+
+	env.duration("PRICE_ALERT_INTERVAL", &c.Workers.PriceAlertInterval)
+	env.duration("HOLD_TTL", &c.Workers.HoldTTL)
+	env.duration("HOLD_REAPER_INTERVAL", &c.Workers.HoldReaperInterval)
+	env.int("JOB_WORKERS", &c.Workers.JobWorkers)
+	env.int("JOB_QUEUE", &c.Workers.JobQueue)
+
+	env.bool("FEATURE_PRICE_ALERTS", &c.Features.PriceAlerts)
+	env.bool("FEATURE_DYNAMIC_PRICING", &c.Features.DynamicPricing)
+
+	return errors.Join(env.errs...)
+}
+
+// Validate reports every setting that would stop the program from running
+// correctly
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Storage == "database" || c.Storage == "memory",
+		"storage must be database or memory, got %q", c.Storage)
+
+	// The database settings don't matter when everything is kept in memory
+	if c.Storage == "database" {
+		switch c.Database.Driver {
+		case "postgres":
+			check(c.Database.Host != "", "database.host must be set")
+			check(c.Database.DBName != "", "database.name must be set")
+		case "sqlite":
+			check(c.Database.Path != "", "database.path must be set for sqlite")
+		default:
+			check(false, "database.driver must be postgres or sqlite, got %q", c.Database.Driver)
+		}
+		check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+		check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+			"database.max_idle_conns must be between 0 and max_open_conns")
+		_, ok := logLevels[c.Database.LogLevel]
+		check(ok, "database.log_level must be one of silent, error, warn or info, got %q", c.Database.LogLevel)
+	}
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "server.port must be a port number, got %q", c.Server.Port)
+	check(c.Server.Mode == "debug" || c.Server.Mode == "release" || c.Server.Mode == "test",
+		"server.mode must be debug, release or test, got %q", c.Server.Mode)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.MaxHeaderBytes > 0, "server.max_header_bytes must be positive")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""),
+		"server.tls_cert_file and server.tls_key_file must be set together")
+
+	check(c.JWT.Secret != "", "jwt.secret must be set (JWT_SECRET)")
+	check(c.JWT.Secret == "" || len(c.JWT.Secret) >= 32, "jwt.secret must be at least 32 characters")
+	check(c.JWT.AccessTokenTTL > 0, "jwt.access_token_ttl must be positive")
+	check(c.JWT.Issuer != "" && c.JWT.Audience != "", "jwt.issuer and jwt.audience must be set")
+	check(c.JWT.SigningKey == "" || c.JWT.KeysDir != "", "jwt.signing_key needs jwt.keys_dir")
+
+	check(c.Login.FreeAttempts >= 0, "login.free_attempts must not be negative")
+	check(c.Login.BaseDelay > 0 && c.Login.MaxDelay >= c.Login.BaseDelay,
+		"login.base_delay must be positive and no more than login.max_delay")
+	check(c.Login.LockoutThreshold > c.Login.FreeAttempts,
+		"login.lockout_threshold must be more than login.free_attempts")
+	check(c.Login.LockoutDuration > 0, "login.lockout_duration must be positive")
+	check(c.Login.IPLockoutThreshold >= c.Login.LockoutThreshold,
+		"login.ip_lockout_threshold must be at least login.lockout_threshold")
+	check(c.Login.FailureWindow >= c.Login.LockoutDuration,
+		"login.failure_window must be at least login.lockout_duration")
+
+	check(c.Accounts.VerificationTTL > 0, "accounts.verification_ttl must be positive")
+	check(c.Accounts.PasswordResetTTL > 0, "accounts.password_reset_ttl must be positive")
+	appURL, err := url.Parse(c.Accounts.AppURL)
+	check(err == nil && (appURL.Scheme == "http" || appURL.Scheme == "https") && appURL.Host != "",
+		"accounts.app_url must be an http or https URL, got %q", c.Accounts.AppURL)
+
+	// bcrypt can't hash more than 72 bytes
+	check(c.Password.MinLength >= 8 && c.Password.MinLength <= 72, "password.min_length must be between 8 and 72")
+
+	// The issuer prefixes the account in otpauth labels, split on the colon
+	check(c.MFA.Issuer != "" && !strings.Contains(c.MFA.Issuer, ":"), "mfa.issuer must be set and must not contain a colon")
+	check(c.MFA.ChallengeTTL > 0, "mfa.challenge_ttl must be positive")
+
+	check(c.OIDC.LoginTTL > 0, "oidc.login_ttl must be positive")
+	seen := make(map[string]bool)
+	for i, p := range c.OIDC.Providers {
+		check(providerName.MatchString(p.Name), "oidc.providers[%d].name must be lower-case letters, digits and dashes, got %q", i, p.Name)
+		check(!seen[p.Name], "oidc.providers[%d].name %q is used twice", i, p.Name)
+		seen[p.Name] = true
+		check(isProviderURL(p.Issuer), "oidc.providers[%d].issuer must be an https URL, got %q", i, p.Issuer)
+		check(p.ClientID != "", "oidc.providers[%d].client_id must be set", i)
+		for _, u := range []string{p.AuthURL, p.TokenURL, p.JWKSURL} {
+			check(u == "" || isProviderURL(u), "oidc.providers[%d] endpoints must be https URLs, got %q", i, u)
+		}
+		if p.RedirectURL != "" {
+			redirect, err := url.Parse(p.RedirectURL)
+			check(err == nil && redirect.IsAbs(), "oidc.providers[%d].redirect_url must be an absolute URL, got %q", i, p.RedirectURL)
+		}
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "file":
+		check(c.Mail.Dir != "", "mail.dir must be set for the file driver")
+	case "smtp":
+		check(c.Mail.SMTP.Host != "" && c.Mail.SMTP.Port != "", "mail.smtp.host and mail.smtp.port must be set for the smtp driver")
+	default:
+		check(false, "mail.driver must be log, file or smtp, got %q", c.Mail.Driver)
+	}
+	_, err = mail.ParseAddress(c.Mail.From)
+	check(err == nil, "mail.from must be an email address, got %q", c.Mail.From)
+
+	check(c.RateLimit.Store == "memory" || c.RateLimit.Store == "redis",
+		"rate_limit.store must be memory or redis, got %q", c.RateLimit.Store)
+	check(c.RateLimit.Store != "redis" || c.RateLimit.Redis.Addr != "", "rate_limit.redis.addr must be set for the redis store")
+	check(c.RateLimit.IPRequests > 0, "rate_limit.ip_requests must be positive")
+	check(c.RateLimit.IPPeriod > 0, "rate_limit.ip_period must be positive")
+	check(c.RateLimit.Requests > 0, "rate_limit.requests must be positive")
+	check(c.RateLimit.Period > 0, "rate_limit.period must be positive")
+	check(c.RateLimit.UserRequests > 0, "rate_limit.user_requests must be positive")
+	check(c.RateLimit.UserPeriod > 0, "rate_limit.user_period must be positive")
+	check(c.RateLimit.APIKeyRequests > 0, "rate_limit.api_key_requests must be positive")
+	check(c.RateLimit.APIKeyPeriod > 0, "rate_limit.api_key_period must be positive")
+	for i, route := range c.RateLimit.Routes {
+		check(route.Method != "" && strings.HasPrefix(route.Path, "/"),
+			"rate_limit.routes[%d] needs a method and a path starting with /", i)
+		check(route.Requests > 0 && route.Period > 0,
+			"rate_limit.routes[%d] needs positive requests and period", i)
+	}
+
+	check(len(c.CORS.AllowedOrigins) > 0, "cors.allowed_origins must list at least one origin")
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(origin == "*" || isOriginPattern(origin),
+			"cors.allowed_origins must be \"*\" or origins like https://app.example.com or https://*.example.com, got %q", origin)
+		check(origin != "*" || !c.CORS.AllowCredentials, "cors.allow_credentials can't be used with the origin \"*\"")
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	check(c.Workers.PriceAlertInterval > 0, "workers.price_alert_interval must be positive")
+	check(c.Workers.HoldTTL > 0, "workers.hold_ttl must be positive")
+	check(c.Workers.HoldReaperInterval > 0, "workers.hold_reaper_interval must be positive")
+	check(c.Workers.JobWorkers > 0, "workers.job_workers must be positive")
+	check(c.Workers.JobQueue > 0, "workers.job_queue must be positive")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// envLoader overlays set environment variables onto config fields,
+// collecting an error for every value that fails to parse
+type envLoader struct {
+	errs []error
+}
+
+func (e *envLoader) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (e *envLoader) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envLoader) int(key string, dst *int) {
+	if value, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not an integer", key, value))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envLoader) duration(key string, dst *time.Duration) {
+	if value, ok := e.lookup(key); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a duration", key, value))
+			return
+		}
+		*dst = d
+	}
+}
+
+func (e *envLoader) bool(key string, dst *bool) {
+	if value, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a boolean", key, value))
+			return
+		}
+		*dst = b
+	}
+}
+
+// date reads a calendar date, YYYY-MM-DD, as midnight UTC
+func (e *envLoader) date(key string, dst *time.Time) {
+	if value, ok := e.lookup(key); ok {
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: %q is not a date (YYYY-MM-DD)", key, value))
+			return
+		}
+		*dst = t
+	}
+}
+
+// list reads a comma-separated list
+func (e *envLoader) list(key string, dst *[]string) {
+	if value, ok := e.lookup(key); ok {
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
+
+var providerName = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// isProviderURL accepts https URLs, and http ones on the local machine
+// for development issuers
+func isProviderURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || net.ParseIP(host).IsLoopback()
+	}
+	return false
+}
+
+// isOriginPattern reports whether raw is a browser origin, a scheme and
+// host with an optional port, whose host may start with "*." to match
+// subdomains
+func isOriginPattern(raw string) bool {
+	u, err := url.Parse(strings.Replace(raw, "://*.", "://wildcard.", 1))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.User == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == "" && !strings.HasSuffix(raw, "?") &&
+		!strings.Contains(strings.TrimPrefix(u.Host, "wildcard."), "*")
+}
+
+// provider returns the provider with the name, or nil
+func (c *OIDCConfig) provider(name string) *OIDCProviderConfig {
+	for i := range c.Providers {
+		if c.Providers[i].Name == name {
+			return &c.Providers[i]
+		}
+	}
+	return nil
+}
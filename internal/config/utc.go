@@ -0,0 +1,89 @@
+package config
+
+import (
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// storeTimesInUTC makes every timestamp the database writes UTC. SQLite
+// keeps timestamps as text and compares them as strings, which only orders
+// correctly if they all have the same offset; Postgres is unaffected.
+func storeTimesInUTC(db *gorm.DB) error {
+	db.Config.NowFunc = func() time.Time {
+		return time.Now().UTC()
+	}
+	if err := db.Callback().Create().Before("gorm:create").Register("fledge:utc_times", utcTimes); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("fledge:utc_times", utcTimes)
+}
+
+// utcTimes converts the time fields of the records being written, and the
+// time values of column updates given as a map, to UTC
+func utcTimes(tx *gorm.DB) {
+	if updates, ok := tx.Statement.Dest.(map[string]interface{}); ok {
+		for column, value := range updates {
+			switch v := value.(type) {
+			case time.Time:
+				updates[column] = v.UTC()
+			case *time.Time:
+				if v != nil {
+					utc := v.UTC()
+					updates[column] = &utc
+				}
+			}
+		}
+	}
+
+	if tx.Statement.Schema == nil {
+		return
+	}
+	rv := reflect.Indirect(tx.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		utcFields(tx, rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			utcFields(tx, reflect.Indirect(rv.Index(i)))
+		}
+	}
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	timePtrType = reflect.TypeOf(&time.Time{})
+)
+
+func utcFields(tx *gorm.DB, record reflect.Value) {
+	if record.Kind() != reflect.Struct {
+		return
+	}
+	ctx := tx.Statement.Context
+	for _, field := range tx.Statement.Schema.Fields {
+		if field.FieldType != timeType && field.FieldType != timePtrType {
+			continue
+		}
+		value, zero := field.ValueOf(ctx, record)
+		if zero {
+			continue
+		}
+		setUTC(tx, field, record, value)
+	}
+}
+
+func setUTC(tx *gorm.DB, field *schema.Field, record reflect.Value, value interface{}) {
+	var err error
+	switch v := value.(type) {
+	case time.Time:
+		err = field.Set(tx.Statement.Context, record, v.UTC())
+	case *time.Time:
+		utc := v.UTC()
+		err = field.Set(tx.Statement.Context, record, &utc)
+	}
+	if err != nil {
+		tx.AddError(err)
+	}
+}
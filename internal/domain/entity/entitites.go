@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"fledge-restapi/internal/util"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,26 +12,205 @@ import (
 // User represents the application user
 type User struct {
 	gorm.Model
-	ID          uuid.UUID       `json:"id" gorm:"type:uuid;"` // Set ID to be a UUID
-	Email       string          `json:"email" gorm:"unique;not null"`
-	Password    string          `json:"-" gorm:"not null"`
-	FirstName   string          `json:"first_name"`
-	LastName    string          `json:"last_name"`
-	PhoneNumber string          `json:"phone_number"`
-	Preferences UserPreferences `json:"preferences" gorm:"foreignKey:UserID"`
-	Bookings    []Booking       `json:"bookings,omitempty" gorm:"foreignKey:UserID"`
-	Role        string          `json:"role" gorm:"default:'user'"`
+	ID              uuid.UUID       `json:"id" gorm:"type:uuid;"` // Set ID to be a UUID
+	Email           string          `json:"email" gorm:"unique;not null"`
+	Password        string          `json:"-" gorm:"not null"`
+	FirstName       string          `json:"first_name"`
+	LastName        string          `json:"last_name"`
+	PhoneNumber     string          `json:"phone_number"`
+	Preferences     UserPreferences `json:"preferences" gorm:"foreignKey:UserID"`
+	Bookings        []Booking       `json:"bookings,omitempty" gorm:"foreignKey:UserID"`
+	Role            string          `json:"role" gorm:"default:'user'"`
+	EmailVerifiedAt *time.Time      `json:"email_verified_at"` // nil until the user proves they own Email
+
+	// Two-factor authentication. TOTPSecret is encrypted, and is set but
+	// not yet in force while TwoFactorEnabledAt is nil. TOTPLastStep is
+	// the time step of the last code accepted, so codes can't be reused.
+	TOTPSecret         string     `json:"-" gorm:"column:totp_secret"`
+	TOTPLastStep       int64      `json:"-" gorm:"column:totp_last_step"`
+	TwoFactorEnabledAt *time.Time `json:"two_factor_enabled_at"`
+}
+
+// UserToken is a single-use token emailed to a user to verify their
+// address or reset their password. Only a keyed hash of the token is kept,
+// so the table can't be used to act on anyone's account.
+type UserToken struct {
+	gorm.Model
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	Purpose   string     `json:"purpose"` // verify_email or reset_password
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when
+// the user has lost their authenticator. Only its hash is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TwoFactorSetupResponse is the secret to add to an authenticator app,
+// as text and as an otpauth:// URI for a QR code
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorCodeRequest carries a code from the user's authenticator app,
+// or one of their recovery codes where the operation allows it
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse lists newly issued recovery codes. They are only
+// ever shown this once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorLoginRequest completes a login that needs a second factor
+type TwoFactorLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // authenticator or recovery code
+}
+
+// LoginResult is the outcome of a correct password: either an access
+// token, or a challenge to exchange for one with a second factor
+type LoginResult struct {
+	Token       string `json:"token,omitempty"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// Session is a signed-in device. Every access token names the session it
+// was issued to, and stops working once the session is revoked or expires.
+type Session struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `json:"-" gorm:"type:uuid;index"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"` // where the session was last seen from
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current" gorm:"-"` // the session making the request
+}
+
+// RevokedSessionsResponse says how many sessions were signed out
+type RevokedSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// API key scopes, each allowing a set of operations to partner
+// integrations
+const (
+	APIScopeSearch = "search" // browse and quote flights and hotels
+	APIScopeBook   = "book"   // book, and manage the bookings made
+)
+
+// APIKey lets a partner's server call the API as a user account without
+// its password. The key is shown once when issued; only the prefix that
+// identifies it and a hash of its secret are kept. Deleting it revokes it.
+type APIKey struct {
+	gorm.Model
+	Name       string     `json:"name"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;index"` // the account the key acts as
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex"`
+	SecretHash string     `json:"-"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	RateLimit  int        `json:"rate_limit"` // requests per rate limit period; 0 for the default
+	ExpiresAt  *time.Time `json:"expires_at"` // nil for never
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// APIKeyRequest issues an API key for a partner's account
+type APIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	UserID    uuid.UUID  `json:"user_id" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=search book"`
+	RateLimit int        `json:"rate_limit" binding:"min=0"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// NewAPIKeyResponse is an issued API key. Key is only ever shown this once.
+type NewAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// UserIdentity links a user to their account at an OpenID Connect
+// provider, identified by the provider's subject
+type UserIdentity struct {
+	gorm.Model
+	UserID   uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
+	Provider string    `json:"provider" gorm:"uniqueIndex:idx_user_identities_provider_subject"`
+	Subject  string    `json:"-" gorm:"uniqueIndex:idx_user_identities_provider_subject"`
+	Email    string    `json:"email"` // as the provider reported it when linked
+}
+
+// OIDCLogin is a sign-in sent to an OpenID Connect provider and not yet
+// completed. It is looked up by a hash of the state the browser carries
+// there and back, and holds the secrets that must not leave the server.
+type OIDCLogin struct {
+	ID           uint `gorm:"primarykey"`
+	CreatedAt    time.Time
+	StateHash    string `gorm:"uniqueIndex"`
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// TableName keeps gorm from splitting OIDC into o_id_c
+func (OIDCLogin) TableName() string {
+	return "oidc_logins"
+}
+
+// OIDCStartResponse is where to send the browser to sign in with a
+// provider. The web app should keep State and check it comes back.
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest passes on the parameters the provider redirected
+// the browser back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// EmailRequest asks for an email about an account, such as a new
+// verification link
+type EmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// VerifyEmailRequest redeems an email verification token
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResetPasswordRequest redeems a password reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// SignupRequest registers a user. The password is checked against the
+// password policy rather than by binding.
 type SignupRequest struct {
-	FirstName string `json:"first_name" binding:"required"`
-	LastName  string `json:"last_name" binding:"required,min=6"`
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	FirstName   string `json:"first_name" binding:"required,max=100"`
+	LastName    string `json:"last_name" binding:"required,max=100"`
+	Email       string `json:"email" binding:"required,email,max=254"`
+	Password    string `json:"password" binding:"required"`
+	PhoneNumber string `json:"phone_number" binding:"omitempty,e164"`
 }
 
 type LoginRequest struct {
@@ -37,6 +218,22 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginThrottle counts recent failed logins for one email address or
+// client IP, keyed "email:<address>" or "ip:<address>", and when the next
+// attempt is allowed
+type LoginThrottle struct {
+	Key          string     `json:"key" gorm:"primaryKey"`
+	Failures     int        `json:"failures"`
+	LastFailedAt time.Time  `json:"last_failed_at"`
+	RetryAt      *time.Time `json:"retry_at"`     // progressive delay before the next attempt
+	LockedUntil  *time.Time `json:"locked_until"` // temporary lockout
+}
+
+// UnlockAccountRequest names the account whose login lockout to lift
+type UnlockAccountRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 // UserPreferences stores user's travel preferences
 type UserPreferences struct {
 	gorm.Model
@@ -50,17 +247,210 @@ type UserPreferences struct {
 // Flight represents a flight offering
 type Flight struct {
 	gorm.Model
-	FlightNumber       string    `json:"flight_number"`
-	Airline            string    `json:"airline"`
-	DepartureCity      string    `json:"departure_city"`
-	ArrivalCity        string    `json:"arrival_city"`
-	DestinationCountry string    `json:"destination_country"`
-	DepartureTime      time.Time `json:"departure_time"`
-	ArrivalTime        time.Time `json:"arrival_time"`
-	AvailableSeats     int       `json:"available_seats"`
-	Price              float64   `json:"price"`
-	Class              string    `json:"class"` // economy, business, first
-	Status             string    `json:"status"`
+	FlightNumber       string      `json:"flight_number"`
+	Airline            string      `json:"airline"`
+	DepartureCity      string      `json:"departure_city"`
+	ArrivalCity        string      `json:"arrival_city"`
+	DestinationCountry string      `json:"destination_country"`
+	DepartureTimezone  string      `json:"departure_timezone"` // IANA name of the origin airport's timezone
+	ArrivalTimezone    string      `json:"arrival_timezone"`   // IANA name of the destination airport's timezone
+	DepartureTime      time.Time   `json:"departure_time"`     // stored in UTC
+	ArrivalTime        time.Time   `json:"arrival_time"`       // stored in UTC
+	Status             string      `json:"status"`
+	Fares              []FareClass `json:"fares" gorm:"foreignKey:FlightID"`
+}
+
+// Cabins lists the cabins a flight can sell, from the back of the aircraft
+var Cabins = []string{"economy", "premium", "business", "first"}
+
+// FareClass is a bookable fare on a flight. A cabin can be sold through
+// several fare buckets, each with its own price, rules and inventory.
+type FareClass struct {
+	gorm.Model
+	FlightID         uint    `json:"flight_id" gorm:"index"`
+	Cabin            string  `json:"cabin"`             // economy, premium, business, first
+	Code             string  `json:"code"`              // fare bucket booking code, e.g. "Y", "M"
+	Price            float64 `json:"price"`             // base fare, before dynamic pricing
+	BaggageAllowance int     `json:"baggage_allowance"` // checked bags included
+	Changeable       bool    `json:"changeable"`
+	ChangeFee        float64 `json:"change_fee"`
+	Refundable       bool    `json:"refundable"`
+	AvailableSeats   int     `json:"available_seats"`
+	Capacity         int     `json:"capacity"` // seats allocated to the fare, used for load factor pricing
+
+	// QuotedPrice is what an adult seat costs now, with pricing rules
+	// applied to Price. It is filled in when flights are returned by the
+	// flight service, and is what a booking made now is charged.
+	QuotedPrice *float64 `json:"quoted_price,omitempty" gorm:"-"`
+}
+
+// CurrentPrice returns the quoted price if the fare has been priced, or
+// else its base fare
+func (f *FareClass) CurrentPrice() float64 {
+	if f.QuotedPrice != nil {
+		return *f.QuotedPrice
+	}
+	return f.Price
+}
+
+// CheapestFares returns the cheapest fare in each cabin, by current price,
+// with at least the given number of seats left, ordered by cabin
+func (f *Flight) CheapestFares(passengers int) []FareClass {
+	cheapest := make(map[string]FareClass)
+	for _, fare := range f.Fares {
+		if fare.AvailableSeats < passengers {
+			continue
+		}
+		if current, ok := cheapest[fare.Cabin]; !ok || fare.CurrentPrice() < current.CurrentPrice() {
+			cheapest[fare.Cabin] = fare
+		}
+	}
+
+	fares := make([]FareClass, 0, len(cheapest))
+	for _, cabin := range Cabins {
+		if fare, ok := cheapest[cabin]; ok {
+			fares = append(fares, fare)
+		}
+	}
+	return fares
+}
+
+// Fare returns the flight's fare class with the given ID
+func (f *Flight) Fare(id uint) (*FareClass, bool) {
+	for i := range f.Fares {
+		if f.Fares[i].ID == id {
+			return &f.Fares[i], true
+		}
+	}
+	return nil, false
+}
+
+// DepartureLocation returns the origin airport's timezone, or UTC if unknown
+func (f *Flight) DepartureLocation() *time.Location {
+	return locationOrUTC(f.DepartureTimezone)
+}
+
+// ArrivalLocation returns the destination airport's timezone, or UTC if unknown
+func (f *Flight) ArrivalLocation() *time.Location {
+	return locationOrUTC(f.ArrivalTimezone)
+}
+
+// DepartsOn reports whether the flight leaves on the calendar date of t in
+// the origin airport's local time
+func (f *Flight) DepartsOn(t time.Time) bool {
+	start, end := util.LocalDayBounds(t, f.DepartureLocation())
+	return !f.DepartureTime.Before(start) && f.DepartureTime.Before(end)
+}
+
+// Duration returns the elapsed flight time, independent of timezones
+func (f *Flight) Duration() time.Duration {
+	return f.ArrivalTime.Sub(f.DepartureTime)
+}
+
+func locationOrUTC(name string) *time.Location {
+	loc, err := util.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// FlightResponse is a flight as returned by the API, with times in both UTC
+// and each airport's local time
+type FlightResponse struct {
+	Flight
+	DepartureTimeUTC   time.Time   `json:"departure_time_utc"`
+	DepartureTimeLocal time.Time   `json:"departure_time_local"`
+	ArrivalTimeUTC     time.Time   `json:"arrival_time_utc"`
+	ArrivalTimeLocal   time.Time   `json:"arrival_time_local"`
+	DurationMinutes    int         `json:"duration_minutes"`
+	CheapestFares      []FareClass `json:"cheapest_fares"` // cheapest available fare per cabin
+}
+
+func NewFlightResponse(f Flight) FlightResponse {
+	return FlightResponse{
+		Flight:             f,
+		DepartureTimeUTC:   f.DepartureTime.UTC(),
+		DepartureTimeLocal: f.DepartureTime.In(f.DepartureLocation()),
+		ArrivalTimeUTC:     f.ArrivalTime.UTC(),
+		ArrivalTimeLocal:   f.ArrivalTime.In(f.ArrivalLocation()),
+		DurationMinutes:    int(f.Duration().Minutes()),
+		CheapestFares:      f.CheapestFares(1),
+	}
+}
+
+func NewFlightResponses(flights []Flight) []FlightResponse {
+	responses := make([]FlightResponse, len(flights))
+	for i, f := range flights {
+		responses[i] = NewFlightResponse(f)
+	}
+	return responses
+}
+
+// Seat represents a single seat in a flight's seat map
+type Seat struct {
+	gorm.Model
+	FlightID  uint   `json:"flight_id" gorm:"uniqueIndex:idx_seats_flight_number"`
+	Row       int    `json:"row" gorm:"column:seat_row;uniqueIndex:idx_seats_flight_number"`
+	Letter    string `json:"letter" gorm:"uniqueIndex:idx_seats_flight_number"`
+	Cabin     string `json:"cabin"` // economy, premium, business, first
+	Window    bool   `json:"window"`
+	Aisle     bool   `json:"aisle"`
+	Exit      bool   `json:"exit"`
+	Blocked   bool   `json:"blocked"`
+	BookingID *uint  `json:"-" gorm:"index"`
+}
+
+// Number returns the seat number as printed on a boarding pass, e.g. "12A"
+func (s *Seat) Number() string {
+	return fmt.Sprintf("%d%s", s.Row, s.Letter)
+}
+
+// Available reports whether the seat can be assigned to a booking
+func (s *Seat) Available() bool {
+	return !s.Blocked && s.BookingID == nil
+}
+
+// SeatType returns "window" or "aisle" for seats matching a preferred seat
+// type, or "middle" otherwise
+func (s *Seat) SeatType() string {
+	switch {
+	case s.Window:
+		return "window"
+	case s.Aisle:
+		return "aisle"
+	default:
+		return "middle"
+	}
+}
+
+// SeatResponse is a seat as shown in a flight's seat availability map
+type SeatResponse struct {
+	Number    string `json:"number"`
+	Row       int    `json:"row"`
+	Letter    string `json:"letter"`
+	Cabin     string `json:"cabin"`
+	Window    bool   `json:"window"`
+	Aisle     bool   `json:"aisle"`
+	Exit      bool   `json:"exit"`
+	Available bool   `json:"available"`
+}
+
+func NewSeatResponses(seats []Seat) []SeatResponse {
+	responses := make([]SeatResponse, len(seats))
+	for i, seat := range seats {
+		responses[i] = SeatResponse{
+			Number:    seat.Number(),
+			Row:       seat.Row,
+			Letter:    seat.Letter,
+			Cabin:     seat.Cabin,
+			Window:    seat.Window,
+			Aisle:     seat.Aisle,
+			Exit:      seat.Exit,
+			Available: seat.Available(),
+		}
+	}
+	return responses
 }
 
 // Hotel represents a hotel offering
@@ -73,9 +463,27 @@ type Hotel struct {
 	Rating         float32   `json:"rating"`
 	Price          float64   `json:"price_per_night"`
 	AvailableRooms int       `json:"available_rooms"`
+	TotalRooms     int       `json:"total_rooms"` // used for load factor pricing
 	Amenities      []Amenity `json:"amenities" gorm:"many2many:hotel_amenities;"`
 }
 
+// PricingRule is an admin-configured price adjustment, applied to flight
+// fares or hotel rooms whenever its condition holds
+type PricingRule struct {
+	gorm.Model
+	Name        string  `json:"name"`
+	Product     string  `json:"product"` // flight, hotel, or empty for both
+	Type        string  `json:"type"`    // load_factor, days_before, day_of_week, season
+	Min         float64 `json:"min"`     // load_factor: fraction sold (0-1); days_before: days, inclusive
+	Max         float64 `json:"max"`
+	Weekdays    string  `json:"weekdays"`     // day_of_week: comma-separated, e.g. "sat,sun"
+	SeasonStart string  `json:"season_start"` // season: MM-DD, inclusive
+	SeasonEnd   string  `json:"season_end"`   // season: MM-DD, inclusive; may wrap over new year
+	Percent     float64 `json:"percent"`      // e.g. 15 raises the price by 15%, -10 lowers it by 10%
+	Priority    int     `json:"priority"`     // lower priorities are listed first in quotes
+	Active      bool    `json:"active"`
+}
+
 // Amenity represents hotel amenities
 type Amenity struct {
 	gorm.Model
@@ -102,29 +510,93 @@ type VacationPackage struct {
 // Booking represents a user booking
 type Booking struct {
 	gorm.Model
-	UserID            uuid.UUID `json:"user_id"`
-	BookingType       string    `json:"booking_type"` // flight, hotel, package
-	FlightID          *uint     `json:"flight_id,omitempty"`
-	HotelID           *uint     `json:"hotel_id,omitempty"`
-	VacationPackageID *uint     `json:"vacation_package_id,omitempty"`
-	Status            string    `json:"status"` // confirmed, cancelled, pending
-	BookingDate       time.Time `json:"booking_date"`
-	TotalPrice        float64   `json:"total_price"`
-	PaymentStatus     string    `json:"payment_status"`
-	CheckInDate       time.Time `json:"check_in_date,omitempty"`
-	CheckOutDate      time.Time `json:"check_out_date,omitempty"`
-	NumGuests         int       `json:"num_guests"`
-	SpecialRequests   string    `json:"special_requests"`
+	UserID            uuid.UUID   `json:"user_id"`
+	BookingType       string      `json:"booking_type"` // flight, hotel, package
+	FlightID          *uint       `json:"flight_id,omitempty"`
+	FareClassID       *uint       `json:"fare_class_id,omitempty"`
+	HotelID           *uint       `json:"hotel_id,omitempty"`
+	VacationPackageID *uint       `json:"vacation_package_id,omitempty"`
+	Status            string      `json:"status"`                    // held, confirmed, cancelled, expired
+	HoldExpiresAt     *time.Time  `json:"hold_expires_at,omitempty"` // inventory is released if unpaid by then
+	BookingDate       time.Time   `json:"booking_date"`
+	TotalPrice        float64     `json:"total_price"`
+	PaymentStatus     string      `json:"payment_status"`
+	PaymentReference  string      `json:"payment_reference,omitempty"`
+	CheckInDate       time.Time   `json:"check_in_date,omitempty"`
+	CheckOutDate      time.Time   `json:"check_out_date,omitempty"`
+	NumGuests         int         `json:"num_guests"`
+	SeatCount         int         `json:"seat_count"` // fare seats taken; infants fly on a lap and take none
+	SpecialRequests   string      `json:"special_requests"`
+	Travellers        []Traveller `json:"travellers,omitempty" gorm:"foreignKey:BookingID"`
+}
+
+// TravellerDetails holds the personal details shared by booking travellers
+// and saved traveller profiles
+type TravellerDetails struct {
+	FirstName       string     `json:"first_name" binding:"required"`
+	LastName        string     `json:"last_name" binding:"required"`
+	DateOfBirth     *time.Time `json:"date_of_birth"`
+	PassengerType   string     `json:"passenger_type" binding:"required,oneof=adult child infant"`
+	PassportNumber  string     `json:"passport_number,omitempty"`
+	PassportCountry string     `json:"passport_country,omitempty"`
+	PassportExpiry  *time.Time `json:"passport_expiry,omitempty"`
+	LoyaltyNumbers  string     `json:"loyalty_numbers,omitempty"` // comma-separated programme:number pairs, e.g. "LH:992001"
+}
+
+// Traveller represents a person travelling on a booking
+type Traveller struct {
+	gorm.Model
+	BookingID uint `json:"booking_id" gorm:"index"`
+	TravellerDetails
+	Price float64 `json:"price"`
+}
+
+// SavedTraveller is a traveller profile stored on a user's account for reuse
+// in future bookings
+type SavedTraveller struct {
+	gorm.Model
+	UserID uuid.UUID `json:"user_id" gorm:"index"`
+	TravellerDetails
+}
+
+// SavedSearch is a flight or hotel search that is periodically re-run to
+// alert the user when prices drop
+type SavedSearch struct {
+	gorm.Model
+	UserID         uuid.UUID            `json:"user_id" gorm:"index"`
+	Name           string               `json:"name"`
+	SearchType     string               `json:"search_type"` // flight, hotel
+	FlightCriteria *FlightSearchRequest `json:"flight_criteria,omitempty" gorm:"serializer:json"`
+	HotelCriteria  *HotelSearchRequest  `json:"hotel_criteria,omitempty" gorm:"serializer:json"`
+	TargetPrice    *float64             `json:"target_price,omitempty"` // per passenger or per night; alerts on any new low if unset
+	LowestPrice    *float64             `json:"lowest_price,omitempty"` // lowest price seen by the last run
+	AlertedPrice   *float64             `json:"alerted_price,omitempty"`
+	LastRunAt      *time.Time           `json:"last_run_at,omitempty"`
+	Active         bool                 `json:"active"` // cleared once the search dates have passed
+}
+
+// PriceAlert is an inbox entry raised when a saved search finds a price
+// below its target
+type PriceAlert struct {
+	gorm.Model
+	UserID        uuid.UUID  `json:"user_id" gorm:"index"`
+	SavedSearchID uint       `json:"saved_search_id" gorm:"index"`
+	ItemType      string     `json:"item_type"` // flight, hotel
+	ItemID        uint       `json:"item_id"`
+	Price         float64    `json:"price"`
+	PreviousPrice *float64   `json:"previous_price,omitempty"`
+	TargetPrice   *float64   `json:"target_price,omitempty"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
 }
 
 // Search request structs
 type FlightSearchRequest struct {
 	DepartureCity string     `json:"departure_city" binding:"required"`
 	ArrivalCity   string     `json:"arrival_city" binding:"required"`
-	DepartureDate time.Time  `json:"departure_date" binding:"required"`
-	ReturnDate    *time.Time `json:"return_date"`
+	DepartureDate time.Time  `json:"departure_date" binding:"required"` // calendar date in the origin airport's timezone
+	ReturnDate    *time.Time `json:"return_date"`                       // calendar date in the destination airport's timezone
 	Passengers    int        `json:"passengers" binding:"required,min=1"`
-	Class         string     `json:"class" binding:"required"`
+	Class         string     `json:"class"` // cabin to restrict results to; all cabins if empty
 }
 
 type HotelSearchRequest struct {
@@ -137,13 +609,82 @@ type HotelSearchRequest struct {
 	MinRating *float32  `json:"min_rating"`
 }
 
+type PricingRuleRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Product     string  `json:"product" binding:"omitempty,oneof=flight hotel"`
+	Type        string  `json:"type" binding:"required,oneof=load_factor days_before day_of_week season"`
+	Min         float64 `json:"min"`
+	Max         float64 `json:"max"`
+	Weekdays    string  `json:"weekdays"`
+	SeasonStart string  `json:"season_start"`
+	SeasonEnd   string  `json:"season_end"`
+	Percent     float64 `json:"percent" binding:"required,gt=-100"`
+	Priority    int     `json:"priority"`
+	Active      *bool   `json:"active"` // defaults to true
+}
+
+type HotelQuoteRequest struct {
+	CheckIn  time.Time `form:"check_in" time_format:"2006-01-02" binding:"required"`
+	CheckOut time.Time `form:"check_out" time_format:"2006-01-02" binding:"required"`
+}
+
+type SavedSearchRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	SearchType  string               `json:"search_type" binding:"required,oneof=flight hotel"`
+	Flight      *FlightSearchRequest `json:"flight"` // required when search_type is flight
+	Hotel       *HotelSearchRequest  `json:"hotel"`  // required when search_type is hotel
+	TargetPrice *float64             `json:"target_price" binding:"omitempty,gt=0"`
+}
+
+type ConfirmBookingRequest struct {
+	PaymentReference string `json:"payment_reference" binding:"required"`
+}
+
 type BookingRequest struct {
-	BookingType       string     `json:"booking_type" binding:"required"`
-	FlightID          *uint      `json:"flight_id"`
-	HotelID           *uint      `json:"hotel_id"`
-	VacationPackageID *uint      `json:"vacation_package_id"`
-	CheckInDate       *time.Time `json:"check_in_date"`
-	CheckOutDate      *time.Time `json:"check_out_date"`
-	NumGuests         int        `json:"num_guests" binding:"required,min=1"`
-	SpecialRequests   string     `json:"special_requests"`
+	BookingType       string             `json:"booking_type" binding:"required"`
+	FlightID          *uint              `json:"flight_id"`
+	FareClassID       *uint              `json:"fare_class_id"` // required for flights
+	HotelID           *uint              `json:"hotel_id"`
+	VacationPackageID *uint              `json:"vacation_package_id"`
+	CheckInDate       *time.Time         `json:"check_in_date"`
+	CheckOutDate      *time.Time         `json:"check_out_date"`
+	NumGuests         int                `json:"num_guests" binding:"required,min=1"`
+	SpecialRequests   string             `json:"special_requests"`
+	SeatNumbers       []string           `json:"seat_numbers"` // flights only; chosen from the user's preferred seat type if empty
+	Travellers        []TravellerDetails `json:"travellers" binding:"dive"`
+	SavedTravellerIDs []uint             `json:"saved_traveller_ids"` // saved traveller profiles to add to Travellers
+
+	// TravellersOptional is set by the unversioned booking paths, which
+	// predate travellers: bookings there may omit them, and every guest is
+	// then booked as an adult
+	TravellersOptional bool `json:"-"`
+}
+
+// SeatMapRequest describes the layout of a flight's seat map, one section
+// per cabin
+type SeatMapRequest struct {
+	Sections []SeatSection `json:"sections" binding:"required,min=1,dive"`
+	Blocked  []string      `json:"blocked"` // seat numbers that can never be assigned, e.g. "14C"
+}
+
+type SeatSection struct {
+	Cabin    string `json:"cabin" binding:"required"`
+	FirstRow int    `json:"first_row" binding:"required,min=1"`
+	LastRow  int    `json:"last_row" binding:"required,gtefield=FirstRow"`
+	Layout   string `json:"layout" binding:"required"` // seat letters with "-" for aisles, e.g. "ABC-DEF"
+	ExitRows []int  `json:"exit_rows"`
+}
+
+type SeatAssignmentRequest struct {
+	SeatNumbers []string `json:"seat_numbers"` // chosen from the user's preferred seat type if empty
+}
+
+// MessageResponse is the body of responses that only report success
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// TokenResponse is the body of a successful login
+type TokenResponse struct {
+	Token string `json:"token"`
 }
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestFlightDepartsOn(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		zone      string
+		departure string // UTC
+		on        time.Time
+		want      bool
+	}{
+		{
+			name:      "late evening on the New York spring-forward day",
+			zone:      "America/New_York",
+			departure: "2026-03-09T03:30:00Z", // 23:30 EDT on the 8th
+			on:        date(2026, 3, 8),
+			want:      true,
+		},
+		{
+			name:      "same flight is not on the next UTC date",
+			zone:      "America/New_York",
+			departure: "2026-03-09T03:30:00Z",
+			on:        date(2026, 3, 9),
+			want:      false,
+		},
+		{
+			name:      "just after midnight on the New York fall-back day",
+			zone:      "America/New_York",
+			departure: "2026-11-01T04:15:00Z", // 00:15 EDT on the 1st
+			on:        date(2026, 11, 1),
+			want:      true,
+		},
+		{
+			name:      "just after midnight is not the previous day",
+			zone:      "America/New_York",
+			departure: "2026-11-01T04:15:00Z",
+			on:        date(2026, 10, 31),
+			want:      false,
+		},
+		{
+			name:      "last hour of the 25-hour New York day",
+			zone:      "America/New_York",
+			departure: "2026-11-02T04:30:00Z", // 23:30 EST on the 1st
+			on:        date(2026, 11, 1),
+			want:      true,
+		},
+		{
+			name:      "just before midnight in London summer time",
+			zone:      "Europe/London",
+			departure: "2026-10-24T22:45:00Z", // 23:45 BST on the 24th
+			on:        date(2026, 10, 24),
+			want:      true,
+		},
+		{
+			name:      "first minute of the London fall-back day",
+			zone:      "Europe/London",
+			departure: "2026-10-24T23:00:00Z", // 00:00 BST on the 25th
+			on:        date(2026, 10, 25),
+			want:      true,
+		},
+		{
+			name:      "first minute after the short London spring-forward day",
+			zone:      "Europe/London",
+			departure: "2026-03-29T23:00:00Z", // 00:00 BST on the 30th
+			on:        date(2026, 3, 29),
+			want:      false,
+		},
+		{
+			name:      "unknown timezone falls back to UTC",
+			zone:      "Nowhere/Special",
+			departure: "2026-03-08T23:30:00Z",
+			on:        date(2026, 3, 8),
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			departure, err := time.Parse(time.RFC3339, tt.departure)
+			if err != nil {
+				t.Fatal(err)
+			}
+			flight := &Flight{DepartureTime: departure, DepartureTimezone: tt.zone}
+			if got := flight.DepartsOn(tt.on); got != tt.want {
+				t.Errorf("DepartsOn(%s) = %v, want %v", tt.on.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	// Create stores the key, failing with gorm.ErrDuplicatedKey if its
+	// prefix is taken
+	Create(ctx context.Context, key *entity.APIKey) error
+	// FindByPrefix returns the unrevoked key with the prefix, or
+	// gorm.ErrRecordNotFound
+	FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	// FindAll lists the unrevoked keys, oldest first
+	FindAll(ctx context.Context) ([]entity.APIKey, error)
+	// Delete revokes a key, failing with ErrAPIKeyNotFound if there is no
+	// unrevoked key with the ID
+	Delete(ctx context.Context, id uint) error
+	// Touch records that the key was used at the given time
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	if err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	err := r.db.WithContext(ctx).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&entity.APIKey{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	// Leaves updated_at alone, which tracks changes to the key itself
+	return r.db.WithContext(ctx).Model(&entity.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
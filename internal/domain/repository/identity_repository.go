@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserIdentityRepository interface {
+	// Find returns the identity for the provider's subject, or
+	// gorm.ErrRecordNotFound
+	Find(ctx context.Context, provider, subject string) (*entity.UserIdentity, error)
+	// Create links the identity, failing with gorm.ErrDuplicatedKey if the
+	// provider's subject is already linked
+	Create(ctx context.Context, identity *entity.UserIdentity) error
+}
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) Find(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+type OIDCLoginRepository interface {
+	// Create stores the login, first clearing out expired ones
+	Create(ctx context.Context, login *entity.OIDCLogin) error
+	// Consume deletes and returns the unexpired login with the state hash,
+	// failing with ErrInvalidOIDCState if there is none. Only one of
+	// several concurrent completions succeeds.
+	Consume(ctx context.Context, stateHash string, now time.Time) (*entity.OIDCLogin, error)
+}
+
+type oidcLoginRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCLoginRepository(db *gorm.DB) OIDCLoginRepository {
+	return &oidcLoginRepository{db: db}
+}
+
+func (r *oidcLoginRepository) Create(ctx context.Context, login *entity.OIDCLogin) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("expires_at <= ?", login.CreatedAt.UTC()).Delete(&entity.OIDCLogin{}).Error; err != nil {
+		return err
+	}
+	return db.Create(login).Error
+}
+
+func (r *oidcLoginRepository) Consume(ctx context.Context, stateHash string, now time.Time) (*entity.OIDCLogin, error) {
+	var logins []entity.OIDCLogin
+	err := r.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("state_hash = ?", stateHash).
+		Delete(&logins).Error
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if len(logins) == 0 || !logins[0].ExpiresAt.After(now) {
+		return nil, errors.ErrInvalidOIDCState
+	}
+	return &logins[0], nil
+}
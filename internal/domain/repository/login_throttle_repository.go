@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository interface {
+	// Find returns the throttles that exist among keys
+	Find(ctx context.Context, keys ...string) ([]entity.LoginThrottle, error)
+	// Update applies update to the throttle for key, starting from an
+	// empty one if there is none, and saves the result. Concurrent updates
+	// of the same key are serialised so no failure is lost.
+	Update(ctx context.Context, key string, update func(*entity.LoginThrottle)) (*entity.LoginThrottle, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Find(ctx context.Context, keys ...string) ([]entity.LoginThrottle, error) {
+	var throttles []entity.LoginThrottle
+	if err := r.db.WithContext(ctx).Where("key IN ?", keys).Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+func (r *loginThrottleRepository) Update(ctx context.Context, key string, update func(*entity.LoginThrottle)) (*entity.LoginThrottle, error) {
+	var throttle entity.LoginThrottle
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Make sure the row exists so there is something to lock
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&entity.LoginThrottle{Key: key}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&throttle).Error; err != nil {
+			return err
+		}
+
+		update(&throttle)
+		return tx.Save(&throttle).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&entity.LoginThrottle{}).Error
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/pkg/errors"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	store *Store
+}
+
+func NewAPIKeyRepository(store *Store) repository.APIKeyRepository {
+	return &apiKeyRepository{store: store}
+}
+
+// Create stores the key, rejecting a prefix in use like the database's
+// unique index, which covers revoked keys too
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.apiKeys.rows {
+		if existing.Prefix == key.Prefix {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	row := *key
+	row.Scopes = slices.Clone(key.Scopes)
+	if err := r.store.apiKeys.insert(&row, r.store.now()); err != nil {
+		return err
+	}
+	key.Model = row.Model
+	return nil
+}
+
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	keys := r.store.apiKeys.find(func(k *entity.APIKey) bool { return k.Prefix == prefix })
+	if len(keys) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &keys[0], nil
+}
+
+func (r *apiKeyRepository) FindAll(ctx context.Context) ([]entity.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.apiKeys.find(nil), nil
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.store.apiKeys.softDelete(id, r.store.now()) {
+		return errors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if key, ok := r.store.apiKeys.get(id); ok {
+		key.LastUsedAt = &at
+		r.store.apiKeys.rows[id] = key
+	}
+	return nil
+}
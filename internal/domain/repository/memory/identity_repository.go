@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/pkg/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type userIdentityRepository struct {
+	store *Store
+}
+
+func NewUserIdentityRepository(store *Store) repository.UserIdentityRepository {
+	return &userIdentityRepository{store: store}
+}
+
+func (r *userIdentityRepository) Find(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	identities := r.find(provider, subject)
+	if len(identities) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &identities[0], nil
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if len(r.find(identity.Provider, identity.Subject)) > 0 {
+		return gorm.ErrDuplicatedKey
+	}
+	return r.store.userIdentities.insert(identity, r.store.now())
+}
+
+// find looks up the provider's subject; the caller holds the lock
+func (r *userIdentityRepository) find(provider, subject string) []entity.UserIdentity {
+	return r.store.userIdentities.find(func(i *entity.UserIdentity) bool {
+		return i.Provider == provider && i.Subject == subject
+	})
+}
+
+type oidcLoginRepository struct {
+	store *Store
+}
+
+func NewOIDCLoginRepository(store *Store) repository.OIDCLoginRepository {
+	return &oidcLoginRepository{store: store}
+}
+
+func (r *oidcLoginRepository) Create(ctx context.Context, login *entity.OIDCLogin) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for hash, existing := range r.store.oidcLogins {
+		if !existing.ExpiresAt.After(login.CreatedAt) {
+			delete(r.store.oidcLogins, hash)
+		}
+	}
+	if _, exists := r.store.oidcLogins[login.StateHash]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	r.store.oidcLogins[login.StateHash] = *login
+	return nil
+}
+
+func (r *oidcLoginRepository) Consume(ctx context.Context, stateHash string, now time.Time) (*entity.OIDCLogin, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	login, ok := r.store.oidcLogins[stateHash]
+	delete(r.store.oidcLogins, stateHash)
+	if !ok || !login.ExpiresAt.After(now) {
+		return nil, errors.ErrInvalidOIDCState
+	}
+	return &login, nil
+}
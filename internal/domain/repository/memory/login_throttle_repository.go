@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+)
+
+type loginThrottleRepository struct {
+	store *Store
+}
+
+func NewLoginThrottleRepository(store *Store) repository.LoginThrottleRepository {
+	return &loginThrottleRepository{store: store}
+}
+
+func (r *loginThrottleRepository) Find(ctx context.Context, keys ...string) ([]entity.LoginThrottle, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var throttles []entity.LoginThrottle
+	for _, key := range keys {
+		if throttle, ok := r.store.loginThrottles[key]; ok {
+			throttles = append(throttles, throttle)
+		}
+	}
+	return throttles, nil
+}
+
+func (r *loginThrottleRepository) Update(ctx context.Context, key string, update func(*entity.LoginThrottle)) (*entity.LoginThrottle, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	throttle, ok := r.store.loginThrottles[key]
+	if !ok {
+		throttle = entity.LoginThrottle{Key: key}
+	}
+	update(&throttle)
+	r.store.loginThrottles[key] = throttle
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) Delete(ctx context.Context, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.store.loginThrottles, key)
+	return nil
+}
@@ -0,0 +1,683 @@
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Flight Repository
+type flightRepository struct {
+	store *Store
+}
+
+func NewFlightRepository(store *Store) repository.FlightRepository {
+	return &flightRepository{store: store}
+}
+
+// Create stores the flight and its fares, like gorm saving the association
+func (r *flightRepository) Create(ctx context.Context, flight *entity.Flight) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := r.store.now()
+	row := *flight
+	row.Fares = nil
+	if err := r.store.flights.insert(&row, now); err != nil {
+		return err
+	}
+	flight.Model = row.Model
+	return r.saveFares(flight, now)
+}
+
+func (r *flightRepository) Update(ctx context.Context, flight *entity.Flight) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := r.store.now()
+	row := *flight
+	row.Fares = nil
+	if err := r.store.flights.save(&row, now); err != nil {
+		return err
+	}
+	flight.Model = row.Model
+	return r.saveFares(flight, now)
+}
+
+func (r *flightRepository) saveFares(flight *entity.Flight, now time.Time) error {
+	for i := range flight.Fares {
+		flight.Fares[i].FlightID = flight.ID
+		if err := r.store.fares.save(&flight.Fares[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *flightRepository) Delete(ctx context.Context, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.flights.softDelete(id, r.store.now())
+	return nil
+}
+
+func (r *flightRepository) FindByID(ctx context.Context, id uint) (*entity.Flight, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	flight, ok := r.store.flights.get(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	flight.Fares = r.fares(flight.ID, nil)
+	return &flight, nil
+}
+
+func (r *flightRepository) Search(ctx context.Context, params repository.FlightSearchParams) ([]entity.Flight, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Same rules as the database query: fares must seat every passenger,
+	// flights without such a fare are skipped, and departure dates are
+	// matched in the origin airport's timezone
+	from, to := util.CalendarDayWindow(params.DepartureDate)
+	available := func(fare *entity.FareClass) bool {
+		return fare.AvailableSeats >= params.Passengers && (params.Class == "" || fare.Cabin == params.Class)
+	}
+
+	var matched []entity.Flight
+	for _, flight := range r.store.flights.find(func(f *entity.Flight) bool {
+		return f.DepartureCity == params.DepartureCity &&
+			f.ArrivalCity == params.ArrivalCity &&
+			!f.DepartureTime.Before(from) && f.DepartureTime.Before(to)
+	}) {
+		flight.Fares = r.fares(flight.ID, available)
+		if len(flight.Fares) > 0 && flight.DepartsOn(params.DepartureDate) {
+			matched = append(matched, flight)
+		}
+	}
+	return matched, nil
+}
+
+func (r *flightRepository) FindAll(ctx context.Context) ([]entity.Flight, error) {
+	return r.findFlights(nil), nil
+}
+
+func (r *flightRepository) FindByOrigin(ctx context.Context, origin string) ([]entity.Flight, error) {
+	return r.findFlights(func(f *entity.Flight) bool { return f.DepartureCity == origin }), nil
+}
+
+func (r *flightRepository) findFlights(keep func(*entity.Flight) bool) []entity.Flight {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	flights := r.store.flights.find(keep)
+	for i := range flights {
+		flights[i].Fares = r.fares(flights[i].ID, nil)
+	}
+	return flights
+}
+
+// fares returns a flight's fares matching keep. The caller holds the lock.
+func (r *flightRepository) fares(flightID uint, keep func(*entity.FareClass) bool) []entity.FareClass {
+	return r.store.fares.find(func(f *entity.FareClass) bool {
+		return f.FlightID == flightID && (keep == nil || keep(f))
+	})
+}
+
+func (r *flightRepository) FindFare(ctx context.Context, flightID, fareID uint) (*entity.FareClass, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	fare, ok := r.store.fares.get(fareID)
+	if !ok || fare.FlightID != flightID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &fare, nil
+}
+
+func (r *flightRepository) ReserveFareSeats(ctx context.Context, fareID uint, seats int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	fare, ok := r.store.fares.get(fareID)
+	if !ok || fare.AvailableSeats < seats {
+		return errors.ErrInsufficientSeats
+	}
+	fare.AvailableSeats -= seats
+	r.store.fares.put(fare, r.store.now())
+	return nil
+}
+
+func (r *flightRepository) ReleaseFareSeats(ctx context.Context, fareID uint, seats int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if fare, ok := r.store.fares.get(fareID); ok {
+		fare.AvailableSeats += seats
+		r.store.fares.put(fare, r.store.now())
+	}
+	return nil
+}
+
+// Seat Repository
+type seatRepository struct {
+	store *Store
+}
+
+func NewSeatRepository(store *Store) repository.SeatRepository {
+	return &seatRepository{store: store}
+}
+
+// CreateMany stores all the seats or none, rejecting duplicate seat numbers
+// on a flight like the database's unique index
+func (r *seatRepository) CreateMany(ctx context.Context, seats []entity.Seat) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	taken := make(map[entity.Seat]bool)
+	key := func(s *entity.Seat) entity.Seat {
+		return entity.Seat{FlightID: s.FlightID, Row: s.Row, Letter: s.Letter}
+	}
+	for _, seat := range r.store.seats.find(nil) {
+		taken[key(&seat)] = true
+	}
+	for i := range seats {
+		k := key(&seats[i])
+		if taken[k] {
+			return gorm.ErrDuplicatedKey
+		}
+		taken[k] = true
+	}
+
+	now := r.store.now()
+	for i := range seats {
+		if err := r.store.seats.insert(&seats[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *seatRepository) FindByFlightID(ctx context.Context, flightID uint) ([]entity.Seat, error) {
+	return r.findSeats(func(s *entity.Seat) bool { return s.FlightID == flightID }), nil
+}
+
+func (r *seatRepository) FindByBookingID(ctx context.Context, bookingID uint) ([]entity.Seat, error) {
+	return r.findSeats(func(s *entity.Seat) bool { return s.BookingID != nil && *s.BookingID == bookingID }), nil
+}
+
+func (r *seatRepository) findSeats(keep func(*entity.Seat) bool) []entity.Seat {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	seats := r.store.seats.find(keep)
+	sort.SliceStable(seats, func(i, j int) bool {
+		if seats[i].Row != seats[j].Row {
+			return seats[i].Row < seats[j].Row
+		}
+		return seats[i].Letter < seats[j].Letter
+	})
+	return seats
+}
+
+// Assign replaces the seats held by a booking, leaving them unchanged if any
+// requested seat is taken, as the database transaction does
+func (r *seatRepository) Assign(ctx context.Context, bookingID uint, seatIDs []uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, id := range seatIDs {
+		seat, ok := r.store.seats.get(id)
+		if !ok || seat.Blocked || (seat.BookingID != nil && *seat.BookingID != bookingID) {
+			return errors.ErrSeatUnavailable
+		}
+	}
+	if len(seatIDs) != len(uniqueIDs(seatIDs)) {
+		return errors.ErrSeatUnavailable
+	}
+
+	now := r.store.now()
+	r.release(bookingID, now)
+	for _, id := range seatIDs {
+		seat, _ := r.store.seats.get(id)
+		seat.BookingID = &bookingID
+		r.store.seats.put(seat, now)
+	}
+	return nil
+}
+
+func (r *seatRepository) ReleaseByBookingID(ctx context.Context, bookingID uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.release(bookingID, r.store.now())
+	return nil
+}
+
+func (r *seatRepository) release(bookingID uint, now time.Time) {
+	for _, seat := range r.store.seats.find(func(s *entity.Seat) bool {
+		return s.BookingID != nil && *s.BookingID == bookingID
+	}) {
+		seat.BookingID = nil
+		r.store.seats.put(seat, now)
+	}
+}
+
+func uniqueIDs(ids []uint) []uint {
+	unique := slices.Clone(ids)
+	slices.Sort(unique)
+	return slices.Compact(unique)
+}
+
+// Hotel Repository
+type hotelRepository struct {
+	store *Store
+}
+
+func NewHotelRepository(store *Store) repository.HotelRepository {
+	return &hotelRepository{store: store}
+}
+
+func (r *hotelRepository) Create(ctx context.Context, hotel *entity.Hotel) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	row := *hotel
+	row.Amenities = slices.Clone(hotel.Amenities)
+	if err := r.store.hotels.insert(&row, r.store.now()); err != nil {
+		return err
+	}
+	hotel.Model = row.Model
+	return nil
+}
+
+func (r *hotelRepository) Update(ctx context.Context, hotel *entity.Hotel) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	row := *hotel
+	row.Amenities = slices.Clone(hotel.Amenities)
+	if err := r.store.hotels.save(&row, r.store.now()); err != nil {
+		return err
+	}
+	hotel.Model = row.Model
+	return nil
+}
+
+func (r *hotelRepository) Delete(ctx context.Context, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.hotels.softDelete(id, r.store.now())
+	return nil
+}
+
+func (r *hotelRepository) FindByID(ctx context.Context, id uint) (*entity.Hotel, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	hotel, ok := r.store.hotels.get(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	hotel.Amenities = slices.Clone(hotel.Amenities)
+	return &hotel, nil
+}
+
+func (r *hotelRepository) Search(ctx context.Context, params repository.HotelSearchParams) ([]entity.Hotel, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	hotels := r.store.hotels.find(func(h *entity.Hotel) bool {
+		return h.City == params.City &&
+			h.AvailableRooms > 0 &&
+			(params.MaxPrice == nil || h.Price <= *params.MaxPrice) &&
+			(params.MinRating == nil || h.Rating >= *params.MinRating)
+	})
+	for i := range hotels {
+		hotels[i].Amenities = slices.Clone(hotels[i].Amenities)
+	}
+	return hotels, nil
+}
+
+func (r *hotelRepository) ReserveRooms(ctx context.Context, hotelID uint, rooms int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	hotel, ok := r.store.hotels.get(hotelID)
+	if !ok || hotel.AvailableRooms < rooms {
+		return errors.ErrNoRoomsAvailable
+	}
+	hotel.AvailableRooms -= rooms
+	r.store.hotels.put(hotel, r.store.now())
+	return nil
+}
+
+func (r *hotelRepository) ReleaseRooms(ctx context.Context, hotelID uint, rooms int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if hotel, ok := r.store.hotels.get(hotelID); ok {
+		hotel.AvailableRooms += rooms
+		r.store.hotels.put(hotel, r.store.now())
+	}
+	return nil
+}
+
+// Booking Repository
+type bookingRepository struct {
+	store *Store
+}
+
+func NewBookingRepository(store *Store) repository.BookingRepository {
+	return &bookingRepository{store: store}
+}
+
+func (r *bookingRepository) FindByID(ctx context.Context, id uint) (*entity.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	booking, ok := r.store.bookings.get(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	booking.Travellers = r.travellers(booking.ID)
+	return &booking, nil
+}
+
+func (r *bookingRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	bookings := r.store.bookings.find(func(b *entity.Booking) bool { return b.UserID == userID })
+	for i := range bookings {
+		bookings[i].Travellers = r.travellers(bookings[i].ID)
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepository) FindExpiredHolds(ctx context.Context, now time.Time) ([]entity.Booking, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.bookings.find(func(b *entity.Booking) bool {
+		return b.Status == "held" && b.HoldExpiresAt != nil && !b.HoldExpiresAt.After(now)
+	}), nil
+}
+
+// travellers returns a booking's travellers. The caller holds the lock.
+func (r *bookingRepository) travellers(bookingID uint) []entity.Traveller {
+	return r.store.travellers.find(func(t *entity.Traveller) bool { return t.BookingID == bookingID })
+}
+
+// Create stores the booking and its travellers
+func (r *bookingRepository) Create(ctx context.Context, booking *entity.Booking) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := r.store.now()
+	row := *booking
+	row.Travellers = nil
+	if err := r.store.bookings.insert(&row, now); err != nil {
+		return err
+	}
+	booking.Model = row.Model
+
+	for i := range booking.Travellers {
+		booking.Travellers[i].BookingID = booking.ID
+		if err := r.store.travellers.insert(&booking.Travellers[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *bookingRepository) Update(ctx context.Context, id uint, updates map[string]interface{}) error {
+	_, err := r.updateWhere(id, updates, func(*entity.Booking) bool { return true })
+	return err
+}
+
+func (r *bookingRepository) UpdateIfStatus(ctx context.Context, id uint, statuses []string, updates map[string]interface{}) (bool, error) {
+	return r.updateWhere(id, updates, func(b *entity.Booking) bool {
+		return slices.Contains(statuses, b.Status)
+	})
+}
+
+func (r *bookingRepository) ConfirmHold(ctx context.Context, id uint, now time.Time, updates map[string]interface{}) (bool, error) {
+	return r.updateWhere(id, updates, func(b *entity.Booking) bool {
+		return b.Status == "held" && b.HoldExpiresAt != nil && b.HoldExpiresAt.After(now)
+	})
+}
+
+// updateWhere applies updates to a booking if it matches, reporting whether
+// it did
+func (r *bookingRepository) updateWhere(id uint, updates map[string]interface{}, match func(*entity.Booking) bool) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	booking, ok := r.store.bookings.get(id)
+	if !ok || !match(&booking) {
+		return false, nil
+	}
+	if err := applyUpdates(&booking, updates); err != nil {
+		return false, err
+	}
+	r.store.bookings.put(booking, r.store.now())
+	return true, nil
+}
+
+// Saved Traveller Repository
+type savedTravellerRepository struct {
+	store *Store
+}
+
+func NewSavedTravellerRepository(store *Store) repository.SavedTravellerRepository {
+	return &savedTravellerRepository{store: store}
+}
+
+func (r *savedTravellerRepository) Create(ctx context.Context, traveller *entity.SavedTraveller) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.savedTravellers.insert(traveller, r.store.now())
+}
+
+func (r *savedTravellerRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.SavedTraveller, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.savedTravellers.find(func(t *entity.SavedTraveller) bool { return t.UserID == userID }), nil
+}
+
+func (r *savedTravellerRepository) FindByIDs(ctx context.Context, userID uuid.UUID, ids []uint) ([]entity.SavedTraveller, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.savedTravellers.find(func(t *entity.SavedTraveller) bool {
+		return t.UserID == userID && slices.Contains(ids, t.ID)
+	}), nil
+}
+
+func (r *savedTravellerRepository) Delete(ctx context.Context, userID uuid.UUID, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	traveller, ok := r.store.savedTravellers.get(id)
+	if !ok || traveller.UserID != userID {
+		return errors.ErrTravellerNotFound
+	}
+	r.store.savedTravellers.softDelete(id, r.store.now())
+	return nil
+}
+
+// Pricing Rule Repository
+type pricingRuleRepository struct {
+	store *Store
+}
+
+func NewPricingRuleRepository(store *Store) repository.PricingRuleRepository {
+	return &pricingRuleRepository{store: store}
+}
+
+func (r *pricingRuleRepository) Create(ctx context.Context, rule *entity.PricingRule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.pricingRules.insert(rule, r.store.now())
+}
+
+func (r *pricingRuleRepository) Update(ctx context.Context, rule *entity.PricingRule) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.pricingRules.save(rule, r.store.now())
+}
+
+func (r *pricingRuleRepository) Delete(ctx context.Context, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.pricingRules.softDelete(id, r.store.now())
+	return nil
+}
+
+func (r *pricingRuleRepository) FindByID(ctx context.Context, id uint) (*entity.PricingRule, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rule, ok := r.store.pricingRules.get(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rule, nil
+}
+
+func (r *pricingRuleRepository) FindAll(ctx context.Context) ([]entity.PricingRule, error) {
+	return r.findRules(nil), nil
+}
+
+func (r *pricingRuleRepository) FindActive(ctx context.Context, product string) ([]entity.PricingRule, error) {
+	return r.findRules(func(rule *entity.PricingRule) bool {
+		return rule.Active && (rule.Product == product || rule.Product == "")
+	}), nil
+}
+
+// findRules returns matching rules ordered by priority, then ID
+func (r *pricingRuleRepository) findRules(keep func(*entity.PricingRule) bool) []entity.PricingRule {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rules := r.store.pricingRules.find(keep)
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].Priority < rules[j].Priority })
+	return rules
+}
+
+// Saved Search Repository
+type savedSearchRepository struct {
+	store *Store
+}
+
+func NewSavedSearchRepository(store *Store) repository.SavedSearchRepository {
+	return &savedSearchRepository{store: store}
+}
+
+func (r *savedSearchRepository) Create(ctx context.Context, search *entity.SavedSearch) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.savedSearches.insert(search, r.store.now())
+}
+
+func (r *savedSearchRepository) Update(ctx context.Context, search *entity.SavedSearch) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.savedSearches.save(search, r.store.now())
+}
+
+func (r *savedSearchRepository) Delete(ctx context.Context, id uint) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.savedSearches.softDelete(id, r.store.now())
+	return nil
+}
+
+func (r *savedSearchRepository) FindByID(ctx context.Context, id uint) (*entity.SavedSearch, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	search, ok := r.store.savedSearches.get(id)
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &search, nil
+}
+
+func (r *savedSearchRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.SavedSearch, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.savedSearches.find(func(s *entity.SavedSearch) bool { return s.UserID == userID }), nil
+}
+
+func (r *savedSearchRepository) FindDue(ctx context.Context, lastRunBefore time.Time) ([]entity.SavedSearch, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return r.store.savedSearches.find(func(s *entity.SavedSearch) bool {
+		return s.Active && (s.LastRunAt == nil || s.LastRunAt.Before(lastRunBefore))
+	}), nil
+}
+
+// Price Alert Repository
+type priceAlertRepository struct {
+	store *Store
+}
+
+func NewPriceAlertRepository(store *Store) repository.PriceAlertRepository {
+	return &priceAlertRepository{store: store}
+}
+
+func (r *priceAlertRepository) Create(ctx context.Context, alert *entity.PriceAlert) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.priceAlerts.insert(alert, r.store.now())
+}
+
+// FindByUserID returns the user's alerts, newest first
+func (r *priceAlertRepository) FindByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]entity.PriceAlert, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	alerts := r.store.priceAlerts.find(func(a *entity.PriceAlert) bool {
+		return a.UserID == userID && (!unreadOnly || a.ReadAt == nil)
+	})
+	slices.Reverse(alerts)
+	sort.SliceStable(alerts, func(i, j int) bool { return alerts[i].CreatedAt.After(alerts[j].CreatedAt) })
+	return alerts, nil
+}
+
+func (r *priceAlertRepository) MarkRead(ctx context.Context, userID uuid.UUID, id uint, readAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	alert, ok := r.store.priceAlerts.get(id)
+	if !ok || alert.UserID != userID {
+		return errors.ErrPriceAlertNotFound
+	}
+	alert.ReadAt = &readAt
+	r.store.priceAlerts.put(alert, r.store.now())
+	return nil
+}
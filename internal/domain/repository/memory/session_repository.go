@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/pkg/errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) repository.SessionRepository {
+	return &sessionRepository{store: store}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, existing := range r.store.sessions {
+		if existing.UserID == session.UserID && !existing.ExpiresAt.After(session.CreatedAt) {
+			delete(r.store.sessions, id)
+		}
+	}
+	if _, exists := r.store.sessions[session.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	r.store.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *sessionRepository) Find(ctx context.Context, id uuid.UUID) (*entity.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id uuid.UUID, ip string, at time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if session, ok := r.store.sessions[id]; ok {
+		session.IPAddress, session.LastSeenAt = ip, at
+		r.store.sessions[id] = session
+	}
+	return nil
+}
+
+func (r *sessionRepository) FindActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var sessions []entity.Session
+	for _, session := range r.store.sessions {
+		if session.UserID == userID && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok || session.UserID != userID {
+		return errors.ErrSessionNotFound
+	}
+	delete(r.store.sessions, id)
+	return nil
+}
+
+func (r *sessionRepository) DeleteOthers(ctx context.Context, userID, keep uuid.UUID) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deleted int64
+	for id, session := range r.store.sessions {
+		if session.UserID == userID && id != keep {
+			delete(r.store.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
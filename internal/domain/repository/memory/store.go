@@ -0,0 +1,183 @@
+// Package memory implements the repository interfaces with thread-safe
+// in-memory storage, for running the API without a database and for fast
+// service tests. It mirrors the GORM repositories' behaviour: soft deletes,
+// gorm.ErrRecordNotFound for missing rows and atomic inventory updates.
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Store holds every table. A single lock keeps operations that span
+// tables, such as loading flights with their fares, consistent.
+type Store struct {
+	mu sync.RWMutex
+
+	users           map[uuid.UUID]entity.User
+	preferences     map[uuid.UUID]entity.UserPreferences
+	loginThrottles  map[string]entity.LoginThrottle
+	userTokens      *table[entity.UserToken]
+	recoveryCodes   *table[entity.RecoveryCode]
+	userIdentities  *table[entity.UserIdentity]
+	oidcLogins      map[string]entity.OIDCLogin
+	sessions        map[uuid.UUID]entity.Session
+	apiKeys         *table[entity.APIKey]
+	flights         *table[entity.Flight]
+	fares           *table[entity.FareClass]
+	seats           *table[entity.Seat]
+	hotels          *table[entity.Hotel]
+	bookings        *table[entity.Booking]
+	travellers      *table[entity.Traveller]
+	savedTravellers *table[entity.SavedTraveller]
+	pricingRules    *table[entity.PricingRule]
+	savedSearches   *table[entity.SavedSearch]
+	priceAlerts     *table[entity.PriceAlert]
+
+	now func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:           make(map[uuid.UUID]entity.User),
+		preferences:     make(map[uuid.UUID]entity.UserPreferences),
+		loginThrottles:  make(map[string]entity.LoginThrottle),
+		userTokens:      newTable(func(t *entity.UserToken) *gorm.Model { return &t.Model }),
+		recoveryCodes:   newTable(func(c *entity.RecoveryCode) *gorm.Model { return &c.Model }),
+		userIdentities:  newTable(func(i *entity.UserIdentity) *gorm.Model { return &i.Model }),
+		oidcLogins:      make(map[string]entity.OIDCLogin),
+		sessions:        make(map[uuid.UUID]entity.Session),
+		apiKeys:         newTable(func(k *entity.APIKey) *gorm.Model { return &k.Model }),
+		flights:         newTable(func(f *entity.Flight) *gorm.Model { return &f.Model }),
+		fares:           newTable(func(f *entity.FareClass) *gorm.Model { return &f.Model }),
+		seats:           newTable(func(s *entity.Seat) *gorm.Model { return &s.Model }),
+		hotels:          newTable(func(h *entity.Hotel) *gorm.Model { return &h.Model }),
+		bookings:        newTable(func(b *entity.Booking) *gorm.Model { return &b.Model }),
+		travellers:      newTable(func(t *entity.Traveller) *gorm.Model { return &t.Model }),
+		savedTravellers: newTable(func(t *entity.SavedTraveller) *gorm.Model { return &t.Model }),
+		pricingRules:    newTable(func(r *entity.PricingRule) *gorm.Model { return &r.Model }),
+		savedSearches:   newTable(func(s *entity.SavedSearch) *gorm.Model { return &s.Model }),
+		priceAlerts:     newTable(func(a *entity.PriceAlert) *gorm.Model { return &a.Model }),
+		now:             time.Now,
+	}
+}
+
+// table is an auto-incrementing, soft-deleting collection of one entity
+type table[T any] struct {
+	rows   map[uint]T
+	nextID uint
+	model  func(*T) *gorm.Model
+}
+
+func newTable[T any](model func(*T) *gorm.Model) *table[T] {
+	return &table[T]{
+		rows:  make(map[uint]T),
+		model: model,
+	}
+}
+
+// insert assigns the row an ID, unless it already has one, and timestamps
+func (t *table[T]) insert(row *T, now time.Time) error {
+	m := t.model(row)
+	if m.ID == 0 {
+		t.nextID++
+		m.ID = t.nextID
+	} else if _, exists := t.rows[m.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	} else if m.ID > t.nextID {
+		t.nextID = m.ID
+	}
+	m.CreatedAt, m.UpdatedAt = now, now
+	t.rows[m.ID] = *row
+	return nil
+}
+
+// save inserts a new row or replaces an existing one, like gorm's Save
+func (t *table[T]) save(row *T, now time.Time) error {
+	m := t.model(row)
+	existing, ok := t.rows[m.ID]
+	if m.ID == 0 || !ok {
+		return t.insert(row, now)
+	}
+	m.CreatedAt = t.model(&existing).CreatedAt
+	m.UpdatedAt = now
+	t.rows[m.ID] = *row
+	return nil
+}
+
+// get returns a live row by ID
+func (t *table[T]) get(id uint) (T, bool) {
+	row, ok := t.rows[id]
+	if !ok || t.model(&row).DeletedAt.Valid {
+		var zero T
+		return zero, false
+	}
+	return row, true
+}
+
+// put writes back a row read with get, bumping its update time
+func (t *table[T]) put(row T, now time.Time) {
+	m := t.model(&row)
+	m.UpdatedAt = now
+	t.rows[m.ID] = row
+}
+
+// find returns the live rows matching keep, ordered by ID
+func (t *table[T]) find(keep func(*T) bool) []T {
+	var rows []T
+	for _, row := range t.rows {
+		if t.model(&row).DeletedAt.Valid {
+			continue
+		}
+		if keep == nil || keep(&row) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return t.model(&rows[i]).ID < t.model(&rows[j]).ID
+	})
+	return rows
+}
+
+// softDelete marks a live row deleted, reporting whether there was one
+func (t *table[T]) softDelete(id uint, now time.Time) bool {
+	row, ok := t.get(id)
+	if !ok {
+		return false
+	}
+	t.model(&row).DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	t.rows[id] = row
+	return true
+}
+
+var schemaCache sync.Map
+
+// applyUpdates sets fields of row by column name, as gorm's Updates does
+// with a map
+func applyUpdates(row interface{}, updates map[string]interface{}) error {
+	s, err := schema.Parse(row, &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(row).Elem()
+	for column, v := range updates {
+		field := s.LookUpField(column)
+		if field == nil {
+			return fmt.Errorf("unknown column %q", column)
+		}
+		if err := field.Set(context.Background(), value, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package memory
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/pkg/errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+// Create stores the user, rejecting duplicate IDs and emails like the
+// database's primary key and case-insensitive unique email index
+func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, exists := r.store.users[user.ID]; exists {
+		return gorm.ErrDuplicatedKey
+	}
+	for _, existing := range r.store.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return errors.ErrEmailAlreadyExists
+		}
+	}
+
+	now := r.store.now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	row := *user
+	row.Bookings = nil
+	r.store.users[user.ID] = row
+	return nil
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if strings.EqualFold(user.Email, email) && !user.DeletedAt.Valid {
+			return &user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindPreferences(ctx context.Context, userID uuid.UUID) (*entity.UserPreferences, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	preferences, ok := r.store.preferences[userID]
+	if !ok || preferences.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &preferences, nil
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.update(id, func(user *entity.User) bool {
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &at
+		}
+		return true
+	})
+}
+
+func (r *userRepository) SetPassword(ctx context.Context, id uuid.UUID, hash string, verifiedAt time.Time) error {
+	return r.update(id, func(user *entity.User) bool {
+		user.Password = hash
+		if user.EmailVerifiedAt == nil {
+			user.EmailVerifiedAt = &verifiedAt
+		}
+		return true
+	})
+}
+
+func (r *userRepository) ClearPassword(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	err := r.update(id, func(user *entity.User) bool {
+		if user.EmailVerifiedAt != nil {
+			return false
+		}
+		user.Password, user.EmailVerifiedAt = "", &verifiedAt
+		return true
+	})
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	return err
+}
+
+func (r *userRepository) SetTOTP(ctx context.Context, id uuid.UUID, secret string, step int64, enabledAt *time.Time) error {
+	return r.update(id, func(user *entity.User) bool {
+		user.TOTPSecret, user.TOTPLastStep, user.TwoFactorEnabledAt = secret, step, enabledAt
+		return true
+	})
+}
+
+func (r *userRepository) EnableTOTP(ctx context.Context, id uuid.UUID, secret string, at time.Time) error {
+	err := r.update(id, func(user *entity.User) bool {
+		if user.TOTPSecret != secret || user.TwoFactorEnabledAt != nil {
+			return false
+		}
+		user.TwoFactorEnabledAt = &at
+		return true
+	})
+	if err == gorm.ErrRecordNotFound {
+		return errors.ErrMFANotSetUp
+	}
+	return err
+}
+
+// update applies change to the stored user, failing with ErrRecordNotFound
+// if there is no such user or change reports it matched nothing
+func (r *userRepository) update(id uuid.UUID, change func(*entity.User) bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok || user.DeletedAt.Valid || !change(&user) {
+		return gorm.ErrRecordNotFound
+	}
+	user.UpdatedAt = r.store.now()
+	r.store.users[id] = user
+	return nil
+}
+
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok || user.TOTPLastStep >= step {
+		return errors.ErrInvalidMFACode
+	}
+	user.TOTPLastStep = step
+	user.UpdatedAt = r.store.now()
+	r.store.users[id] = user
+	return nil
+}
+
+type userTokenRepository struct {
+	store *Store
+}
+
+func NewUserTokenRepository(store *Store) repository.UserTokenRepository {
+	return &userTokenRepository{store: store}
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *entity.UserToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.userTokens.rows {
+		if existing.TokenHash == token.TokenHash {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	return r.store.userTokens.insert(token, r.store.now())
+}
+
+func (r *userTokenRepository) Find(ctx context.Context, hash, purpose string, now time.Time) (*entity.UserToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tokens := r.store.userTokens.find(func(t *entity.UserToken) bool {
+		return t.TokenHash == hash && t.Purpose == purpose && t.UsedAt == nil && t.ExpiresAt.After(now)
+	})
+	if len(tokens) == 0 {
+		return nil, errors.ErrInvalidEmailToken
+	}
+	return &tokens[0], nil
+}
+
+func (r *userTokenRepository) Consume(ctx context.Context, hash, purpose string, now time.Time) (*entity.UserToken, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	tokens := r.store.userTokens.find(func(t *entity.UserToken) bool {
+		return t.TokenHash == hash && t.Purpose == purpose
+	})
+	if len(tokens) == 0 || tokens[0].UsedAt != nil || !tokens[0].ExpiresAt.After(now) {
+		return nil, errors.ErrInvalidEmailToken
+	}
+	token := tokens[0]
+	token.UsedAt = &now
+	r.store.userTokens.put(token, r.store.now())
+	return &token, nil
+}
+
+func (r *userTokenRepository) Revoke(ctx context.Context, userID uuid.UUID, purpose string, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, token := range r.store.userTokens.find(func(t *entity.UserToken) bool {
+		return t.UserID == userID && t.Purpose == purpose && t.UsedAt == nil
+	}) {
+		token.UsedAt = &now
+		r.store.userTokens.put(token, r.store.now())
+	}
+	return nil
+}
+
+type recoveryCodeRepository struct {
+	store *Store
+}
+
+func NewRecoveryCodeRepository(store *Store) repository.RecoveryCodeRepository {
+	return &recoveryCodeRepository{store: store}
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, codes []entity.RecoveryCode) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.deleteAll(userID)
+	now := r.store.now()
+	for i := range codes {
+		if err := r.store.recoveryCodes.insert(&codes[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	codes := r.store.recoveryCodes.find(func(c *entity.RecoveryCode) bool {
+		return c.UserID == userID && c.CodeHash == hash && c.UsedAt == nil
+	})
+	if len(codes) == 0 {
+		return errors.ErrInvalidMFACode
+	}
+	codes[0].UsedAt = &now
+	r.store.recoveryCodes.put(codes[0], r.store.now())
+	return nil
+}
+
+func (r *recoveryCodeRepository) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.deleteAll(userID)
+	return nil
+}
+
+// deleteAll removes the user's codes outright; the caller holds the lock
+func (r *recoveryCodeRepository) deleteAll(userID uuid.UUID) {
+	for id, code := range r.store.recoveryCodes.rows {
+		if code.UserID == userID {
+			delete(r.store.recoveryCodes.rows, id)
+		}
+	}
+}
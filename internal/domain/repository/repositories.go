@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -49,6 +51,9 @@ type FlightRepository interface {
 	Search(ctx context.Context, params FlightSearchParams) ([]entity.Flight, error)
 	FindAll(ctx context.Context) ([]entity.Flight, error)
 	FindByOrigin(ctx context.Context, origin string) ([]entity.Flight, error)
+	FindFare(ctx context.Context, flightID, fareID uint) (*entity.FareClass, error)
+	ReserveFareSeats(ctx context.Context, fareID uint, seats int) error
+	ReleaseFareSeats(ctx context.Context, fareID uint, seats int) error
 }
 
 type FlightSearchParams struct {
@@ -57,7 +62,7 @@ type FlightSearchParams struct {
 	DepartureDate time.Time
 	ReturnDate    *time.Time
 	Passengers    int
-	Class         string
+	Class         string // cabin; all cabins if empty
 }
 
 type flightRepository struct {
@@ -70,22 +75,52 @@ func NewFlightRepository(db *gorm.DB) FlightRepository {
 
 func (r *flightRepository) Search(ctx context.Context, params FlightSearchParams) ([]entity.Flight, error) {
 	var flights []entity.Flight
+	// DepartureDate is a calendar date in the origin airport's timezone, which
+	// is only known per flight, so query a window covering every UTC offset
+	// and keep the flights that leave on that date locally.
+	from, to := util.CalendarDayWindow(params.DepartureDate)
+	// Only fares that can seat every passenger are loaded, and flights
+	// without any such fare are skipped.
+	availableFares := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("available_seats >= ?", params.Passengers)
+		if params.Class != "" {
+			db = db.Where("cabin = ?", params.Class)
+		}
+		return db
+	}
+
 	query := r.db.WithContext(ctx).
+		Preload("Fares", availableFares).
 		Where("departure_city = ? AND arrival_city = ?", params.DepartureCity, params.ArrivalCity).
-		Where("departure_time >= ? AND departure_time <= ?",
-			params.DepartureDate, params.DepartureDate.Add(24*time.Hour)).
-		Where("available_seats >= ?", params.Passengers).
-		Where("class = ?", params.Class)
+		Where("departure_time >= ? AND departure_time < ?", from, to).
+		Where("EXISTS (?)", availableFares(r.db.Model(&entity.FareClass{}).
+			Select("1").
+			Where("fare_classes.flight_id = flights.id")))
 
 	if err := query.Find(&flights).Error; err != nil {
 		return nil, err
 	}
-	return flights, nil
+
+	matched := flights[:0]
+	for _, flight := range flights {
+		if flight.DepartsOn(params.DepartureDate) {
+			matched = append(matched, flight)
+		}
+	}
+	return matched, nil
+}
+
+func (r *flightRepository) FindByID(ctx context.Context, id uint) (*entity.Flight, error) {
+	var flight entity.Flight
+	if err := r.db.WithContext(ctx).Preload("Fares").First(&flight, id).Error; err != nil {
+		return nil, err
+	}
+	return &flight, nil
 }
 
 func (r *flightRepository) FindAll(ctx context.Context) ([]entity.Flight, error) {
 	var flights []entity.Flight
-	if err := r.db.WithContext(ctx).Find(&flights).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Fares").Find(&flights).Error; err != nil {
 		return nil, err
 	}
 	return flights, nil
@@ -94,6 +129,7 @@ func (r *flightRepository) FindAll(ctx context.Context) ([]entity.Flight, error)
 func (r *flightRepository) FindByOrigin(ctx context.Context, origin string) ([]entity.Flight, error) {
 	var flights []entity.Flight
 	if err := r.db.WithContext(ctx).
+		Preload("Fares").
 		Where("departure_city = ?", origin).
 		Find(&flights).Error; err != nil {
 		return nil, err
@@ -101,10 +137,118 @@ func (r *flightRepository) FindByOrigin(ctx context.Context, origin string) ([]e
 	return flights, nil
 }
 
+func (r *flightRepository) FindFare(ctx context.Context, flightID, fareID uint) (*entity.FareClass, error) {
+	var fare entity.FareClass
+	if err := r.db.WithContext(ctx).
+		Where("flight_id = ?", flightID).
+		First(&fare, fareID).Error; err != nil {
+		return nil, err
+	}
+	return &fare, nil
+}
+
+// ReserveFareSeats takes seats from a fare's inventory, failing with
+// ErrInsufficientSeats rather than overselling under concurrent bookings
+func (r *flightRepository) ReserveFareSeats(ctx context.Context, fareID uint, seats int) error {
+	result := r.db.WithContext(ctx).Model(&entity.FareClass{}).
+		Where("id = ? AND available_seats >= ?", fareID, seats).
+		Update("available_seats", gorm.Expr("available_seats - ?", seats))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrInsufficientSeats
+	}
+	return nil
+}
+
+func (r *flightRepository) ReleaseFareSeats(ctx context.Context, fareID uint, seats int) error {
+	return r.db.WithContext(ctx).Model(&entity.FareClass{}).
+		Where("id = ?", fareID).
+		Update("available_seats", gorm.Expr("available_seats + ?", seats)).Error
+}
+
+// Seat Repository
+type SeatRepository interface {
+	CreateMany(ctx context.Context, seats []entity.Seat) error
+	FindByFlightID(ctx context.Context, flightID uint) ([]entity.Seat, error)
+	FindByBookingID(ctx context.Context, bookingID uint) ([]entity.Seat, error)
+	Assign(ctx context.Context, bookingID uint, seatIDs []uint) error
+	ReleaseByBookingID(ctx context.Context, bookingID uint) error
+}
+
+type seatRepository struct {
+	db *gorm.DB
+}
+
+func NewSeatRepository(db *gorm.DB) SeatRepository {
+	return &seatRepository{db: db}
+}
+
+func (r *seatRepository) CreateMany(ctx context.Context, seats []entity.Seat) error {
+	return r.db.WithContext(ctx).Create(&seats).Error
+}
+
+func (r *seatRepository) FindByFlightID(ctx context.Context, flightID uint) ([]entity.Seat, error) {
+	var seats []entity.Seat
+	if err := r.db.WithContext(ctx).
+		Where("flight_id = ?", flightID).
+		Order("seat_row, letter").
+		Find(&seats).Error; err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
+func (r *seatRepository) FindByBookingID(ctx context.Context, bookingID uint) ([]entity.Seat, error) {
+	var seats []entity.Seat
+	if err := r.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Order("seat_row, letter").
+		Find(&seats).Error; err != nil {
+		return nil, err
+	}
+	return seats, nil
+}
+
+// Assign replaces the seats held by a booking. Each seat is only claimed if
+// it is still free, so concurrent selections of the same seat cannot both
+// succeed; the loser gets ErrSeatUnavailable and keeps its previous seats.
+func (r *seatRepository) Assign(ctx context.Context, bookingID uint, seatIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.Seat{}).
+			Where("booking_id = ?", bookingID).
+			Update("booking_id", nil).Error; err != nil {
+			return err
+		}
+
+		for _, id := range seatIDs {
+			result := tx.Model(&entity.Seat{}).
+				Where("id = ? AND booking_id IS NULL AND blocked = ?", id, false).
+				Update("booking_id", bookingID)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.ErrSeatUnavailable
+			}
+		}
+		return nil
+	})
+}
+
+func (r *seatRepository) ReleaseByBookingID(ctx context.Context, bookingID uint) error {
+	return r.db.WithContext(ctx).Model(&entity.Seat{}).
+		Where("booking_id = ?", bookingID).
+		Update("booking_id", nil).Error
+}
+
 // Hotel Repository
 type HotelRepository interface {
 	Repository[entity.Hotel]
 	Search(ctx context.Context, params HotelSearchParams) ([]entity.Hotel, error)
+	ReserveRooms(ctx context.Context, hotelID uint, rooms int) error
+	ReleaseRooms(ctx context.Context, hotelID uint, rooms int) error
 }
 
 type HotelSearchParams struct {
@@ -132,7 +276,7 @@ func (r *hotelRepository) Search(ctx context.Context, params HotelSearchParams)
 		Where("available_rooms > 0")
 
 	if params.MaxPrice != nil {
-		query = query.Where("price_per_night <= ?", *params.MaxPrice)
+		query = query.Where("price <= ?", *params.MaxPrice)
 	}
 	if params.MinRating != nil {
 		query = query.Where("rating >= ?", *params.MinRating)
@@ -144,12 +288,36 @@ func (r *hotelRepository) Search(ctx context.Context, params HotelSearchParams)
 	return hotels, nil
 }
 
+// ReserveRooms takes rooms from a hotel's inventory, failing with
+// ErrNoRoomsAvailable rather than overbooking under concurrent bookings
+func (r *hotelRepository) ReserveRooms(ctx context.Context, hotelID uint, rooms int) error {
+	result := r.db.WithContext(ctx).Model(&entity.Hotel{}).
+		Where("id = ? AND available_rooms >= ?", hotelID, rooms).
+		Update("available_rooms", gorm.Expr("available_rooms - ?", rooms))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrNoRoomsAvailable
+	}
+	return nil
+}
+
+func (r *hotelRepository) ReleaseRooms(ctx context.Context, hotelID uint, rooms int) error {
+	return r.db.WithContext(ctx).Model(&entity.Hotel{}).
+		Where("id = ?", hotelID).
+		Update("available_rooms", gorm.Expr("available_rooms + ?", rooms)).Error
+}
+
 // Booking Repository
 type BookingRepository interface {
 	FindByID(ctx context.Context, id uint) (*entity.Booking, error)
 	FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Booking, error)
+	FindExpiredHolds(ctx context.Context, now time.Time) ([]entity.Booking, error)
 	Create(ctx context.Context, booking *entity.Booking) error
 	Update(ctx context.Context, id uint, updates map[string]interface{}) error
+	UpdateIfStatus(ctx context.Context, id uint, statuses []string, updates map[string]interface{}) (bool, error)
+	ConfirmHold(ctx context.Context, id uint, now time.Time, updates map[string]interface{}) (bool, error)
 }
 
 type bookingRepository struct {
@@ -162,7 +330,7 @@ func NewBookingRepository(db *gorm.DB) BookingRepository {
 
 func (r *bookingRepository) FindByID(ctx context.Context, id uint) (*entity.Booking, error) {
 	var booking entity.Booking
-	if err := r.db.WithContext(ctx).First(&booking, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Travellers").First(&booking, id).Error; err != nil {
 		return nil, err
 	}
 	return &booking, nil
@@ -170,7 +338,17 @@ func (r *bookingRepository) FindByID(ctx context.Context, id uint) (*entity.Book
 
 func (r *bookingRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.Booking, error) {
 	var bookings []entity.Booking
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Travellers").Where("user_id = ?", userID).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+func (r *bookingRepository) FindExpiredHolds(ctx context.Context, now time.Time) ([]entity.Booking, error) {
+	var bookings []entity.Booking
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND hold_expires_at <= ?", "held", now.UTC()).
+		Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
@@ -183,3 +361,187 @@ func (r *bookingRepository) Create(ctx context.Context, booking *entity.Booking)
 func (r *bookingRepository) Update(ctx context.Context, id uint, updates map[string]interface{}) error {
 	return r.db.WithContext(ctx).Model(&entity.Booking{}).Where("id = ?", id).Updates(updates).Error
 }
+
+// UpdateIfStatus applies updates only while the booking is in one of the
+// given statuses, reporting whether it did. Status transitions go through
+// here so that racing transitions, such as a user cancelling a hold as it
+// expires, only take effect once.
+func (r *bookingRepository) UpdateIfStatus(ctx context.Context, id uint, statuses []string, updates map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Booking{}).
+		Where("id = ? AND status IN ?", id, statuses).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ConfirmHold applies updates to a held booking only if its hold has not
+// expired, reporting whether it did
+func (r *bookingRepository) ConfirmHold(ctx context.Context, id uint, now time.Time, updates map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Booking{}).
+		Where("id = ? AND status = ? AND hold_expires_at > ?", id, "held", now.UTC()).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Saved Traveller Repository
+type SavedTravellerRepository interface {
+	Create(ctx context.Context, traveller *entity.SavedTraveller) error
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.SavedTraveller, error)
+	FindByIDs(ctx context.Context, userID uuid.UUID, ids []uint) ([]entity.SavedTraveller, error)
+	Delete(ctx context.Context, userID uuid.UUID, id uint) error
+}
+
+type savedTravellerRepository struct {
+	db *gorm.DB
+}
+
+func NewSavedTravellerRepository(db *gorm.DB) SavedTravellerRepository {
+	return &savedTravellerRepository{db: db}
+}
+
+func (r *savedTravellerRepository) Create(ctx context.Context, traveller *entity.SavedTraveller) error {
+	return r.db.WithContext(ctx).Create(traveller).Error
+}
+
+func (r *savedTravellerRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.SavedTraveller, error) {
+	var travellers []entity.SavedTraveller
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&travellers).Error; err != nil {
+		return nil, err
+	}
+	return travellers, nil
+}
+
+func (r *savedTravellerRepository) FindByIDs(ctx context.Context, userID uuid.UUID, ids []uint) ([]entity.SavedTraveller, error) {
+	var travellers []entity.SavedTraveller
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Find(&travellers).Error; err != nil {
+		return nil, err
+	}
+	return travellers, nil
+}
+
+func (r *savedTravellerRepository) Delete(ctx context.Context, userID uuid.UUID, id uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.SavedTraveller{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrTravellerNotFound
+	}
+	return nil
+}
+
+// Pricing Rule Repository
+type PricingRuleRepository interface {
+	Repository[entity.PricingRule]
+	FindAll(ctx context.Context) ([]entity.PricingRule, error)
+	FindActive(ctx context.Context, product string) ([]entity.PricingRule, error)
+}
+
+type pricingRuleRepository struct {
+	baseRepository[entity.PricingRule]
+}
+
+func NewPricingRuleRepository(db *gorm.DB) PricingRuleRepository {
+	return &pricingRuleRepository{baseRepository[entity.PricingRule]{db: db}}
+}
+
+func (r *pricingRuleRepository) FindAll(ctx context.Context) ([]entity.PricingRule, error) {
+	var rules []entity.PricingRule
+	if err := r.db.WithContext(ctx).Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *pricingRuleRepository) FindActive(ctx context.Context, product string) ([]entity.PricingRule, error) {
+	var rules []entity.PricingRule
+	if err := r.db.WithContext(ctx).
+		Where("active = ?", true).
+		Where("product = ? OR product = ''", product).
+		Order("priority, id").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Saved Search Repository
+type SavedSearchRepository interface {
+	Repository[entity.SavedSearch]
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.SavedSearch, error)
+	FindDue(ctx context.Context, lastRunBefore time.Time) ([]entity.SavedSearch, error)
+}
+
+type savedSearchRepository struct {
+	baseRepository[entity.SavedSearch]
+}
+
+func NewSavedSearchRepository(db *gorm.DB) SavedSearchRepository {
+	return &savedSearchRepository{baseRepository[entity.SavedSearch]{db: db}}
+}
+
+func (r *savedSearchRepository) FindByUserID(ctx context.Context, userID uuid.UUID) ([]entity.SavedSearch, error) {
+	var searches []entity.SavedSearch
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&searches).Error; err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// FindDue returns active searches that have not run since the given time
+func (r *savedSearchRepository) FindDue(ctx context.Context, lastRunBefore time.Time) ([]entity.SavedSearch, error) {
+	var searches []entity.SavedSearch
+	if err := r.db.WithContext(ctx).
+		Where("active = ?", true).
+		Where("last_run_at IS NULL OR last_run_at < ?", lastRunBefore.UTC()).
+		Order("id").
+		Find(&searches).Error; err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// Price Alert Repository
+type PriceAlertRepository interface {
+	Create(ctx context.Context, alert *entity.PriceAlert) error
+	FindByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]entity.PriceAlert, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, id uint, readAt time.Time) error
+}
+
+type priceAlertRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceAlertRepository(db *gorm.DB) PriceAlertRepository {
+	return &priceAlertRepository{db: db}
+}
+
+func (r *priceAlertRepository) Create(ctx context.Context, alert *entity.PriceAlert) error {
+	return r.db.WithContext(ctx).Create(alert).Error
+}
+
+func (r *priceAlertRepository) FindByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]entity.PriceAlert, error) {
+	var alerts []entity.PriceAlert
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at DESC").Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *priceAlertRepository) MarkRead(ctx context.Context, userID uuid.UUID, id uint, readAt time.Time) error {
+	result := r.db.WithContext(ctx).Model(&entity.PriceAlert{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", readAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrPriceAlertNotFound
+	}
+	return nil
+}
@@ -0,0 +1,685 @@
+package repository
+
+import (
+	"context"
+	stderrors "errors"
+	"fledge-restapi/internal/config"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/migrations"
+	"fledge-restapi/pkg/errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// openTestDB returns a fresh SQLite database with every migration applied
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := config.InitDB(config.DatabaseConfig{
+		Driver:   "sqlite",
+		Path:     filepath.Join(t.TempDir(), "fledge.db"),
+		LogLevel: "silent",
+	})
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("applying migrations: %v", err)
+	}
+	return db
+}
+
+func mustParse(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func createUser(t *testing.T, db *gorm.DB, email string) *entity.User {
+	t.Helper()
+	user := &entity.User{ID: uuid.New(), Email: email, Password: "hash"}
+	if err := NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func createFlight(t *testing.T, db *gorm.DB, flight entity.Flight) *entity.Flight {
+	t.Helper()
+	flight.ArrivalTime = flight.DepartureTime.Add(7 * time.Hour)
+	if err := NewFlightRepository(db).Create(context.Background(), &flight); err != nil {
+		t.Fatal(err)
+	}
+	return &flight
+}
+
+func flightNumbers(flights []entity.Flight) []string {
+	numbers := make([]string, len(flights))
+	for i, f := range flights {
+		numbers[i] = f.FlightNumber
+	}
+	slices.Sort(numbers)
+	return numbers
+}
+
+func fareCodes(flights []entity.Flight) map[string][]string {
+	codes := make(map[string][]string)
+	for _, f := range flights {
+		for _, fare := range f.Fares {
+			codes[f.FlightNumber] = append(codes[f.FlightNumber], fare.Code)
+		}
+		slices.Sort(codes[f.FlightNumber])
+	}
+	return codes
+}
+
+func TestFlightRepositorySearch(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewFlightRepository(db)
+
+	economy := func(seats int) entity.FareClass {
+		return entity.FareClass{Cabin: "economy", Code: "Y", Price: 100, AvailableSeats: seats, Capacity: 100}
+	}
+	business := func(seats int) entity.FareClass {
+		return entity.FareClass{Cabin: "business", Code: "J", Price: 500, AvailableSeats: seats, Capacity: 10}
+	}
+	premium := func(seats int) entity.FareClass {
+		return entity.FareClass{Cabin: "premium", Code: "W", Price: 250, AvailableSeats: seats, Capacity: 20}
+	}
+	route := func(number, from, to, zone, departs string, fares ...entity.FareClass) entity.Flight {
+		return entity.Flight{
+			FlightNumber: number, Airline: "FL", DepartureCity: from, ArrivalCity: to,
+			DepartureTimezone: zone, DepartureTime: mustParse(t, departs), Fares: fares,
+		}
+	}
+
+	// 23:30 in New York on 8 March, already the 9th in UTC
+	createFlight(t, db, route("FL1", "NYC", "LON", "America/New_York", "2026-03-09T03:30:00Z", economy(10), business(2)))
+	// 08:00 in New York on 9 March, economy sold out
+	createFlight(t, db, route("FL2", "NYC", "LON", "America/New_York", "2026-03-09T12:00:00Z", economy(0), premium(5)))
+	createFlight(t, db, route("FL3", "NYC", "PAR", "America/New_York", "2026-03-08T15:00:00Z", economy(10)))
+	createFlight(t, db, route("FL4", "LON", "NYC", "Europe/London", "2026-03-08T00:30:00Z", economy(10)))
+	deleted := createFlight(t, db, route("FL5", "NYC", "LON", "America/New_York", "2026-03-08T14:00:00Z", economy(10)))
+	if err := repo.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	date := func(day int) time.Time { return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name   string
+		params FlightSearchParams
+		want   []string
+		fares  map[string][]string
+	}{
+		{
+			name:   "matches the local departure date rather than the UTC one",
+			params: FlightSearchParams{DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: date(8), Passengers: 1},
+			want:   []string{"FL1"},
+			fares:  map[string][]string{"FL1": {"J", "Y"}},
+		},
+		{
+			name:   "next day skips the flight leaving late the evening before",
+			params: FlightSearchParams{DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: date(9), Passengers: 1},
+			want:   []string{"FL2"},
+			fares:  map[string][]string{"FL2": {"W"}},
+		},
+		{
+			name:   "cabin loads only that cabin's fares",
+			params: FlightSearchParams{DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: date(8), Passengers: 1, Class: "business"},
+			want:   []string{"FL1"},
+			fares:  map[string][]string{"FL1": {"J"}},
+		},
+		{
+			name:   "fares without enough seats for every passenger are left out",
+			params: FlightSearchParams{DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: date(8), Passengers: 3},
+			want:   []string{"FL1"},
+			fares:  map[string][]string{"FL1": {"Y"}},
+		},
+		{
+			name:   "flights without any fare with enough seats are skipped",
+			params: FlightSearchParams{DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: date(8), Passengers: 11},
+			want:   []string{},
+		},
+		{
+			name:   "sold out cabin",
+			params: FlightSearchParams{DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: date(9), Passengers: 1, Class: "economy"},
+			want:   []string{},
+		},
+		{
+			name:   "direction matters",
+			params: FlightSearchParams{DepartureCity: "LON", ArrivalCity: "NYC", DepartureDate: date(8), Passengers: 1},
+			want:   []string{"FL4"},
+		},
+		{
+			name:   "no flights on the route",
+			params: FlightSearchParams{DepartureCity: "PAR", ArrivalCity: "NYC", DepartureDate: date(8), Passengers: 1},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flights, err := repo.Search(ctx, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := flightNumbers(flights); !slices.Equal(got, tt.want) {
+				t.Fatalf("flights = %v, want %v", got, tt.want)
+			}
+			codes := fareCodes(flights)
+			for number, want := range tt.fares {
+				if got := codes[number]; !slices.Equal(got, want) {
+					t.Errorf("%s fares = %v, want %v", number, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFlightRepositoryFareInventory(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewFlightRepository(db)
+	flight := createFlight(t, db, entity.Flight{
+		FlightNumber: "FL1", DepartureCity: "NYC", ArrivalCity: "LON",
+		DepartureTime: mustParse(t, "2026-03-09T03:30:00Z"),
+		Fares:         []entity.FareClass{{Cabin: "economy", Code: "Y", AvailableSeats: 3, Capacity: 3}},
+	})
+	fareID := flight.Fares[0].ID
+
+	tests := []struct {
+		name    string
+		reserve int
+		release int
+		wantErr error
+		left    int
+	}{
+		{name: "reserve some", reserve: 2, left: 1},
+		{name: "reserve more than are left", reserve: 2, wantErr: errors.ErrInsufficientSeats, left: 1},
+		{name: "release", release: 2, left: 3},
+		{name: "reserve every seat", reserve: 3, left: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.reserve > 0 {
+				if err := repo.ReserveFareSeats(ctx, fareID, tt.reserve); !stderrors.Is(err, tt.wantErr) {
+					t.Fatalf("ReserveFareSeats = %v, want %v", err, tt.wantErr)
+				}
+			}
+			if tt.release > 0 {
+				if err := repo.ReleaseFareSeats(ctx, fareID, tt.release); err != nil {
+					t.Fatal(err)
+				}
+			}
+			fare, err := repo.FindFare(ctx, flight.ID, fareID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fare.AvailableSeats != tt.left {
+				t.Errorf("available seats = %d, want %d", fare.AvailableSeats, tt.left)
+			}
+		})
+	}
+}
+
+func TestHotelRepositorySearch(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewHotelRepository(db)
+
+	hotels := []entity.Hotel{
+		{Name: "Grand", City: "Paris", Rating: 4.5, Price: 200, AvailableRooms: 5, TotalRooms: 10},
+		{Name: "Budget", City: "Paris", Rating: 3, Price: 90, AvailableRooms: 2, TotalRooms: 10},
+		{Name: "Palace", City: "Paris", Rating: 5, Price: 400, AvailableRooms: 0, TotalRooms: 10},
+		{Name: "Roma", City: "Rome", Rating: 4, Price: 150, AvailableRooms: 3, TotalRooms: 10},
+		{Name: "Closed", City: "Paris", Rating: 4, Price: 100, AvailableRooms: 5, TotalRooms: 10},
+	}
+	for i := range hotels {
+		if err := repo.Create(ctx, &hotels[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Delete(ctx, hotels[4].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	price := func(p float64) *float64 { return &p }
+	rating := func(r float32) *float32 { return &r }
+	tests := []struct {
+		name   string
+		params HotelSearchParams
+		want   []string
+	}{
+		{name: "city only skips sold out and deleted hotels", params: HotelSearchParams{City: "Paris"}, want: []string{"Budget", "Grand"}},
+		{name: "max price", params: HotelSearchParams{City: "Paris", MaxPrice: price(100)}, want: []string{"Budget"}},
+		{name: "max price is inclusive", params: HotelSearchParams{City: "Paris", MaxPrice: price(200)}, want: []string{"Budget", "Grand"}},
+		{name: "min rating", params: HotelSearchParams{City: "Paris", MinRating: rating(4)}, want: []string{"Grand"}},
+		{name: "min rating is inclusive", params: HotelSearchParams{City: "Paris", MinRating: rating(3)}, want: []string{"Budget", "Grand"}},
+		{name: "price and rating together", params: HotelSearchParams{City: "Paris", MaxPrice: price(150), MinRating: rating(4)}, want: []string{}},
+		{name: "other city", params: HotelSearchParams{City: "Rome"}, want: []string{"Roma"}},
+		{name: "unknown city", params: HotelSearchParams{City: "Oslo"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.Search(ctx, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := make([]string, len(found))
+			for i, h := range found {
+				names[i] = h.Name
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("hotels = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookingRepository(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewBookingRepository(db)
+	alice := createUser(t, db, "alice@example.com")
+	bob := createUser(t, db, "bob@example.com")
+	flight := createFlight(t, db, entity.Flight{
+		FlightNumber: "FL1", DepartureCity: "NYC", ArrivalCity: "LON",
+		DepartureTime: mustParse(t, "2026-03-09T03:30:00Z"),
+		Fares:         []entity.FareClass{{Cabin: "economy", Code: "Y", AvailableSeats: 10, Capacity: 10}},
+	})
+
+	now := mustParse(t, "2026-03-01T12:00:00Z")
+	book := func(user *entity.User, status string, holdFor time.Duration) *entity.Booking {
+		t.Helper()
+		expires := now.Add(holdFor)
+		booking := &entity.Booking{
+			UserID: user.ID, BookingType: "flight", FlightID: &flight.ID, FareClassID: &flight.Fares[0].ID,
+			Status: status, HoldExpiresAt: &expires, BookingDate: now, NumGuests: 1, SeatCount: 1,
+			Travellers: []entity.Traveller{{TravellerDetails: entity.TravellerDetails{
+				FirstName: "Ada", LastName: "Lovelace", PassengerType: "adult",
+			}, Price: 100}},
+		}
+		if err := repo.Create(ctx, booking); err != nil {
+			t.Fatal(err)
+		}
+		return booking
+	}
+
+	live := book(alice, "held", 10*time.Minute)
+	expired := book(alice, "held", -time.Minute)
+	expiresNow := book(alice, "held", 0)
+	confirmed := book(alice, "confirmed", -time.Hour)
+	bobs := book(bob, "held", -time.Minute)
+	deleted := book(alice, "held", -time.Minute)
+	if err := db.Delete(&entity.Booking{}, deleted.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(bookings []entity.Booking) []uint {
+		out := make([]uint, len(bookings))
+		for i, b := range bookings {
+			out[i] = b.ID
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	t.Run("find by ID loads travellers", func(t *testing.T) {
+		found, err := repo.FindByID(ctx, live.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found.Travellers) != 1 || found.Travellers[0].FirstName != "Ada" || found.Travellers[0].Price != 100 {
+			t.Errorf("travellers = %+v", found.Travellers)
+		}
+		if found.SeatCount != 1 || found.Status != "held" {
+			t.Errorf("booking = %+v", found)
+		}
+	})
+
+	t.Run("find by user", func(t *testing.T) {
+		tests := []struct {
+			user *entity.User
+			want []uint
+		}{
+			{alice, []uint{live.ID, expired.ID, expiresNow.ID, confirmed.ID}},
+			{bob, []uint{bobs.ID}},
+		}
+		for _, tt := range tests {
+			found, err := repo.FindByUserID(ctx, tt.user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(found); !slices.Equal(got, tt.want) {
+				t.Errorf("%s bookings = %v, want %v", tt.user.Email, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("expired holds", func(t *testing.T) {
+		tests := []struct {
+			name string
+			now  time.Time
+			want []uint
+		}{
+			{"at now", now, []uint{expired.ID, expiresNow.ID, bobs.ID}},
+			{"now given in another timezone", now.In(time.FixedZone("JST", 9*60*60)), []uint{expired.ID, expiresNow.ID, bobs.ID}},
+			{"before any hold expired", now.Add(-time.Hour), []uint{}},
+			{"after every hold expired", now.Add(time.Hour), []uint{live.ID, expired.ID, expiresNow.ID, bobs.ID}},
+		}
+		for _, tt := range tests {
+			found, err := repo.FindExpiredHolds(ctx, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(found); !slices.Equal(got, tt.want) {
+				t.Errorf("%s: expired holds = %v, want %v", tt.name, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("confirm hold", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			booking *entity.Booking
+			want    bool
+		}{
+			{"expired hold", expired, false},
+			{"hold expiring now", expiresNow, false},
+			{"already confirmed", confirmed, false},
+			{"live hold", live, true},
+			{"live hold twice", live, false},
+		}
+		for _, tt := range tests {
+			ok, err := repo.ConfirmHold(ctx, tt.booking.ID, now, map[string]interface{}{"status": "confirmed"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("%s: ConfirmHold = %v, want %v", tt.name, ok, tt.want)
+			}
+		}
+	})
+
+	t.Run("update if status", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			booking  *entity.Booking
+			statuses []string
+			want     bool
+			status   string
+		}{
+			{"held to expired", expired, []string{"held"}, true, "expired"},
+			{"only once", expired, []string{"held"}, false, "expired"},
+			{"any of several statuses", confirmed, []string{"held", "confirmed"}, true, "cancelled"},
+			{"soft deleted bookings are untouched", deleted, []string{"held"}, false, ""},
+		}
+		for _, tt := range tests {
+			ok, err := repo.UpdateIfStatus(ctx, tt.booking.ID, tt.statuses, map[string]interface{}{"status": tt.status})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.want {
+				t.Errorf("%s: UpdateIfStatus = %v, want %v", tt.name, ok, tt.want)
+			}
+			if tt.status == "" {
+				continue
+			}
+			found, err := repo.FindByID(ctx, tt.booking.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found.Status != tt.status {
+				t.Errorf("%s: status = %q, want %q", tt.name, found.Status, tt.status)
+			}
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		if err := repo.Update(ctx, bobs.ID, map[string]interface{}{"special_requests": "aisle"}); err != nil {
+			t.Fatal(err)
+		}
+		found, err := repo.FindByID(ctx, bobs.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found.SpecialRequests != "aisle" {
+			t.Errorf("special requests = %q", found.SpecialRequests)
+		}
+	})
+
+	t.Run("soft deleted booking is hidden", func(t *testing.T) {
+		if _, err := repo.FindByID(ctx, deleted.ID); !stderrors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("FindByID = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		var row entity.Booking
+		if err := db.Unscoped().First(&row, deleted.ID).Error; err != nil || !row.DeletedAt.Valid {
+			t.Errorf("soft deleted row = %+v, %v; want it kept with deleted_at set", row, err)
+		}
+	})
+}
+
+func TestSoftDeletes(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	flights := NewFlightRepository(db)
+	hotels := NewHotelRepository(db)
+
+	flight := createFlight(t, db, entity.Flight{
+		FlightNumber: "FL1", DepartureCity: "NYC", ArrivalCity: "LON",
+		DepartureTime: mustParse(t, "2026-03-09T03:30:00Z"),
+		Fares:         []entity.FareClass{{Cabin: "economy", Code: "Y", AvailableSeats: 10, Capacity: 10}},
+	})
+	hotel := &entity.Hotel{Name: "Grand", City: "Paris", AvailableRooms: 1}
+	if err := hotels.Create(ctx, hotel); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flights.Delete(ctx, flight.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := hotels.Delete(ctx, hotel.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		check func() (int, error)
+	}{
+		{"flight by ID", func() (int, error) {
+			_, err := flights.FindByID(ctx, flight.ID)
+			if stderrors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil
+			}
+			return 1, err
+		}},
+		{"all flights", func() (int, error) {
+			found, err := flights.FindAll(ctx)
+			return len(found), err
+		}},
+		{"flights by origin", func() (int, error) {
+			found, err := flights.FindByOrigin(ctx, "NYC")
+			return len(found), err
+		}},
+		{"flight search", func() (int, error) {
+			found, err := flights.Search(ctx, FlightSearchParams{
+				DepartureCity: "NYC", ArrivalCity: "LON", DepartureDate: time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC), Passengers: 1,
+			})
+			return len(found), err
+		}},
+		{"hotel by ID", func() (int, error) {
+			_, err := hotels.FindByID(ctx, hotel.ID)
+			if stderrors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil
+			}
+			return 1, err
+		}},
+		{"hotel search", func() (int, error) {
+			found, err := hotels.Search(ctx, HotelSearchParams{City: "Paris"})
+			return len(found), err
+		}},
+		{"rows are kept", func() (int, error) {
+			var count int64
+			if err := db.Unscoped().Model(&entity.Flight{}).Where("id = ? AND deleted_at IS NOT NULL", flight.ID).Count(&count).Error; err != nil {
+				return 0, err
+			}
+			return 1 - int(count), nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.check()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 0 {
+				t.Errorf("found %d deleted records", n)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryUpdatesOnlyTheirColumns(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	repo := NewUserRepository(db)
+	at := mustParse(t, "2026-03-01T12:00:00Z")
+	later := at.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		before entity.User
+		update func(id uuid.UUID) error
+		check  func(t *testing.T, user *entity.User)
+	}{
+		{
+			name:   "verifying the email keeps two-factor authentication enabled meanwhile",
+			before: entity.User{TOTPSecret: "sealed", TwoFactorEnabledAt: &at},
+			update: func(id uuid.UUID) error { return repo.MarkEmailVerified(ctx, id, later) },
+			check: func(t *testing.T, user *entity.User) {
+				if user.TwoFactorEnabledAt == nil || user.TOTPSecret != "sealed" || user.EmailVerifiedAt == nil {
+					t.Errorf("user = %+v", user)
+				}
+			},
+		},
+		{
+			name:   "verifying again keeps the first time",
+			before: entity.User{EmailVerifiedAt: &at},
+			update: func(id uuid.UUID) error { return repo.MarkEmailVerified(ctx, id, later) },
+			check: func(t *testing.T, user *entity.User) {
+				if !user.EmailVerifiedAt.Equal(at) {
+					t.Errorf("verified at %s, want %s", user.EmailVerifiedAt, at)
+				}
+			},
+		},
+		{
+			name:   "resetting the password keeps the TOTP secret",
+			before: entity.User{TOTPSecret: "sealed", TOTPLastStep: 7, TwoFactorEnabledAt: &at},
+			update: func(id uuid.UUID) error { return repo.SetPassword(ctx, id, "new-hash", later) },
+			check: func(t *testing.T, user *entity.User) {
+				if user.Password != "new-hash" || user.TOTPSecret != "sealed" || user.TOTPLastStep != 7 || user.TwoFactorEnabledAt == nil {
+					t.Errorf("user = %+v", user)
+				}
+				if !user.EmailVerifiedAt.Equal(later) {
+					t.Errorf("verified at %v, want %s", user.EmailVerifiedAt, later)
+				}
+			},
+		},
+		{
+			name:   "clearing an unverified account's password",
+			before: entity.User{},
+			update: func(id uuid.UUID) error { return repo.ClearPassword(ctx, id, later) },
+			check: func(t *testing.T, user *entity.User) {
+				if user.Password != "" || user.EmailVerifiedAt == nil {
+					t.Errorf("user = %+v", user)
+				}
+			},
+		},
+		{
+			name:   "clearing leaves a verified account's password",
+			before: entity.User{EmailVerifiedAt: &at},
+			update: func(id uuid.UUID) error { return repo.ClearPassword(ctx, id, later) },
+			check: func(t *testing.T, user *entity.User) {
+				if user.Password != "hash" || !user.EmailVerifiedAt.Equal(at) {
+					t.Errorf("user = %+v", user)
+				}
+			},
+		},
+		{
+			name:   "enabling two-factor authentication keeps the step already used",
+			before: entity.User{TOTPSecret: "sealed", TOTPLastStep: 7},
+			update: func(id uuid.UUID) error { return repo.EnableTOTP(ctx, id, "sealed", later) },
+			check: func(t *testing.T, user *entity.User) {
+				if user.TwoFactorEnabledAt == nil || user.TOTPLastStep != 7 {
+					t.Errorf("user = %+v", user)
+				}
+			},
+		},
+		{
+			name:   "enabling with a secret another setup replaced",
+			before: entity.User{TOTPSecret: "newer"},
+			update: func(id uuid.UUID) error {
+				if err := repo.EnableTOTP(ctx, id, "sealed", later); err != errors.ErrMFANotSetUp {
+					return fmt.Errorf("EnableTOTP = %v, want %v", err, errors.ErrMFANotSetUp)
+				}
+				return nil
+			},
+			check: func(t *testing.T, user *entity.User) {
+				if user.TwoFactorEnabledAt != nil {
+					t.Errorf("enabled with a stale secret: %+v", user)
+				}
+			},
+		},
+		{
+			name:   "disabling two-factor authentication keeps the email verified",
+			before: entity.User{TOTPSecret: "sealed", TOTPLastStep: 7, TwoFactorEnabledAt: &at, EmailVerifiedAt: &at},
+			update: func(id uuid.UUID) error { return repo.SetTOTP(ctx, id, "", 0, nil) },
+			check: func(t *testing.T, user *entity.User) {
+				if user.TOTPSecret != "" || user.TOTPLastStep != 0 || user.TwoFactorEnabledAt != nil || user.EmailVerifiedAt == nil {
+					t.Errorf("user = %+v", user)
+				}
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := tt.before
+			user.ID, user.Email, user.Password = uuid.New(), fmt.Sprintf("user%d@example.com", i), "hash"
+			if err := repo.Create(ctx, &user); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.update(user.ID); err != nil {
+				t.Fatal(err)
+			}
+			stored, err := repo.FindByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, stored)
+		})
+	}
+
+	if err := repo.SetPassword(ctx, uuid.New(), "hash", at); !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("SetPassword for no user = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
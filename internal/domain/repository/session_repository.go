@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	// Create stores the session, first clearing out the user's expired ones
+	Create(ctx context.Context, session *entity.Session) error
+	// Find returns the session with the ID, or gorm.ErrRecordNotFound
+	Find(ctx context.Context, id uuid.UUID) (*entity.Session, error)
+	// Touch records that the session was used from ip at the given time
+	Touch(ctx context.Context, id uuid.UUID, ip string, at time.Time) error
+	// FindActiveByUser lists the user's unexpired sessions, most recently
+	// seen first
+	FindActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error)
+	// Delete revokes one of the user's sessions, failing with
+	// ErrSessionNotFound if they have none with the ID
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// DeleteOthers revokes every session of the user's but keep, which may
+	// be uuid.Nil to revoke them all, and returns how many it revoked
+	DeleteOthers(ctx context.Context, userID, keep uuid.UUID) (int64, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	db := r.db.WithContext(ctx)
+	err := db.Where("user_id = ? AND expires_at <= ?", session.UserID, session.CreatedAt.UTC()).
+		Delete(&entity.Session{}).Error
+	if err != nil {
+		return err
+	}
+	return db.Create(session).Error
+}
+
+func (r *sessionRepository) Find(ctx context.Context, id uuid.UUID) (*entity.Session, error) {
+	var session entity.Session
+	if err := r.db.WithContext(ctx).First(&session, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id uuid.UUID, ip string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"ip_address": ip, "last_seen_at": at}).Error
+}
+
+func (r *sessionRepository) FindActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, now.UTC()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepository) DeleteOthers(ctx context.Context, userID, keep uuid.UUID) (int64, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND id <> ?", userID, keep).Delete(&entity.Session{})
+	return result.RowsAffected, result.Error
+}
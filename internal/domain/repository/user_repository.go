@@ -2,14 +2,40 @@ package repository
 
 import (
 	"context"
+	stderrors "errors"
 	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
-	FindByID(ctx context.Context, id uint) (*entity.User, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	FindPreferences(ctx context.Context, userID uuid.UUID) (*entity.UserPreferences, error)
+	// MarkEmailVerified records that the user proved they own their email,
+	// keeping the first time if they already had
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error
+	// SetPassword replaces the password hash and marks the email verified,
+	// for a password set through a link sent to it
+	SetPassword(ctx context.Context, id uuid.UUID, hash string, verifiedAt time.Time) error
+	// ClearPassword drops the password of a user whose email isn't
+	// verified, marking it verified at verifiedAt. It does nothing if the
+	// email is already verified.
+	ClearPassword(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error
+	// SetTOTP sets the TOTP secret, the last step used and when two-factor
+	// authentication was enabled
+	SetTOTP(ctx context.Context, id uuid.UUID, secret string, step int64, enabledAt *time.Time) error
+	// EnableTOTP turns two-factor authentication on at at, failing with
+	// ErrMFANotSetUp unless secret is still the user's pending secret
+	EnableTOTP(ctx context.Context, id uuid.UUID, secret string, at time.Time) error
+	// AdvanceTOTPStep records that a TOTP code for step was used, failing
+	// with ErrInvalidMFACode if it or a later one already was
+	AdvanceTOTPStep(ctx context.Context, id uuid.UUID, step int64) error
 }
 
 type userRepository struct {
@@ -20,22 +46,225 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// Create adds the user, failing with ErrEmailAlreadyExists if the email is
+// taken in any letter case
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	err := r.db.WithContext(ctx).Create(user).Error
+	if stderrors.Is(err, gorm.ErrDuplicatedKey) {
+		return errors.ErrEmailAlreadyExists
+	}
+	return err
 }
 
+// FindByEmail matches the email ignoring letter case
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("lower(email) = ?", strings.ToLower(email)).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByID(ctx context.Context, id uint) (*entity.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
 	var user entity.User
-	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
-}
\ No newline at end of file
+}
+
+func (r *userRepository) FindPreferences(ctx context.Context, userID uuid.UUID) (*entity.UserPreferences, error) {
+	var preferences entity.UserPreferences
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// The methods changing a user each write only their own columns, so
+// concurrent changes to the same user don't undo each other
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", at.UTC()),
+	})
+}
+
+func (r *userRepository) SetPassword(ctx context.Context, id uuid.UUID, hash string, verifiedAt time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"password":          hash,
+		"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", verifiedAt.UTC()),
+	})
+}
+
+func (r *userRepository) ClearPassword(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ? AND email_verified_at IS NULL", id).
+		Updates(map[string]interface{}{"password": "", "email_verified_at": verifiedAt.UTC()}).Error
+}
+
+func (r *userRepository) SetTOTP(ctx context.Context, id uuid.UUID, secret string, step int64, enabledAt *time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"totp_secret":           secret,
+		"totp_last_step":        step,
+		"two_factor_enabled_at": enabledAt,
+	})
+}
+
+func (r *userRepository) EnableTOTP(ctx context.Context, id uuid.UUID, secret string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ? AND totp_secret = ? AND two_factor_enabled_at IS NULL", id, secret).
+		Update("two_factor_enabled_at", at.UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrMFANotSetUp
+	}
+	return nil
+}
+
+// update sets columns of the user, failing with ErrRecordNotFound if there
+// is no such user
+func (r *userRepository) update(ctx context.Context, id uuid.UUID, columns map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, id uuid.UUID, step int64) error {
+	result := r.db.WithContext(ctx).Model(&entity.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *entity.UserToken) error
+	// Find returns the unused, unexpired token with the hash and purpose,
+	// failing with ErrInvalidEmailToken if there is none
+	Find(ctx context.Context, hash, purpose string, now time.Time) (*entity.UserToken, error)
+	// Consume marks the unused, unexpired token with the hash and purpose
+	// used and returns it, failing with ErrInvalidEmailToken if there is
+	// none. Only one of several concurrent redemptions succeeds.
+	Consume(ctx context.Context, hash, purpose string, now time.Time) (*entity.UserToken, error)
+	// Revoke marks all the user's unused tokens for the purpose used
+	Revoke(ctx context.Context, userID uuid.UUID, purpose string, now time.Time) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(ctx context.Context, token *entity.UserToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *userTokenRepository) Find(ctx context.Context, hash, purpose string, now time.Time) (*entity.UserToken, error) {
+	var token entity.UserToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now.UTC()).
+		First(&token).Error
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.ErrInvalidEmailToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *userTokenRepository) Consume(ctx context.Context, hash, purpose string, now time.Time) (*entity.UserToken, error) {
+	var token entity.UserToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("token_hash = ? AND purpose = ?", hash, purpose).First(&token).Error; err != nil {
+			if stderrors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.ErrInvalidEmailToken
+			}
+			return err
+		}
+
+		result := tx.Model(&entity.UserToken{}).
+			Where("id = ? AND used_at IS NULL AND expires_at > ?", token.ID, now.UTC()).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.ErrInvalidEmailToken
+		}
+		token.UsedAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *userTokenRepository) Revoke(ctx context.Context, userID uuid.UUID, purpose string, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
+
+type RecoveryCodeRepository interface {
+	// Replace swaps the user's recovery codes for new ones
+	Replace(ctx context.Context, userID uuid.UUID, codes []entity.RecoveryCode) error
+	// Consume marks the user's unused code with the hash used, failing
+	// with ErrInvalidMFACode if there is none
+	Consume(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error
+	DeleteAll(ctx context.Context, userID uuid.UUID) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, codes []entity.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error {
+	result := r.db.WithContext(ctx).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (r *recoveryCodeRepository) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/service"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// ListKeys godoc
+// @Summary List API keys
+// @Tags admin
+// @Produce json
+// @Success 200 {array} entity.APIKey
+// @Security Bearer
+// @Router /v1/admin/api-keys [get]
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateKey godoc
+// @Summary Issue an API key
+// @Description Issue a key a partner's server can call the API with as the given account. The key is only shown in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key body entity.APIKeyRequest true "Account, scopes and limits"
+// @Success 201 {object} entity.NewAPIKeyResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/admin/api-keys [post]
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req entity.APIKeyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(c.Request.Context(), &req)
+	if err != nil {
+		var invalid *errors.ValidationError
+		switch {
+		case err == errors.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case stderrors.As(err, &invalid):
+			writeValidationError(c, invalid)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// RevokeKey godoc
+// @Summary Revoke an API key
+// @Tags admin
+// @Param id path int true "API key ID"
+// @Success 200 {object} entity.MessageResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeKey(c.Request.Context(), uint(id)); err != nil {
+		if err == errors.ErrAPIKeyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
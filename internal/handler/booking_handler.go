@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"fledge-restapi/internal/domain/entity"
 	"fledge-restapi/internal/service"
+	"fledge-restapi/pkg/errors"
 )
 
 type BookingHandler struct {
@@ -91,7 +93,7 @@ func (h *BookingHandler) UpdateBooking(c *gin.Context) {
 
 	err = h.bookingService.UpdateBooking(c.Request.Context(), uint(bookingID), userID, updates)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBookingError(c, err, "Failed to update booking")
 		return
 	}
 
@@ -117,9 +119,60 @@ func (h *BookingHandler) CancelBooking(c *gin.Context) {
 
 	err = h.bookingService.CancelBooking(c.Request.Context(), uint(bookingID), userID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBookingError(c, err, "Failed to cancel booking")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully"})
 }
+
+// ConfirmBooking godoc
+// @Summary Confirm a held booking
+// @Description Record payment for a held booking before its hold expires
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Param payment body entity.ConfirmBookingRequest true "Payment details"
+// @Success 200 {object} entity.Booking
+// @Failure 409 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/bookings/{id}/confirm [post]
+func (h *BookingHandler) ConfirmBooking(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req entity.ConfirmBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.bookingService.ConfirmBooking(c.Request.Context(), uint(bookingID), userID, &req)
+	if err != nil {
+		writeBookingError(c, err, "Failed to confirm booking")
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
+func writeBookingError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case errors.ErrBookingNotFound, errors.ErrBookingAccessDenied:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+	case errors.ErrBookingNotHeld, errors.ErrHoldExpired, errors.ErrBookingNotUpdatable:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.ErrFieldNotUpdatable, errors.ErrCancellationClosed:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"fledge-restapi/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user's ID, writing an error
+// response and returning false if it is missing or malformed
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// currentSessionID returns the session the request's access token was
+// issued to
+func currentSessionID(c *gin.Context) uuid.UUID {
+	id, _ := uuid.Parse(c.GetString("sessionID"))
+	return id
+}
+
+// client describes who sent the request
+func client(c *gin.Context) service.Client {
+	return service.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
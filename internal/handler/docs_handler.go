@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fledge-restapi/internal/openapi"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUI renders the spec served at /openapi.json
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Fledge API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+type DocsHandler struct {
+	spec *openapi.Document
+}
+
+func NewDocsHandler(spec *openapi.Document) *DocsHandler {
+	return &DocsHandler{
+		spec: spec,
+	}
+}
+
+// Spec serves the OpenAPI document
+func (h *DocsHandler) Spec(c *gin.Context) {
+	c.JSON(http.StatusOK, h.spec)
+}
+
+// UI serves Swagger UI for browsing the OpenAPI document
+func (h *DocsHandler) UI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+}
@@ -28,9 +28,9 @@ func NewFlightHandler(flightService service.FlightService) *FlightHandler {
 // @Accept json
 // @Produce json
 // @Param search body entity.FlightSearchRequest true "Flight search criteria"
-// @Success 200 {array} entity.Flight
+// @Success 200 {array} entity.FlightResponse
 // @Failure 400 {object} errors.ErrorResponse
-// @Router /api/flights/search [POST]
+// @Router /v1/flights/search [POST]
 func (h *FlightHandler) SearchFlights(c *gin.Context) {
 	var req entity.FlightSearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -44,7 +44,7 @@ func (h *FlightHandler) SearchFlights(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, flights)
+	c.JSON(http.StatusOK, entity.NewFlightResponses(flights))
 }
 
 // GetFlight godoc
@@ -54,9 +54,9 @@ func (h *FlightHandler) SearchFlights(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Flight ID"
-// @Success 200 {object} entity.Flight
+// @Success 200 {object} entity.FlightResponse
 // @Failure 404 {object} errors.ErrorResponse
-// @Router /api/flights/{id} [get]
+// @Router /v1/flights/{id} [get]
 func (h *FlightHandler) GetFlight(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -70,7 +70,17 @@ func (h *FlightHandler) GetFlight(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, flight)
+	c.JSON(http.StatusOK, entity.NewFlightResponse(*flight))
+}
+
+// ListFlights lists every flight, or only those from the city given by
+// the origin query parameter
+func (h *FlightHandler) ListFlights(c *gin.Context) {
+	if c.Query("origin") != "" {
+		h.ListFlightsByOrigin(c)
+		return
+	}
+	h.ListAllFlights(c)
 }
 
 func (h *FlightHandler) ListAllFlights(c *gin.Context) {
@@ -80,7 +90,7 @@ func (h *FlightHandler) ListAllFlights(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, flights)
+	c.JSON(http.StatusOK, entity.NewFlightResponses(flights))
 }
 
 func (h *FlightHandler) ListFlightsByOrigin(c *gin.Context) {
@@ -97,7 +107,43 @@ func (h *FlightHandler) ListFlightsByOrigin(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, flights)
+	c.JSON(http.StatusOK, entity.NewFlightResponses(flights))
+}
+
+// QuoteFare godoc
+// @Summary Quote a fare
+// @Description Get the current price of one adult seat on a fare, with the pricing adjustments applied
+// @Tags flights
+// @Produce json
+// @Param id path int true "Flight ID"
+// @Param fareId path int true "Fare class ID"
+// @Success 200 {object} pricing.Quote
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /v1/flights/{id}/fares/{fareId}/quote [get]
+func (h *FlightHandler) QuoteFare(c *gin.Context) {
+	flightID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flight ID"})
+		return
+	}
+	fareID, err := strconv.ParseUint(c.Param("fareId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fare ID"})
+		return
+	}
+
+	quote, err := h.flightService.QuoteFare(c.Request.Context(), uint(flightID), uint(fareID))
+	if err != nil {
+		switch err {
+		case errors.ErrFlightNotFound, errors.ErrFareNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to quote fare"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
 }
 
 // BookFlight godoc
@@ -111,8 +157,18 @@ func (h *FlightHandler) ListFlightsByOrigin(c *gin.Context) {
 // @Success 201 {object} entity.Booking
 // @Failure 400 {object} errors.ErrorResponse
 // @Security Bearer
-// @Router /api/flights/{id}/book [post]
+// @Router /v1/flights/{id}/book [post]
 func (h *FlightHandler) BookFlight(c *gin.Context) {
+	h.bookFlight(c, false)
+}
+
+// LegacyBookFlight serves the unversioned booking path, which predates
+// travellers, so bookings there may leave them out
+func (h *FlightHandler) LegacyBookFlight(c *gin.Context) {
+	h.bookFlight(c, true)
+}
+
+func (h *FlightHandler) bookFlight(c *gin.Context, travellersOptional bool) {
 	userIDStr, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
@@ -138,13 +194,22 @@ func (h *FlightHandler) BookFlight(c *gin.Context) {
 	req.FlightID = new(uint)
 	*req.FlightID = uint(flightID)
 	req.BookingType = "flight"
+	req.TravellersOptional = travellersOptional
 
 	booking, err := h.flightService.BookFlight(c.Request.Context(), userID, &req)
 	if err != nil {
 		switch err {
 		case errors.ErrInsufficientSeats:
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient seats available"})
+		case errors.ErrSeatUnavailable:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.ErrFareNotFound, errors.ErrSeatNotFound, errors.ErrSeatCountMismatch, errors.ErrSeatMapNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
+			if isTravellerError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
 		}
 		return
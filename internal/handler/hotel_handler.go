@@ -30,7 +30,7 @@ func NewHotelHandler(hotelService service.HotelService) *HotelHandler {
 // @Param search body entity.HotelSearchRequest true "Hotel search criteria"
 // @Success 200 {array} entity.Hotel
 // @Failure 400 {object} errors.ErrorResponse
-// @Router /api/hotels/search [get]
+// @Router /v1/hotels/search [post]
 func (h *HotelHandler) SearchHotels(c *gin.Context) {
 	var req entity.HotelSearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -56,7 +56,7 @@ func (h *HotelHandler) SearchHotels(c *gin.Context) {
 // @Param id path int true "Hotel ID"
 // @Success 200 {object} entity.Hotel
 // @Failure 404 {object} errors.ErrorResponse
-// @Router /api/hotels/{id} [get]
+// @Router /v1/hotels/{id} [get]
 func (h *HotelHandler) GetHotel(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -73,6 +73,46 @@ func (h *HotelHandler) GetHotel(c *gin.Context) {
 	c.JSON(http.StatusOK, hotel)
 }
 
+// QuoteStay godoc
+// @Summary Quote a hotel stay
+// @Description Get the current nightly rate and total for a stay, with the pricing adjustments applied
+// @Tags hotels
+// @Produce json
+// @Param id path int true "Hotel ID"
+// @Param check_in query string true "Check-in date (YYYY-MM-DD)"
+// @Param check_out query string true "Check-out date (YYYY-MM-DD)"
+// @Success 200 {object} pricing.StayQuote
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /v1/hotels/{id}/quote [get]
+func (h *HotelHandler) QuoteStay(c *gin.Context) {
+	hotelID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hotel ID"})
+		return
+	}
+
+	var req entity.HotelQuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.hotelService.QuoteStay(c.Request.Context(), uint(hotelID), req.CheckIn, req.CheckOut)
+	if err != nil {
+		switch err {
+		case errors.ErrHotelNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.ErrInvalidStayDuration:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to quote stay"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
 // BookHotel godoc
 // @Summary Book a hotel
 // @Description Create a new hotel booking
@@ -84,8 +124,18 @@ func (h *HotelHandler) GetHotel(c *gin.Context) {
 // @Success 201 {object} entity.Booking
 // @Failure 400 {object} errors.ErrorResponse
 // @Security Bearer
-// @Router /api/hotels/{id}/book [post]
+// @Router /v1/hotels/{id}/book [post]
 func (h *HotelHandler) BookHotel(c *gin.Context) {
+	h.bookHotel(c, false)
+}
+
+// LegacyBookHotel serves the unversioned booking path, which predates
+// travellers, so bookings there may leave them out
+func (h *HotelHandler) LegacyBookHotel(c *gin.Context) {
+	h.bookHotel(c, true)
+}
+
+func (h *HotelHandler) bookHotel(c *gin.Context, travellersOptional bool) {
 	userIDStr, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
@@ -111,13 +161,20 @@ func (h *HotelHandler) BookHotel(c *gin.Context) {
 	req.HotelID = new(uint)
 	*req.HotelID = uint(hotelID)
 	req.BookingType = "hotel"
+	req.TravellersOptional = travellersOptional
 
 	booking, err := h.hotelService.BookHotel(c.Request.Context(), userID, &req)
 	if err != nil {
 		switch err {
 		case errors.ErrNoRoomsAvailable:
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient rooms available"})
+		case errors.ErrInvalidStayDuration:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		default:
+			if isTravellerError(err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
 		}
 		return
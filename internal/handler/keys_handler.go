@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"fledge-restapi/internal/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge is how long clients may cache the key set, so how long a new
+// key should be published, as a public key, before it starts signing
+const jwksMaxAge = "max-age=300"
+
+type KeysHandler struct {
+	jwt *util.JWTManager
+}
+
+func NewKeysHandler(jwt *util.JWTManager) *KeysHandler {
+	return &KeysHandler{
+		jwt: jwt,
+	}
+}
+
+// JWKS serves the public keys access tokens are verified with, so other
+// services can check them without sharing a secret
+func (h *KeysHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, "+jwksMaxAge)
+	c.JSON(http.StatusOK, h.jwt.Keys().JWKS())
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartOIDCLogin godoc
+// @Summary Start signing in with an identity provider
+// @Description Get the provider URL to send the browser to. The web app should keep the state and check the provider sends it back.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} entity.OIDCStartResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 502 {object} errors.ErrorResponse
+// @Router /v1/auth/oidc/{provider}/start [post]
+func (h *UserHandler) StartOIDCLogin(c *gin.Context) {
+	start, err := h.userService.StartOIDCLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		writeOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, start)
+}
+
+// CompleteOIDCLogin godoc
+// @Summary Finish signing in with an identity provider
+// @Description Exchange the code and state the provider redirected back with for an access token, or a two-factor challenge. The first sign-in links the account with the provider's verified email, or creates one.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param request body entity.OIDCCallbackRequest true "Code and state from the provider"
+// @Success 200 {object} entity.LoginResult
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 502 {object} errors.ErrorResponse
+// @Router /v1/auth/oidc/{provider}/callback [post]
+func (h *UserHandler) CompleteOIDCLogin(c *gin.Context) {
+	var req entity.OIDCCallbackRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	result, err := h.userService.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), &req, client(c))
+	if err != nil {
+		writeOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func writeOIDCError(c *gin.Context, err error) {
+	switch err {
+	case errors.ErrProviderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.ErrInvalidOIDCState:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.ErrOIDCLoginFailed:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.ErrOIDCEmailMissing:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.ErrProviderUnavailable:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/service"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingHandler struct {
+	pricingService service.PricingService
+}
+
+func NewPricingHandler(pricingService service.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+	}
+}
+
+// ListRules godoc
+// @Summary List pricing rules
+// @Tags admin
+// @Produce json
+// @Success 200 {array} entity.PricingRule
+// @Security Bearer
+// @Router /v1/admin/pricing-rules [get]
+func (h *PricingHandler) ListRules(c *gin.Context) {
+	rules, err := h.pricingService.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pricing rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule godoc
+// @Summary Create a pricing rule
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param rule body entity.PricingRuleRequest true "Pricing rule"
+// @Success 201 {object} entity.PricingRule
+// @Failure 400 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/admin/pricing-rules [post]
+func (h *PricingHandler) CreateRule(c *gin.Context) {
+	var req entity.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.pricingService.CreateRule(c.Request.Context(), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to create pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule godoc
+// @Summary Update a pricing rule
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Pricing rule ID"
+// @Param rule body entity.PricingRuleRequest true "Pricing rule"
+// @Success 200 {object} entity.PricingRule
+// @Failure 400 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/admin/pricing-rules/{id} [put]
+func (h *PricingHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pricing rule ID"})
+		return
+	}
+
+	var req entity.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.pricingService.UpdateRule(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		h.writeError(c, err, "Failed to update pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule godoc
+// @Summary Delete a pricing rule
+// @Tags admin
+// @Param id path int true "Pricing rule ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/admin/pricing-rules/{id} [delete]
+func (h *PricingHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pricing rule ID"})
+		return
+	}
+
+	if err := h.pricingService.DeleteRule(c.Request.Context(), uint(id)); err != nil {
+		h.writeError(c, err, "Failed to delete pricing rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pricing rule deleted successfully"})
+}
+
+func (h *PricingHandler) writeError(c *gin.Context, err error, message string) {
+	switch {
+	case err == errors.ErrPricingRuleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case stderrors.Is(err, errors.ErrInvalidPricingRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
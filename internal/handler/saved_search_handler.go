@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/service"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SavedSearchHandler struct {
+	savedSearchService service.SavedSearchService
+}
+
+func NewSavedSearchHandler(savedSearchService service.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{
+		savedSearchService: savedSearchService,
+	}
+}
+
+// CreateSavedSearch godoc
+// @Summary Save a search
+// @Description Save a flight or hotel search to be re-run periodically, with an optional target price for alerts
+// @Tags saved-searches
+// @Accept json
+// @Produce json
+// @Param search body entity.SavedSearchRequest true "Saved search"
+// @Success 201 {object} entity.SavedSearch
+// @Failure 400 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/saved-searches [post]
+func (h *SavedSearchHandler) CreateSavedSearch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req entity.SavedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	search, err := h.savedSearchService.CreateSavedSearch(c.Request.Context(), userID, &req)
+	if err != nil {
+		switch err {
+		case errors.ErrMissingSearchCriteria, errors.ErrInvalidInput:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save search"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+// ListSavedSearches godoc
+// @Summary List saved searches
+// @Tags saved-searches
+// @Produce json
+// @Success 200 {array} entity.SavedSearch
+// @Security Bearer
+// @Router /v1/saved-searches [get]
+func (h *SavedSearchHandler) ListSavedSearches(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	searches, err := h.savedSearchService.ListSavedSearches(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+// DeleteSavedSearch godoc
+// @Summary Delete a saved search
+// @Tags saved-searches
+// @Param id path int true "Saved search ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/saved-searches/{id} [delete]
+func (h *SavedSearchHandler) DeleteSavedSearch(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid saved search ID"})
+		return
+	}
+
+	if err := h.savedSearchService.DeleteSavedSearch(c.Request.Context(), userID, uint(id)); err != nil {
+		if err == errors.ErrSavedSearchNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete saved search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved search deleted successfully"})
+}
+
+// ListAlerts godoc
+// @Summary List price alerts
+// @Description List the user's price alerts, newest first
+// @Tags saved-searches
+// @Produce json
+// @Param unread query bool false "Only list unread alerts"
+// @Success 200 {array} entity.PriceAlert
+// @Security Bearer
+// @Router /v1/alerts [get]
+func (h *SavedSearchHandler) ListAlerts(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	alerts, err := h.savedSearchService.ListAlerts(c.Request.Context(), userID, unreadOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// MarkAlertRead godoc
+// @Summary Mark a price alert as read
+// @Tags saved-searches
+// @Param id path int true "Price alert ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/alerts/{id}/read [post]
+func (h *SavedSearchHandler) MarkAlertRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	if err := h.savedSearchService.MarkAlertRead(c.Request.Context(), userID, uint(id)); err != nil {
+		if err == errors.ErrPriceAlertNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alert marked as read"})
+}
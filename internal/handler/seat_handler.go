@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/service"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SeatHandler struct {
+	seatService service.SeatService
+}
+
+func NewSeatHandler(seatService service.SeatService) *SeatHandler {
+	return &SeatHandler{
+		seatService: seatService,
+	}
+}
+
+// GetSeatMap godoc
+// @Summary Get flight seat map
+// @Description Get every seat on a flight with its attributes and availability
+// @Tags flights
+// @Produce json
+// @Param id path int true "Flight ID"
+// @Success 200 {array} entity.SeatResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /v1/flights/{id}/seats [get]
+func (h *SeatHandler) GetSeatMap(c *gin.Context) {
+	flightID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flight ID"})
+		return
+	}
+
+	seats, err := h.seatService.GetSeatMap(c.Request.Context(), uint(flightID))
+	if err != nil {
+		switch err {
+		case errors.ErrFlightNotFound, errors.ErrSeatMapNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seat map"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewSeatResponses(seats))
+}
+
+// CreateSeatMap godoc
+// @Summary Create flight seat map
+// @Description Generate a flight's seats from a cabin layout
+// @Tags flights
+// @Accept json
+// @Produce json
+// @Param id path int true "Flight ID"
+// @Param layout body entity.SeatMapRequest true "Seat map layout"
+// @Success 201 {array} entity.SeatResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/flights/{id}/seats [post]
+func (h *SeatHandler) CreateSeatMap(c *gin.Context) {
+	flightID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid flight ID"})
+		return
+	}
+
+	var req entity.SeatMapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seats, err := h.seatService.CreateSeatMap(c.Request.Context(), uint(flightID), &req)
+	if err != nil {
+		switch err {
+		case errors.ErrFlightNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.ErrSeatMapExists:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.ErrInvalidSeatLayout:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create seat map"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, entity.NewSeatResponses(seats))
+}
+
+// AssignSeats godoc
+// @Summary Choose seats for a booking
+// @Description Assign the given seats to a flight booking, or pick seats of the user's preferred type if none are given
+// @Tags bookings
+// @Accept json
+// @Produce json
+// @Param id path int true "Booking ID"
+// @Param seats body entity.SeatAssignmentRequest true "Seat numbers"
+// @Success 200 {array} entity.SeatResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/bookings/{id}/seats [put]
+func (h *SeatHandler) AssignSeats(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	bookingID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req entity.SeatAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seats, err := h.seatService.AssignSeats(c.Request.Context(), userID, uint(bookingID), req.SeatNumbers)
+	if err != nil {
+		switch err {
+		case errors.ErrBookingNotFound, errors.ErrBookingAccessDenied:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		case errors.ErrSeatUnavailable:
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.ErrInvalidBookingType, errors.ErrBookingCancelled, errors.ErrSeatNotFound,
+			errors.ErrSeatCountMismatch, errors.ErrSeatMapNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign seats"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.NewSeatResponses(seats))
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"fledge-restapi/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListSessions godoc
+// @Summary List signed-in sessions
+// @Description List the devices the user is signed in on, most recently active first. The one making the request is marked current.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} entity.Session
+// @Security Bearer
+// @Router /v1/auth/sessions [get]
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.userService.ListSessions(c.Request.Context(), userID, currentSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Sign a session out
+// @Description Revoke one of the user's sessions. Its access token stops working at once; revoking the current session signs out.
+// @Tags auth
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} entity.MessageResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/auth/sessions/{id} [delete]
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := h.userService.RevokeSession(c.Request.Context(), userID, id); err != nil {
+		if err == errors.ErrSessionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeOtherSessions godoc
+// @Summary Sign out everywhere else
+// @Description Revoke every session of the user's except the one making the request
+// @Tags auth
+// @Produce json
+// @Success 200 {object} entity.RevokedSessionsResponse
+// @Security Bearer
+// @Router /v1/auth/sessions/revoke-others [post]
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.userService.RevokeOtherSessions(c.Request.Context(), userID, currentSessionID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
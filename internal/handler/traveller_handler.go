@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/service"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TravellerHandler struct {
+	travellerService service.TravellerService
+}
+
+func NewTravellerHandler(travellerService service.TravellerService) *TravellerHandler {
+	return &TravellerHandler{
+		travellerService: travellerService,
+	}
+}
+
+// ListSavedTravellers godoc
+// @Summary List saved travellers
+// @Description List the traveller profiles saved on the user's account
+// @Tags profile
+// @Produce json
+// @Success 200 {array} entity.SavedTraveller
+// @Security Bearer
+// @Router /v1/profile/travellers [get]
+func (h *TravellerHandler) ListSavedTravellers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	travellers, err := h.travellerService.ListSavedTravellers(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch travellers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, travellers)
+}
+
+// SaveTraveller godoc
+// @Summary Save a traveller
+// @Description Save a traveller profile on the user's account for future bookings
+// @Tags profile
+// @Accept json
+// @Produce json
+// @Param traveller body entity.TravellerDetails true "Traveller details"
+// @Success 201 {object} entity.SavedTraveller
+// @Failure 400 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/profile/travellers [post]
+func (h *TravellerHandler) SaveTraveller(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req entity.TravellerDetails
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	traveller, err := h.travellerService.SaveTraveller(c.Request.Context(), userID, &req)
+	if err != nil {
+		switch err {
+		case errors.ErrIncompletePassport, errors.ErrPassportExpired:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save traveller"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, traveller)
+}
+
+// DeleteSavedTraveller godoc
+// @Summary Delete a saved traveller
+// @Tags profile
+// @Param id path int true "Saved traveller ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/profile/travellers/{id} [delete]
+func (h *TravellerHandler) DeleteSavedTraveller(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	travellerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid traveller ID"})
+		return
+	}
+
+	err = h.travellerService.DeleteSavedTraveller(c.Request.Context(), userID, uint(travellerID))
+	if err != nil {
+		if err == errors.ErrTravellerNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete traveller"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Traveller deleted successfully"})
+}
+
+// isTravellerError reports whether err is a traveller validation failure
+// that should be reported to the client as a bad request
+func isTravellerError(err error) bool {
+	switch err {
+	case errors.ErrTravellerNotFound, errors.ErrTravellerCountMismatch, errors.ErrDateOfBirthRequired,
+		errors.ErrPassengerTypeMismatch, errors.ErrNoAdultTraveller, errors.ErrTooManyInfants,
+		errors.ErrIncompletePassport, errors.ErrPassportExpired:
+		return true
+	}
+	return false
+}
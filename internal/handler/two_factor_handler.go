@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyTwoFactor godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the mfa_token from login and an authenticator or recovery code for an access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.TwoFactorLoginRequest true "Login challenge and code"
+// @Success 200 {object} entity.TokenResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 429 {object} errors.ErrorResponse
+// @Router /v1/auth/2fa/verify [post]
+func (h *UserHandler) VerifyTwoFactor(c *gin.Context) {
+	var req entity.TwoFactorLoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	token, err := h.userService.CompleteTwoFactorLogin(c.Request.Context(), &req, client(c))
+	if err != nil {
+		switch err {
+		case errors.ErrInvalidMFAToken, errors.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			writeThrottledError(c, err, "Failed to login")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// SetUpTwoFactor godoc
+// @Summary Start setting up two-factor authentication
+// @Description Generate a new authenticator secret. It takes effect once confirmed with a code.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} entity.TwoFactorSetupResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/auth/2fa/setup [post]
+func (h *UserHandler) SetUpTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	setup, err := h.userService.SetUpTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// EnableTwoFactor godoc
+// @Summary Turn on two-factor authentication
+// @Description Confirm the secret from setup with a current code. The recovery codes are only shown once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} entity.RecoveryCodesResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/auth/2fa/enable [post]
+func (h *UserHandler) EnableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req entity.TwoFactorCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.userService.EnableTwoFactor(c.Request.Context(), userID, req.Code, c.ClientIP())
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Turn off two-factor authentication
+// @Description Remove the authenticator and recovery codes, given an authenticator or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.TwoFactorCodeRequest true "Authenticator or recovery code"
+// @Success 200 {object} entity.MessageResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/auth/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req entity.TwoFactorCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.userService.DisableTwoFactor(c.Request.Context(), userID, req.Code, c.ClientIP()); err != nil {
+		writeTwoFactorError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace the recovery codes
+// @Description Issue a new set of recovery codes, invalidating the old ones
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.TwoFactorCodeRequest true "Authenticator code"
+// @Success 200 {object} entity.RecoveryCodesResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Security Bearer
+// @Router /v1/auth/2fa/recovery-codes [post]
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	var req entity.TwoFactorCodeRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	codes, err := h.userService.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code, c.ClientIP())
+	if err != nil {
+		writeTwoFactorError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	c.JSON(http.StatusOK, entity.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func writeTwoFactorError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case errors.ErrInvalidMFACode:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.ErrMFAEnabled, errors.ErrMFANotSetUp:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		writeThrottledError(c, err, fallback)
+	}
+}
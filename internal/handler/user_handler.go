@@ -1,9 +1,12 @@
 package handler
 
 import (
+	stderrors "errors"
 	"fledge-restapi/internal/domain/entity"
 	"fledge-restapi/internal/service"
 	"fledge-restapi/pkg/errors"
+	"fmt"
+	"math"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -21,17 +24,20 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 
 func (h *UserHandler) Signup(c *gin.Context) {
 	var req entity.SignupRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	if err := h.userService.CreateUser(c.Request.Context(), &req); err != nil {
-		if err == errors.ErrEmailAlreadyExists {
+		var invalid *errors.ValidationError
+		switch {
+		case err == errors.ErrEmailAlreadyExists:
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
+		case stderrors.As(err, &invalid):
+			writeValidationError(c, invalid)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
@@ -45,22 +51,53 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.userService.Login(c.Request.Context(), &req)
+	result, err := h.userService.Login(c.Request.Context(), &req, client(c))
 	if err != nil {
-		if err == errors.ErrInvalidCredentials {
+		switch err {
+		case errors.ErrInvalidCredentials:
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			return
+		case errors.ErrEmailNotVerified:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			writeThrottledError(c, err, "Failed to login")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, result)
+}
+
+// UnlockAccount godoc
+// @Summary Unlock an account
+// @Description Lift the delay or lockout left on an email by failed logins
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param account body entity.UnlockAccountRequest true "Account to unlock"
+// @Success 200 {object} entity.MessageResponse
+// @Security Bearer
+// @Router /v1/admin/users/unlock [post]
+func (h *UserHandler) UnlockAccount(c *gin.Context) {
+	var req entity.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.UnlockAccount(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
 }
 
 func (h *UserHandler) GetProfile(c *gin.Context) {
-	userID, _ := c.Get("userID")
-	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user profile"})
 		return
@@ -69,6 +106,127 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// RequestEmailVerification godoc
+// @Summary Resend the email verification link
+// @Description Email a new verification link if the address belongs to an unverified account. The response is the same either way.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.EmailRequest true "Account email"
+// @Success 202 {object} entity.MessageResponse
+// @Router /v1/auth/verify-email/request [post]
+func (h *UserHandler) RequestEmailVerification(c *gin.Context) {
+	var req entity.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.RequestEmailVerification(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account needs verifying, a link is on its way"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Redeem the token from a verification email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} entity.MessageResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /v1/auth/verify-email/confirm [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req entity.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		writeAccountTokenError(c, err, "Failed to verify email")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// RequestPasswordReset godoc
+// @Summary Request a password reset
+// @Description Email a password reset link if the address belongs to an account. The response is the same either way.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.EmailRequest true "Account email"
+// @Success 202 {object} entity.MessageResponse
+// @Router /v1/auth/password-reset/request [post]
+func (h *UserHandler) RequestPasswordReset(c *gin.Context) {
+	var req entity.EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a reset link is on its way"})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password with the token from a password reset email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entity.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} entity.MessageResponse
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /v1/auth/password-reset/confirm [post]
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req entity.ResetPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), &req); err != nil {
+		writeAccountTokenError(c, err, "Failed to reset password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset"})
+}
+
+// writeThrottledError answers 429 with a Retry-After header if err is a
+// login throttle, and with a 500 carrying fallback otherwise
+func writeThrottledError(c *gin.Context, err error, fallback string) {
+	var throttled *service.LoginThrottledError
+	if stderrors.As(err, &throttled) {
+		c.Header("Retry-After", fmt.Sprint(int64(math.Ceil(throttled.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
+
+func writeAccountTokenError(c *gin.Context, err error, fallback string) {
+	var invalid *errors.ValidationError
+	switch {
+	case err == errors.ErrInvalidEmailToken:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case stderrors.As(err, &invalid):
+		writeValidationError(c, invalid)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
 /*New methods below
 
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
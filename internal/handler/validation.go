@@ -0,0 +1,82 @@
+package handler
+
+import (
+	stderrors "errors"
+	"fledge-restapi/pkg/errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindJSON binds the request body into req, writing a 400 response and
+// returning false if it can't. Fields that fail validation are reported
+// one by one under their JSON names.
+func bindJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	var invalid validator.ValidationErrors
+	if stderrors.As(err, &invalid) {
+		writeValidationError(c, fieldErrors(req, invalid))
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	return false
+}
+
+func writeValidationError(c *gin.Context, err *errors.ValidationError) {
+	c.JSON(http.StatusBadRequest, gin.H{"error": errors.ErrInvalidInput.Error(), "fields": err.Fields})
+}
+
+// fieldErrors describes each validation failure in plain words
+func fieldErrors(req interface{}, invalid validator.ValidationErrors) *errors.ValidationError {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fields := make(map[string]string, len(invalid))
+	for _, fe := range invalid {
+		name := fe.Field()
+		if field, ok := t.FieldByName(fe.StructField()); ok {
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+				name = tag
+			}
+		}
+		if _, seen := fields[name]; !seen {
+			fields[name] = describeFieldError(fe)
+		}
+	}
+	return &errors.ValidationError{Fields: fields}
+}
+
+func describeFieldError(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "e164":
+		return "must be a phone number in international format, such as +447700900123"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// DevMailer delivers nothing. It writes each message to a .eml file in a
+// directory, or logs it if there is no directory, so links in emails can
+// be followed during development.
+type DevMailer struct {
+	from string
+	dir  string
+}
+
+func NewDevMailer(from, dir string) *DevMailer {
+	return &DevMailer{from: from, dir: dir}
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+func (m *DevMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := compose(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	if m.dir == "" {
+		log.Printf("mail: to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), unsafeFilename.ReplaceAllString(msg.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o644)
+}
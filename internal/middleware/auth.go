@@ -1,17 +1,32 @@
 package middleware
 
 import (
+	"context"
 	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"log"
 	"net/http"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// SessionChecker confirms the session an access token was issued to is
+// still active, failing with ErrSessionRevoked if it isn't
+type SessionChecker interface {
+	Check(ctx context.Context, userID, sessionID uuid.UUID, clientIP string) error
+}
+
+// AuthMiddleware accepts requests with a valid access token whose session
+// hasn't been revoked, and those APIKeyAuth has already authenticated
+func AuthMiddleware(jwt *util.JWTManager, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if c.GetString("apiKeyID") != "" {
+			c.Next()
+			return
+		}
+
 		tokenString := c.GetHeader("Authorization")
 		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "No authorization header"})
@@ -24,74 +39,41 @@ func AuthMiddleware() gin.HandlerFunc {
 			tokenString = tokenString[7:]
 		}
 
-		claims, err := util.ValidateJWT(tokenString)
+		claims, err := jwt.Validate(tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", claims.UserID)
-		c.Set("email", claims.Email)
-		c.Next()
-	}
-}
-
-func RateLimiter() gin.HandlerFunc {
-	// Simple in-memory store for rate limiting
-	type client struct {
-		lastSeen time.Time
-		count    int
-	}
-
-	var (
-		mutex   sync.Mutex
-		clients = make(map[string]*client)
-	)
-
-	return func(c *gin.Context) {
-		mutex.Lock()
-		defer mutex.Unlock()
-
-		ip := c.ClientIP()
-		now := time.Now()
-
-		if cl, exists := clients[ip]; exists {
-			if now.Sub(cl.lastSeen) > time.Minute {
-				cl.count = 0
-			}
-
-			if cl.count >= 100 { // 100 requests per minute
-				c.JSON(http.StatusTooManyRequests, gin.H{
-					"error": "Rate limit exceeded",
-				})
-				c.Abort()
-				return
-			}
-
-			cl.count++
-			cl.lastSeen = now
-		} else {
-			clients[ip] = &client{
-				lastSeen: now,
-				count:    1,
-			}
+		switch err := sessions.Check(c.Request.Context(), claims.UserID, claims.SessionID, c.ClientIP()); err {
+		case nil:
+		case errors.ErrSessionRevoked:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		default:
+			log.Printf("auth: checking session %s: %v", claims.SessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session"})
+			c.Abort()
+			return
 		}
 
+		c.Set("userID", claims.UserID.String())
+		c.Set("sessionID", claims.SessionID.String())
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
 
-// Cors creates a new CORS middleware
-func Cors() gin.HandlerFunc {
+// RequireRole rejects requests from users without the given role. It must
+// run after AuthMiddleware.
+func RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.GetString("role") != role {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
 			return
 		}
 
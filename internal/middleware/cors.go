@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers browsers may send and read cross-origin
+const (
+	corsAllowedHeaders = "Accept, Authorization, Cache-Control, Content-Type, X-API-Key, X-Requested-With"
+	corsExposedHeaders = "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Deprecation, Sunset, Link"
+)
+
+// CORSPolicy says which browser origins may call the API
+type CORSPolicy struct {
+	// AllowedOrigins are origins such as https://app.example.com, patterns
+	// such as https://*.example.com matching any subdomain, or "*" for any
+	// origin
+	AllowedOrigins   []string
+	AllowCredentials bool          // let browsers send cookies; not allowed with "*"
+	MaxAge           time.Duration // how long browsers may cache a preflight
+}
+
+// CORS answers cross-origin requests from the allowed origins. A matched
+// origin is echoed back with Vary: Origin, since responses differ by
+// origin. Preflights are answered with the methods the route actually
+// has, so call AllowRoutes once every route is registered.
+type CORS struct {
+	policy    CORSPolicy
+	anyOrigin bool
+	exact     map[string]bool
+	wildcards []wildcardOrigin
+	routes    []corsRoute
+}
+
+// wildcardOrigin matches subdomains: https://*.example.com is scheme
+// "https" and suffix ".example.com"
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+// corsRoute is a registered path pattern and its methods
+type corsRoute struct {
+	segments []string
+	methods  []string
+}
+
+func NewCORS(policy CORSPolicy) *CORS {
+	c := &CORS{policy: policy, exact: make(map[string]bool)}
+	for _, origin := range policy.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, suffix, _ := strings.Cut(origin, "://*")
+			c.wildcards = append(c.wildcards, wildcardOrigin{scheme: scheme, suffix: suffix})
+		default:
+			c.exact[origin] = true
+		}
+	}
+	return c
+}
+
+// AllowRoutes records the methods of each route, for answering preflights
+func (c *CORS) AllowRoutes(routes gin.RoutesInfo) {
+	byPath := make(map[string][]string)
+	var paths []string
+	for _, route := range routes {
+		if _, seen := byPath[route.Path]; !seen {
+			paths = append(paths, route.Path)
+		}
+		byPath[route.Path] = append(byPath[route.Path], route.Method)
+	}
+
+	c.routes = c.routes[:0]
+	for _, path := range paths {
+		c.routes = append(c.routes, corsRoute{
+			segments: strings.Split(strings.Trim(path, "/"), "/"),
+			methods:  byPath[path],
+		})
+	}
+}
+
+// Handle is the middleware. Requests from other origins are served without
+// CORS headers, which browsers then refuse to hand to the page.
+func (c *CORS) Handle(ctx *gin.Context) {
+	origin := ctx.GetHeader("Origin")
+	preflight := ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != ""
+	header := ctx.Writer.Header()
+
+	if !c.anyOrigin {
+		header.Add("Vary", "Origin")
+	}
+	if origin == "" || !c.allowed(origin) {
+		if preflight {
+			ctx.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		ctx.Next()
+		return
+	}
+
+	if c.anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+		if c.policy.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	if !preflight {
+		header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		ctx.Next()
+		return
+	}
+
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	methods := c.methods(ctx.Request.URL.Path)
+	if slices.Contains(methods, ctx.GetHeader("Access-Control-Request-Method")) {
+		header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		header.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+		if c.policy.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", fmt.Sprint(int64(c.policy.MaxAge.Seconds())))
+		}
+	}
+	ctx.AbortWithStatus(http.StatusNoContent)
+}
+
+// allowed reports whether origin may call the API
+func (c *CORS) allowed(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.exact[origin] {
+		return true
+	}
+	for _, w := range c.wildcards {
+		host, ok := strings.CutPrefix(origin, w.scheme+"://")
+		if !ok {
+			continue
+		}
+		subdomain, ok := strings.CutSuffix(host, w.suffix)
+		if ok && subdomain != "" && strings.Trim(subdomain, "abcdefghijklmnopqrstuvwxyz0123456789-.") == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// methods lists the methods routes matching path accept, sorted
+func (c *CORS) methods(path string) []string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var methods []string
+	for _, route := range c.routes {
+		if route.matches(segments) {
+			methods = append(methods, route.methods...)
+		}
+	}
+	slices.Sort(methods)
+	return slices.Compact(methods)
+}
+
+// matches reports whether a request path's segments fit the route's, where
+// :name matches any one segment and *name the rest of the path
+func (r corsRoute) matches(segments []string) bool {
+	for i, want := range r.segments {
+		if strings.HasPrefix(want, "*") {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if !strings.HasPrefix(want, ":") && want != segments[i] {
+			return false
+		}
+	}
+	return len(segments) == len(r.segments)
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newCORSEngine serves a few of the API's routes behind CORS with policy
+func newCORSEngine(policy CORSPolicy) *gin.Engine {
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r := gin.New()
+	cors := NewCORS(policy)
+	r.Use(cors.Handle)
+	r.GET("/v1/flights", ok)
+	r.GET("/v1/bookings/:id", ok)
+	r.PATCH("/v1/bookings/:id", ok)
+	r.DELETE("/v1/bookings/:id", ok)
+	r.POST("/v1/bookings/:id/confirm", ok)
+	r.GET("/docs/*page", ok)
+	cors.AllowRoutes(r.Routes())
+	return r
+}
+
+type corsRequest struct {
+	method    string
+	path      string
+	origin    string
+	preflight string // Access-Control-Request-Method, making it a preflight
+}
+
+func (req corsRequest) serve(r *gin.Engine) *httptest.ResponseRecorder {
+	method, path := req.method, req.path
+	if method == "" {
+		method = http.MethodGet
+	}
+	if path == "" {
+		path = "/v1/flights"
+	}
+	httpReq := httptest.NewRequest(method, path, nil)
+	if req.origin != "" {
+		httpReq.Header.Set("Origin", req.origin)
+	}
+	if req.preflight != "" {
+		httpReq.Header.Set("Access-Control-Request-Method", req.preflight)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httpReq)
+	return w
+}
+
+func TestCORSOrigins(t *testing.T) {
+	listed := CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.org", "https://*.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	anyOrigin := CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}
+
+	tests := []struct {
+		name        string
+		policy      CORSPolicy
+		req         corsRequest
+		status      int
+		origin      string // Access-Control-Allow-Origin, empty for none
+		credentials bool
+		varyOrigin  bool
+	}{
+		{
+			name:   "exact origin",
+			policy: listed, req: corsRequest{origin: "https://app.example.org"},
+			status: http.StatusOK, origin: "https://app.example.org", credentials: true, varyOrigin: true,
+		},
+		{
+			name:   "origins match whatever their case",
+			policy: listed, req: corsRequest{origin: "https://APP.example.org"},
+			status: http.StatusOK, origin: "https://APP.example.org", credentials: true, varyOrigin: true,
+		},
+		{
+			name:   "wildcard subdomain",
+			policy: listed, req: corsRequest{origin: "https://shop.example.com"},
+			status: http.StatusOK, origin: "https://shop.example.com", credentials: true, varyOrigin: true,
+		},
+		{
+			name:   "wildcard nested subdomain",
+			policy: listed, req: corsRequest{origin: "https://eu.shop.example.com"},
+			status: http.StatusOK, origin: "https://eu.shop.example.com", credentials: true, varyOrigin: true,
+		},
+		{
+			name:   "wildcard doesn't match the bare domain",
+			policy: listed, req: corsRequest{origin: "https://example.com"},
+			status: http.StatusOK, varyOrigin: true,
+		},
+		{
+			name:   "wildcard doesn't match another scheme",
+			policy: listed, req: corsRequest{origin: "http://shop.example.com"},
+			status: http.StatusOK, varyOrigin: true,
+		},
+		{
+			name:   "wildcard doesn't match a lookalike domain",
+			policy: listed, req: corsRequest{origin: "https://evilexample.com"},
+			status: http.StatusOK, varyOrigin: true,
+		},
+		{
+			name:   "disallowed origin is served without CORS headers",
+			policy: listed, req: corsRequest{origin: "https://evil.example.net"},
+			status: http.StatusOK, varyOrigin: true,
+		},
+		{
+			name:   "disallowed origin's preflight gets 204 without CORS headers",
+			policy: listed, req: corsRequest{method: http.MethodOptions, origin: "https://evil.example.net", preflight: http.MethodGet},
+			status: http.StatusNoContent, varyOrigin: true,
+		},
+		{
+			name:   "same-origin request without Origin",
+			policy: listed, req: corsRequest{},
+			status: http.StatusOK, varyOrigin: true,
+		},
+		{
+			name:   "any origin never allows credentials",
+			policy: anyOrigin, req: corsRequest{origin: "https://anywhere.example"},
+			status: http.StatusOK, origin: "*",
+		},
+		{
+			name:   "any origin's preflight",
+			policy: anyOrigin, req: corsRequest{method: http.MethodOptions, origin: "https://anywhere.example", preflight: http.MethodGet},
+			status: http.StatusNoContent, origin: "*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.req.serve(newCORSEngine(tt.policy))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.origin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.origin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.credentials {
+				t.Errorf("credentials allowed = %v, want %v", got, tt.credentials)
+			}
+			if got := slices.Contains(w.Header().Values("Vary"), "Origin"); got != tt.varyOrigin {
+				t.Errorf("Vary: Origin = %v, want %v (Vary %q)", got, tt.varyOrigin, w.Header().Values("Vary"))
+			}
+			if tt.origin == "" && w.Header().Get("Access-Control-Allow-Methods") != "" {
+				t.Errorf("disallowed origin got Access-Control-Allow-Methods %q", w.Header().Get("Access-Control-Allow-Methods"))
+			}
+			exposed := w.Header().Get("Access-Control-Expose-Headers") != ""
+			if want := tt.origin != "" && tt.req.preflight == ""; exposed != want {
+				t.Errorf("exposes headers = %v, want %v", exposed, want)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	r := newCORSEngine(CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.org"},
+		MaxAge:         10 * time.Minute,
+	})
+	const origin = "https://app.example.org"
+
+	tests := []struct {
+		name    string
+		path    string
+		method  string
+		methods string // Access-Control-Allow-Methods, empty if refused
+	}{
+		{name: "PATCH on a booking", path: "/v1/bookings/42", method: http.MethodPatch, methods: "DELETE, GET, PATCH"},
+		{name: "DELETE on a booking", path: "/v1/bookings/42", method: http.MethodDelete, methods: "DELETE, GET, PATCH"},
+		{name: "nested booking route", path: "/v1/bookings/42/confirm", method: http.MethodPost, methods: "POST"},
+		{name: "collection", path: "/v1/flights", method: http.MethodGet, methods: "GET"},
+		{name: "catch-all route", path: "/docs/swagger/index.html", method: http.MethodGet, methods: "GET"},
+		{name: "method the route doesn't have", path: "/v1/flights", method: http.MethodPatch},
+		{name: "PATCH on the booking's confirm route", path: "/v1/bookings/42/confirm", method: http.MethodPatch},
+		{name: "unknown path", path: "/v1/nowhere", method: http.MethodGet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := corsRequest{method: http.MethodOptions, path: tt.path, origin: origin, preflight: tt.method}.serve(r)
+			if w.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusNoContent)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, origin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != tt.methods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.methods)
+			}
+
+			maxAge, headers := w.Header().Get("Access-Control-Max-Age"), w.Header().Get("Access-Control-Allow-Headers")
+			if tt.methods == "" {
+				if maxAge != "" || headers != "" {
+					t.Errorf("refused preflight got Max-Age %q and Allow-Headers %q", maxAge, headers)
+				}
+				return
+			}
+			if maxAge != "600" {
+				t.Errorf("Access-Control-Max-Age = %q, want 600", maxAge)
+			}
+			if headers != corsAllowedHeaders {
+				t.Errorf("Access-Control-Allow-Headers = %q, want %q", headers, corsAllowedHeaders)
+			}
+			vary := w.Header().Values("Vary")
+			for _, want := range []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"} {
+				if !slices.Contains(vary, want) {
+					t.Errorf("Vary %q is missing %s", vary, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSMaxAgeUnset(t *testing.T) {
+	r := newCORSEngine(CORSPolicy{AllowedOrigins: []string{"https://app.example.org"}})
+	w := corsRequest{method: http.MethodOptions, origin: "https://app.example.org", preflight: http.MethodGet}.serve(r)
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("Access-Control-Max-Age = %q without a MaxAge", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != http.MethodGet {
+		t.Errorf("Access-Control-Allow-Methods = %q, want GET", got)
+	}
+}
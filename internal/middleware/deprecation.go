@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks responses as coming from a deprecated endpoint with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers. A zero sunset omits
+// the Sunset header. If successor is set, a Link header points to it; it is
+// a route pattern whose :params are filled from the request.
+func Deprecated(since, sunset time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	var sunsetHeader string
+	if !sunset.IsZero() {
+		sunsetHeader = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if sunsetHeader != "" {
+			c.Header("Sunset", sunsetHeader)
+		}
+		if successor != "" {
+			c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, fillParams(successor, c.Params)))
+		}
+
+		c.Next()
+	}
+}
+
+// fillParams substitutes a request's path parameters into a route pattern
+func fillParams(pattern string, params gin.Params) string {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			if value, ok := params.Get(segment[1:]); ok {
+				segments[i] = value
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"fledge-restapi/internal/ratelimit"
+	"fledge-restapi/internal/util"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitPolicies are the limits applied by RateLimiter
+type RateLimitPolicies struct {
+	Anonymous ratelimit.Policy            // per client IP, for requests without a user
+	User      ratelimit.Policy            // per authenticated user
+	APIKey    ratelimit.Policy            // per API key, whose own limit replaces Requests if it has one
+	Routes    map[string]ratelimit.Policy // per client on one route, keyed by method and route pattern, e.g. "POST /v1/auth/login"
+}
+
+// rateLimitResultKey holds the result of IPRateLimiter, so RateLimiter can
+// report whichever limit is tighter
+const rateLimitResultKey = "rateLimitResult"
+
+// IPRateLimiter limits every request per client IP under policy. It runs
+// before authentication, so requests that fail it are limited too, and
+// policy should leave room for the users and API keys behind one address.
+func IPRateLimiter(store ratelimit.Store, policy ratelimit.Policy, clock util.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit(c, store, "ip:"+c.ClientIP(), []ratelimit.Policy{policy}, clock.Now())
+	}
+}
+
+// RateLimiter limits requests per client: per API key for requests
+// APIKeyAuth accepted, per user once AuthMiddleware has identified one, per
+// IP otherwise. Requests to a route with its own policy
+// must also fit within that. It sets the RateLimit-Limit, RateLimit-Remaining
+// and RateLimit-Reset headers for the tightest limit, including
+// IPRateLimiter's, and Retry-After when a request is refused. If the store
+// fails, requests are let through rather than taking the API down with it.
+func RateLimiter(store ratelimit.Store, policies RateLimitPolicies, clock util.Clock) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client, policy := "ip:"+c.ClientIP(), policies.Anonymous
+		if keyID := c.GetString("apiKeyID"); keyID != "" {
+			client, policy = "key:"+keyID, policies.APIKey
+			if limit := c.GetInt("apiKeyRateLimit"); limit > 0 {
+				policy.Requests = limit
+			}
+		} else if userID := c.GetString("userID"); userID != "" {
+			client, policy = "user:"+userID, policies.User
+		}
+
+		checks := []ratelimit.Policy{policy}
+		if route, ok := policies.Routes[c.Request.Method+" "+c.FullPath()]; ok {
+			checks = append(checks, route)
+		}
+		limit(c, store, client, checks, clock.Now())
+	}
+}
+
+// limit spends a request from client's bucket under each policy in turn,
+// setting the headers for the tightest result so far and refusing the
+// request if any bucket is empty
+func limit(c *gin.Context, store ratelimit.Store, client string, checks []ratelimit.Policy, now time.Time) {
+	var tightest *ratelimit.Result
+	if earlier, ok := c.Get(rateLimitResultKey); ok {
+		result := earlier.(ratelimit.Result)
+		tightest = &result
+	}
+	for _, policy := range checks {
+		result, err := store.Take(c.Request.Context(), client, policy, now)
+		if err != nil {
+			log.Printf("Rate limiter unavailable, allowing request: %v", err)
+			c.Next()
+			return
+		}
+		if tightest == nil || !result.Allowed || (tightest.Allowed && result.Remaining < tightest.Remaining) {
+			tightest = &result
+		}
+		if !result.Allowed {
+			break
+		}
+	}
+
+	c.Header("RateLimit-Limit", fmt.Sprint(tightest.Limit))
+	c.Header("RateLimit-Remaining", fmt.Sprint(tightest.Remaining))
+	c.Header("RateLimit-Reset", fmt.Sprint(seconds(tightest.Reset)))
+	if !tightest.Allowed {
+		c.Header("Retry-After", fmt.Sprint(seconds(tightest.RetryAfter)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+		return
+	}
+
+	c.Set(rateLimitResultKey, *tightest)
+	c.Next()
+}
+
+// seconds rounds a duration up to whole seconds, as the headers expect
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,255 @@
+CREATE TABLE users (
+    id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    email text NOT NULL,
+    password text NOT NULL,
+    first_name text,
+    last_name text,
+    phone_number text,
+    role text DEFAULT 'user',
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE user_preferences (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    preferred_seat text,
+    meal_preference text,
+    preferred_airlines text,
+    preferred_hotels text
+);
+CREATE INDEX idx_user_preferences_deleted_at ON user_preferences (deleted_at);
+CREATE INDEX idx_user_preferences_user_id ON user_preferences (user_id);
+
+CREATE TABLE flights (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    flight_number text,
+    airline text,
+    departure_city text,
+    arrival_city text,
+    destination_country text,
+    departure_timezone text,
+    arrival_timezone text,
+    departure_time timestamptz,
+    arrival_time timestamptz,
+    status text
+);
+CREATE INDEX idx_flights_deleted_at ON flights (deleted_at);
+CREATE INDEX idx_flights_route ON flights (departure_city, arrival_city, departure_time);
+
+CREATE TABLE fare_classes (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    flight_id bigint REFERENCES flights (id),
+    cabin text,
+    code text,
+    price double precision,
+    baggage_allowance bigint,
+    changeable boolean,
+    change_fee double precision,
+    refundable boolean,
+    available_seats bigint,
+    capacity bigint
+);
+CREATE INDEX idx_fare_classes_deleted_at ON fare_classes (deleted_at);
+CREATE INDEX idx_fare_classes_flight_id ON fare_classes (flight_id);
+
+CREATE TABLE hotels (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    address text,
+    city text,
+    country text,
+    rating real,
+    price double precision,
+    available_rooms bigint,
+    total_rooms bigint
+);
+CREATE INDEX idx_hotels_deleted_at ON hotels (deleted_at);
+CREATE INDEX idx_hotels_city ON hotels (city);
+
+CREATE TABLE amenities (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    description text
+);
+CREATE INDEX idx_amenities_deleted_at ON amenities (deleted_at);
+
+CREATE TABLE hotel_amenities (
+    hotel_id bigint REFERENCES hotels (id),
+    amenity_id bigint REFERENCES amenities (id),
+    PRIMARY KEY (hotel_id, amenity_id)
+);
+
+CREATE TABLE vacation_packages (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    description text,
+    destination text,
+    duration bigint,
+    start_date timestamptz,
+    end_date timestamptz,
+    price double precision,
+    includes text,
+    max_people bigint,
+    available boolean
+);
+CREATE INDEX idx_vacation_packages_deleted_at ON vacation_packages (deleted_at);
+
+CREATE TABLE pricing_rules (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    product text,
+    type text,
+    min double precision,
+    max double precision,
+    weekdays text,
+    season_start text,
+    season_end text,
+    percent double precision,
+    priority bigint,
+    active boolean
+);
+CREATE INDEX idx_pricing_rules_deleted_at ON pricing_rules (deleted_at);
+
+CREATE TABLE bookings (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    booking_type text,
+    flight_id bigint REFERENCES flights (id),
+    fare_class_id bigint REFERENCES fare_classes (id),
+    hotel_id bigint REFERENCES hotels (id),
+    vacation_package_id bigint REFERENCES vacation_packages (id),
+    status text,
+    hold_expires_at timestamptz,
+    booking_date timestamptz,
+    total_price double precision,
+    payment_status text,
+    payment_reference text,
+    check_in_date timestamptz,
+    check_out_date timestamptz,
+    num_guests bigint,
+    special_requests text
+);
+CREATE INDEX idx_bookings_deleted_at ON bookings (deleted_at);
+CREATE INDEX idx_bookings_user_id ON bookings (user_id);
+CREATE INDEX idx_bookings_holds ON bookings (status, hold_expires_at);
+
+CREATE TABLE seats (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    flight_id bigint REFERENCES flights (id),
+    seat_row bigint,
+    letter text,
+    cabin text,
+    "window" boolean,
+    aisle boolean,
+    exit boolean,
+    blocked boolean,
+    booking_id bigint REFERENCES bookings (id)
+);
+CREATE INDEX idx_seats_deleted_at ON seats (deleted_at);
+CREATE UNIQUE INDEX idx_seats_flight_number ON seats (flight_id, seat_row, letter);
+CREATE INDEX idx_seats_booking_id ON seats (booking_id);
+
+CREATE TABLE travellers (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    booking_id bigint REFERENCES bookings (id),
+    first_name text,
+    last_name text,
+    date_of_birth timestamptz,
+    passenger_type text,
+    passport_number text,
+    passport_country text,
+    passport_expiry timestamptz,
+    loyalty_numbers text,
+    price double precision
+);
+CREATE INDEX idx_travellers_deleted_at ON travellers (deleted_at);
+CREATE INDEX idx_travellers_booking_id ON travellers (booking_id);
+
+CREATE TABLE saved_travellers (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    first_name text,
+    last_name text,
+    date_of_birth timestamptz,
+    passenger_type text,
+    passport_number text,
+    passport_country text,
+    passport_expiry timestamptz,
+    loyalty_numbers text
+);
+CREATE INDEX idx_saved_travellers_deleted_at ON saved_travellers (deleted_at);
+CREATE INDEX idx_saved_travellers_user_id ON saved_travellers (user_id);
+
+CREATE TABLE saved_searches (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    name text,
+    search_type text,
+    flight_criteria jsonb,
+    hotel_criteria jsonb,
+    target_price double precision,
+    lowest_price double precision,
+    alerted_price double precision,
+    last_run_at timestamptz,
+    active boolean
+);
+CREATE INDEX idx_saved_searches_deleted_at ON saved_searches (deleted_at);
+CREATE INDEX idx_saved_searches_user_id ON saved_searches (user_id);
+
+CREATE TABLE price_alerts (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    saved_search_id bigint REFERENCES saved_searches (id),
+    item_type text,
+    item_id bigint,
+    price double precision,
+    previous_price double precision,
+    target_price double precision,
+    read_at timestamptz
+);
+CREATE INDEX idx_price_alerts_deleted_at ON price_alerts (deleted_at);
+CREATE INDEX idx_price_alerts_user_id ON price_alerts (user_id);
+CREATE INDEX idx_price_alerts_saved_search_id ON price_alerts (saved_search_id);
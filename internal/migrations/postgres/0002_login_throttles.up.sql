@@ -0,0 +1,7 @@
+CREATE TABLE login_throttles (
+    key text PRIMARY KEY,
+    failures bigint NOT NULL DEFAULT 0,
+    last_failed_at timestamptz,
+    retry_at timestamptz,
+    locked_until timestamptz
+);
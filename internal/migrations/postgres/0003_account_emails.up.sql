@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN email_verified_at timestamptz;
+
+CREATE TABLE user_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    purpose text,
+    token_hash text,
+    expires_at timestamptz,
+    used_at timestamptz
+);
+CREATE INDEX idx_user_tokens_deleted_at ON user_tokens (deleted_at);
+CREATE INDEX idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE UNIQUE INDEX idx_user_tokens_token_hash ON user_tokens (token_hash);
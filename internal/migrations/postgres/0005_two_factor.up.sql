@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN totp_secret text;
+ALTER TABLE users ADD COLUMN totp_last_step bigint NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN two_factor_enabled_at timestamptz;
+
+CREATE TABLE recovery_codes (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    code_hash text,
+    used_at timestamptz
+);
+CREATE INDEX idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
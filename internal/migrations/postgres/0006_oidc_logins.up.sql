@@ -0,0 +1,24 @@
+CREATE TABLE user_identities (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id uuid REFERENCES users (id),
+    provider text,
+    subject text,
+    email text
+);
+CREATE INDEX idx_user_identities_deleted_at ON user_identities (deleted_at);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+CREATE UNIQUE INDEX idx_user_identities_provider_subject ON user_identities (provider, subject);
+
+CREATE TABLE oidc_logins (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    state_hash text,
+    provider text,
+    nonce text,
+    code_verifier text,
+    expires_at timestamptz
+);
+CREATE UNIQUE INDEX idx_oidc_logins_state_hash ON oidc_logins (state_hash);
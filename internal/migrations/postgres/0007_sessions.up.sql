@@ -0,0 +1,10 @@
+CREATE TABLE sessions (
+    id uuid PRIMARY KEY,
+    user_id uuid REFERENCES users (id),
+    user_agent text,
+    ip_address text,
+    created_at timestamptz,
+    last_seen_at timestamptz,
+    expires_at timestamptz
+);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
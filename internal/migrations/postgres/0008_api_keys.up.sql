@@ -0,0 +1,17 @@
+CREATE TABLE api_keys (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    user_id uuid REFERENCES users (id),
+    prefix text,
+    secret_hash text,
+    scopes jsonb,
+    rate_limit bigint,
+    expires_at timestamptz,
+    last_used_at timestamptz
+);
+CREATE INDEX idx_api_keys_deleted_at ON api_keys (deleted_at);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1 @@
+ALTER TABLE bookings DROP COLUMN seat_count;
@@ -0,0 +1,15 @@
+DROP TABLE price_alerts;
+DROP TABLE saved_searches;
+DROP TABLE saved_travellers;
+DROP TABLE travellers;
+DROP TABLE seats;
+DROP TABLE bookings;
+DROP TABLE pricing_rules;
+DROP TABLE vacation_packages;
+DROP TABLE hotel_amenities;
+DROP TABLE amenities;
+DROP TABLE hotels;
+DROP TABLE fare_classes;
+DROP TABLE flights;
+DROP TABLE user_preferences;
+DROP TABLE users;
@@ -0,0 +1,255 @@
+CREATE TABLE users (
+    id text PRIMARY KEY,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    email text NOT NULL,
+    password text NOT NULL,
+    first_name text,
+    last_name text,
+    phone_number text,
+    role text DEFAULT 'user',
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE user_preferences (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    preferred_seat text,
+    meal_preference text,
+    preferred_airlines text,
+    preferred_hotels text
+);
+CREATE INDEX idx_user_preferences_deleted_at ON user_preferences (deleted_at);
+CREATE INDEX idx_user_preferences_user_id ON user_preferences (user_id);
+
+CREATE TABLE flights (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    flight_number text,
+    airline text,
+    departure_city text,
+    arrival_city text,
+    destination_country text,
+    departure_timezone text,
+    arrival_timezone text,
+    departure_time datetime,
+    arrival_time datetime,
+    status text
+);
+CREATE INDEX idx_flights_deleted_at ON flights (deleted_at);
+CREATE INDEX idx_flights_route ON flights (departure_city, arrival_city, departure_time);
+
+CREATE TABLE fare_classes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    flight_id bigint REFERENCES flights (id),
+    cabin text,
+    code text,
+    price real,
+    baggage_allowance bigint,
+    changeable boolean,
+    change_fee real,
+    refundable boolean,
+    available_seats bigint,
+    capacity bigint
+);
+CREATE INDEX idx_fare_classes_deleted_at ON fare_classes (deleted_at);
+CREATE INDEX idx_fare_classes_flight_id ON fare_classes (flight_id);
+
+CREATE TABLE hotels (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    address text,
+    city text,
+    country text,
+    rating real,
+    price real,
+    available_rooms bigint,
+    total_rooms bigint
+);
+CREATE INDEX idx_hotels_deleted_at ON hotels (deleted_at);
+CREATE INDEX idx_hotels_city ON hotels (city);
+
+CREATE TABLE amenities (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    description text
+);
+CREATE INDEX idx_amenities_deleted_at ON amenities (deleted_at);
+
+CREATE TABLE hotel_amenities (
+    hotel_id bigint REFERENCES hotels (id),
+    amenity_id bigint REFERENCES amenities (id),
+    PRIMARY KEY (hotel_id, amenity_id)
+);
+
+CREATE TABLE vacation_packages (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    description text,
+    destination text,
+    duration bigint,
+    start_date datetime,
+    end_date datetime,
+    price real,
+    includes text,
+    max_people bigint,
+    available boolean
+);
+CREATE INDEX idx_vacation_packages_deleted_at ON vacation_packages (deleted_at);
+
+CREATE TABLE pricing_rules (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    product text,
+    type text,
+    min real,
+    max real,
+    weekdays text,
+    season_start text,
+    season_end text,
+    percent real,
+    priority bigint,
+    active boolean
+);
+CREATE INDEX idx_pricing_rules_deleted_at ON pricing_rules (deleted_at);
+
+CREATE TABLE bookings (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    booking_type text,
+    flight_id bigint REFERENCES flights (id),
+    fare_class_id bigint REFERENCES fare_classes (id),
+    hotel_id bigint REFERENCES hotels (id),
+    vacation_package_id bigint REFERENCES vacation_packages (id),
+    status text,
+    hold_expires_at datetime,
+    booking_date datetime,
+    total_price real,
+    payment_status text,
+    payment_reference text,
+    check_in_date datetime,
+    check_out_date datetime,
+    num_guests bigint,
+    special_requests text
+);
+CREATE INDEX idx_bookings_deleted_at ON bookings (deleted_at);
+CREATE INDEX idx_bookings_user_id ON bookings (user_id);
+CREATE INDEX idx_bookings_holds ON bookings (status, hold_expires_at);
+
+CREATE TABLE seats (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    flight_id bigint REFERENCES flights (id),
+    seat_row bigint,
+    letter text,
+    cabin text,
+    "window" boolean,
+    aisle boolean,
+    exit boolean,
+    blocked boolean,
+    booking_id bigint REFERENCES bookings (id)
+);
+CREATE INDEX idx_seats_deleted_at ON seats (deleted_at);
+CREATE UNIQUE INDEX idx_seats_flight_number ON seats (flight_id, seat_row, letter);
+CREATE INDEX idx_seats_booking_id ON seats (booking_id);
+
+CREATE TABLE travellers (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    booking_id bigint REFERENCES bookings (id),
+    first_name text,
+    last_name text,
+    date_of_birth datetime,
+    passenger_type text,
+    passport_number text,
+    passport_country text,
+    passport_expiry datetime,
+    loyalty_numbers text,
+    price real
+);
+CREATE INDEX idx_travellers_deleted_at ON travellers (deleted_at);
+CREATE INDEX idx_travellers_booking_id ON travellers (booking_id);
+
+CREATE TABLE saved_travellers (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    first_name text,
+    last_name text,
+    date_of_birth datetime,
+    passenger_type text,
+    passport_number text,
+    passport_country text,
+    passport_expiry datetime,
+    loyalty_numbers text
+);
+CREATE INDEX idx_saved_travellers_deleted_at ON saved_travellers (deleted_at);
+CREATE INDEX idx_saved_travellers_user_id ON saved_travellers (user_id);
+
+CREATE TABLE saved_searches (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    name text,
+    search_type text,
+    flight_criteria text,
+    hotel_criteria text,
+    target_price real,
+    lowest_price real,
+    alerted_price real,
+    last_run_at datetime,
+    active boolean
+);
+CREATE INDEX idx_saved_searches_deleted_at ON saved_searches (deleted_at);
+CREATE INDEX idx_saved_searches_user_id ON saved_searches (user_id);
+
+CREATE TABLE price_alerts (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    saved_search_id bigint REFERENCES saved_searches (id),
+    item_type text,
+    item_id bigint,
+    price real,
+    previous_price real,
+    target_price real,
+    read_at datetime
+);
+CREATE INDEX idx_price_alerts_deleted_at ON price_alerts (deleted_at);
+CREATE INDEX idx_price_alerts_user_id ON price_alerts (user_id);
+CREATE INDEX idx_price_alerts_saved_search_id ON price_alerts (saved_search_id);
@@ -0,0 +1,7 @@
+CREATE TABLE login_throttles (
+    key text PRIMARY KEY,
+    failures integer NOT NULL DEFAULT 0,
+    last_failed_at datetime,
+    retry_at datetime,
+    locked_until datetime
+);
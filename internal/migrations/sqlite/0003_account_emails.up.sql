@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN email_verified_at datetime;
+
+CREATE TABLE user_tokens (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    purpose text,
+    token_hash text,
+    expires_at datetime,
+    used_at datetime
+);
+CREATE INDEX idx_user_tokens_deleted_at ON user_tokens (deleted_at);
+CREATE INDEX idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE UNIQUE INDEX idx_user_tokens_token_hash ON user_tokens (token_hash);
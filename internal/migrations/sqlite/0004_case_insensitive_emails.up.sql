@@ -0,0 +1,4 @@
+-- Fails if two accounts' emails differ only in letter case; merge or
+-- rename them first
+UPDATE users SET email = lower(email) WHERE email <> lower(email);
+CREATE UNIQUE INDEX idx_users_email_lower ON users (lower(email));
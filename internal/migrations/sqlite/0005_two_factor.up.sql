@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN totp_secret text;
+ALTER TABLE users ADD COLUMN totp_last_step integer NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN two_factor_enabled_at datetime;
+
+CREATE TABLE recovery_codes (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    user_id text REFERENCES users (id),
+    code_hash text,
+    used_at datetime
+);
+CREATE INDEX idx_recovery_codes_deleted_at ON recovery_codes (deleted_at);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
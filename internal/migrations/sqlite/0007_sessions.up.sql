@@ -0,0 +1,10 @@
+CREATE TABLE sessions (
+    id text PRIMARY KEY,
+    user_id text REFERENCES users (id),
+    user_agent text,
+    ip_address text,
+    created_at datetime,
+    last_seen_at datetime,
+    expires_at datetime
+);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,17 @@
+CREATE TABLE api_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    user_id text REFERENCES users (id),
+    prefix text,
+    secret_hash text,
+    scopes text,
+    rate_limit integer,
+    expires_at datetime,
+    last_used_at datetime
+);
+CREATE INDEX idx_api_keys_deleted_at ON api_keys (deleted_at);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX idx_api_keys_prefix ON api_keys (prefix);
@@ -0,0 +1,4 @@
+-- Infants fly on an adult's lap, so a booking's fare seats can be fewer
+-- than its guests. Earlier flight bookings took a seat per guest.
+ALTER TABLE bookings ADD COLUMN seat_count integer NOT NULL DEFAULT 0;
+UPDATE bookings SET seat_count = num_guests WHERE booking_type = 'flight';
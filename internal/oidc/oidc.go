@@ -0,0 +1,366 @@
+// Package oidc signs users in through OpenID Connect providers with the
+// authorization code flow and PKCE (RFC 7636). Providers are configured
+// generically: an issuer URL, whose endpoints are discovered, and client
+// credentials.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"fledge-restapi/internal/jwk"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrUnavailable means the provider couldn't be reached or failed
+	ErrUnavailable = errors.New("identity provider unavailable")
+	// ErrRejected means the provider refused the authorization code, or
+	// returned an ID token that doesn't verify
+	ErrRejected = errors.New("identity provider login rejected")
+)
+
+// SigningMethods are the ID token algorithms accepted. HMAC algorithms
+// are left out on purpose: they would be keyed with the client secret.
+var SigningMethods = []string{"RS256", "ES256", "EdDSA"}
+
+const (
+	// keyRefreshInterval limits refetching the provider's keys when a
+	// token names a kid we don't have, so bad tokens can't hammer it
+	keyRefreshInterval = time.Minute
+	// clockSkew is tolerated between us and the provider
+	clockSkew = time.Minute
+	// maxResponseBytes caps what is read from the provider
+	maxResponseBytes = 1 << 20
+)
+
+// Config describes one provider
+type Config struct {
+	Name         string // used in our URLs, e.g. google
+	Issuer       string
+	ClientID     string
+	ClientSecret string   // empty for a public client
+	RedirectURL  string   // where the provider sends the browser back
+	Scopes       []string // openid is always requested
+
+	// Endpoints are discovered from the issuer unless all three are set
+	AuthURL  string
+	TokenURL string
+	JWKSURL  string
+}
+
+// Claims are the parts of a verified ID token used to find or create the
+// user
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+}
+
+// Provider talks to one OpenID Connect provider. Its discovered endpoints
+// and keys are cached.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	endpoints   *endpoints
+	keys        jwk.Set
+	keysFetched time.Time
+}
+
+type endpoints struct {
+	Issuer   string `json:"issuer"`
+	AuthURL  string `json:"authorization_endpoint"`
+	TokenURL string `json:"token_endpoint"`
+	JWKSURL  string `json:"jwks_uri"`
+}
+
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	p := &Provider{cfg: cfg, client: client}
+	if cfg.AuthURL != "" && cfg.TokenURL != "" && cfg.JWKSURL != "" {
+		p.endpoints = &endpoints{Issuer: cfg.Issuer, AuthURL: cfg.AuthURL, TokenURL: cfg.TokenURL, JWKSURL: cfg.JWKSURL}
+	}
+	return p
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL returns where to send the browser to sign in. state comes
+// back with the code; nonce must be in the ID token; verifier is the PKCE
+// secret that Exchange needs.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {p.scope()},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(ep.AuthURL, "?") {
+		sep = "&"
+	}
+	return ep.AuthURL + sep + params.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the claims of the
+// verified ID token, which must carry nonce
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string, now time.Time) (*Claims, error) {
+	ep, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: token endpoint: %d %s %s", ErrRejected, status, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in the token response", ErrRejected)
+	}
+	return p.verify(ctx, ep, token.IDToken, nonce, now)
+}
+
+// idToken is the ID token payload
+type idToken struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	AuthorizedBy  string   `json:"azp"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Name          string   `json:"name"`
+}
+
+func (p *Provider) verify(ctx context.Context, ep *endpoints, raw, nonce string, now time.Time) (*Claims, error) {
+	var claims idToken
+	_, err := jwt.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.key(ctx, ep, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.Alg != "" && key.Alg != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q is for %s", kid, key.Alg)
+		}
+		return key.PublicKey()
+	},
+		jwt.WithValidMethods(SigningMethods),
+		jwt.WithIssuer(ep.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+	)
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: id_token: %v", ErrRejected, err)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: id_token nonce mismatch", ErrRejected)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedBy != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: id_token azp is %q", ErrRejected, claims.AuthorizedBy)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: id_token has no subject", ErrRejected)
+	}
+
+	return &Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+		Name:          claims.Name,
+	}, nil
+}
+
+// key returns the provider's key with the kid, refetching the key set if
+// it isn't known yet, as happens after the provider rotates its keys
+func (p *Provider) key(ctx context.Context, ep *endpoints, kid string) (*jwk.Key, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys.Lookup(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ep.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	var keys jwk.Set
+	status, err := p.do(req, &keys)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: jwks_uri returned %d", ErrUnavailable, status)
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+
+	if key, ok := p.keys.Lookup(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// discover fetches the provider's metadata the first time it's needed
+func (p *Provider) discover(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, err
+	}
+	var ep endpoints
+	status, err := p.do(req, &ep)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: discovery returned %d", ErrUnavailable, status)
+	}
+	// Required by OpenID Connect Discovery, so one issuer can't stand in
+	// for another
+	if ep.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery is for issuer %q, not %q", ErrUnavailable, ep.Issuer, p.cfg.Issuer)
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" || ep.JWKSURL == "" {
+		return nil, fmt.Errorf("%w: discovery is missing endpoints", ErrUnavailable)
+	}
+	p.endpoints = &ep
+	return p.endpoints, nil
+}
+
+// do sends req and decodes the JSON body into v, returning the status.
+// Transport failures and server errors are ErrUnavailable.
+func (p *Provider) do(req *http.Request, v interface{}) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return 0, fmt.Errorf("%w: %s returned %d", ErrUnavailable, req.URL.Path, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("%w: %s: %v", ErrUnavailable, req.URL.Path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+func (p *Provider) scope() string {
+	scopes := []string{"openid"}
+	for _, s := range p.cfg.Scopes {
+		if s != "openid" {
+			scopes = append(scopes, s)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+// NewVerifier returns a random PKCE code verifier, also suitable for state
+// and nonce values
+func NewVerifier() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// Challenge is the S256 PKCE challenge for verifier
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// flexBool accepts booleans sent as JSON strings, as some providers do for
+// email_verified
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*b = true
+	case "false", `"false"`, "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
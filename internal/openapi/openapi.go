@@ -0,0 +1,275 @@
+// Package openapi builds an OpenAPI 3 document from the router's route
+// table and a description of each operation, deriving request and response
+// schemas from the Go types the handlers bind and return.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation describes one route for the spec. Path uses gin's syntax, e.g.
+// /api/flights/:id.
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	Auth        bool   // requires a bearer token
+	Scope       string // API key scope that may call the operation instead
+	Deprecated  bool
+	Params      []Param     // path parameters not listed here are documented as strings
+	Body        interface{} // a value of the JSON request body type, or nil
+	Responses   []Response
+}
+
+// Param is a path or query parameter
+type Param struct {
+	Name        string
+	In          string // path or query
+	Description string
+	Required    bool
+	Type        interface{} // a value of the parameter's type
+}
+
+// Response is one documented response status
+type Response struct {
+	Status      int
+	Description string
+	Body        interface{} // a value of the JSON response type, or nil for none
+}
+
+// PathID documents a numeric ID path parameter
+func PathID(name, description string) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Type: uint(0)}
+}
+
+// Query documents a query string parameter
+func Query(name, description string, typ interface{}, required bool) Param {
+	return Param{Name: name, In: "query", Description: description, Required: required, Type: typ}
+}
+
+// Info describes the API as a whole
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Document is an OpenAPI 3 document, ready to be encoded as JSON
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components components                      `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+const (
+	bearerAuth = "bearerAuth"
+	apiKeyAuth = "apiKeyAuth"
+)
+
+var pathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Build documents every route in routes using ops. Every registered route
+// must have exactly one operation and every operation a registered route,
+// so the spec can't drift from the router; Build reports each mismatch.
+func Build(info Info, routes gin.RoutesInfo, ops []Operation) (*Document, error) {
+	registered := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	var problems []string
+	documented := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		key := op.Method + " " + op.Path
+		switch {
+		case documented[key]:
+			problems = append(problems, "documented twice: "+key)
+		case !registered[key]:
+			problems = append(problems, "documented but not registered: "+key)
+		}
+		documented[key] = true
+	}
+	for _, route := range routes {
+		if key := route.Method + " " + route.Path; !documented[key] {
+			problems = append(problems, "registered but not documented: "+key)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("openapi: spec does not match routes:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	schemas := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   make(map[string]map[string]operation),
+	}
+	for _, op := range ops {
+		path := Path(op.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]operation)
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = buildOperation(op, schemas)
+	}
+	doc.Components = components{
+		Schemas: schemas.components,
+		SecuritySchemes: map[string]securityScheme{
+			bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			apiKeyAuth: {Type: "apiKey", In: "header", Name: "X-API-Key"},
+		},
+	}
+
+	return doc, nil
+}
+
+func buildOperation(op Operation, schemas *schemaRegistry) operation {
+	out := operation{
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: operationID(op.Method, op.Path),
+		Responses:   make(map[string]response),
+		Deprecated:  op.Deprecated,
+	}
+	if op.Tag != "" {
+		out.Tags = []string{op.Tag}
+	}
+	switch {
+	case op.Auth:
+		out.Security = []map[string][]string{{bearerAuth: {}}}
+	case op.Scope != "":
+		out.Security = []map[string][]string{{}} // anonymous callers are welcome too
+	}
+	if op.Scope != "" {
+		out.Security = append(out.Security, map[string][]string{apiKeyAuth: {}})
+		note := "API keys need the " + op.Scope + " scope."
+		if out.Description != "" {
+			note = strings.TrimSuffix(out.Description, ".") + ". " + note
+		}
+		out.Description = note
+	}
+
+	listed := make(map[string]bool)
+	for _, p := range op.Params {
+		listed[p.Name] = true
+		out.Parameters = append(out.Parameters, parameter{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.Required || p.In == "path",
+			Schema:      schemas.schemaOf(p.Type),
+		})
+	}
+	for _, match := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		if !listed[match[1]] {
+			out.Parameters = append(out.Parameters, parameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+
+	if op.Body != nil {
+		out.RequestBody = &requestBody{
+			Required: true,
+			Content:  map[string]mediaType{"application/json": {Schema: schemas.schemaOf(op.Body)}},
+		}
+	}
+
+	for _, r := range op.Responses {
+		res := response{Description: r.Description}
+		if res.Description == "" {
+			res.Description = http.StatusText(r.Status)
+		}
+		if r.Body != nil {
+			res.Content = map[string]mediaType{"application/json": {Schema: schemas.schemaOf(r.Body)}}
+		}
+		out.Responses[fmt.Sprint(r.Status)] = res
+	}
+
+	return out
+}
+
+// Path converts a gin route pattern to an OpenAPI path, e.g.
+// /api/flights/:id to /api/flights/{id}
+func Path(route string) string {
+	return pathParam.ReplaceAllString(route, "{$1}")
+}
+
+// operationID derives a stable ID from the method and path, e.g.
+// GET /api/flights/:id becomes getApiFlightsById
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if segment[0] == ':' || segment[0] == '*' {
+			b.WriteString("By")
+			segment = segment[1:]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+// Package password decides which passwords users may choose.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBytes is the longest password bcrypt can hash
+const MaxBytes = 72
+
+// Policy is the rules a new password must meet
+type Policy struct {
+	MinLength     int // in characters
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Breached rejects passwords known from data breaches; nil skips the
+	// check
+	Breached *BreachedList
+}
+
+// Check returns a description of everything wrong with password, or ""
+// if it meets the policy
+func (p Policy) Check(password string) string {
+	var problems []string
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		problems = append(problems, fmt.Sprintf("be at least %d characters long", p.MinLength))
+	}
+	if len(password) > MaxBytes {
+		problems = append(problems, fmt.Sprintf("be at most %d bytes long", MaxBytes))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	var missing []string
+	for _, class := range []struct {
+		required, present bool
+		name              string
+	}{
+		{p.RequireLower, lower, "a lower-case letter"},
+		{p.RequireUpper, upper, "an upper-case letter"},
+		{p.RequireDigit, digit, "a digit"},
+		{p.RequireSymbol, symbol, "a symbol"},
+	} {
+		if class.required && !class.present {
+			missing = append(missing, class.name)
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "contain "+joinList(missing))
+	}
+
+	// Only worth saying once the password is otherwise acceptable
+	if len(problems) == 0 && p.Breached.Contains(password) {
+		return "has appeared in a data breach; choose another"
+	}
+	if len(problems) == 0 {
+		return ""
+	}
+	return "must " + joinList(problems)
+}
+
+// joinList joins items as "a, b and c"
+func joinList(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
@@ -0,0 +1,136 @@
+package pricing
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/util"
+	"math"
+	"time"
+)
+
+// Input describes the inventory being priced
+type Input struct {
+	Product   string    // flight or hotel
+	BasePrice float64   // static fare or nightly rate
+	Capacity  int       // total inventory; load factor rules are skipped if zero
+	Remaining int       // inventory still available
+	StartsAt  time.Time // departure or check-in, in the local time of the airport or hotel
+}
+
+// Adjustment is the effect of a single rule on a quote
+type Adjustment struct {
+	RuleID  uint    `json:"rule_id"`
+	Rule    string  `json:"rule"`
+	Percent float64 `json:"percent"`
+	Amount  float64 `json:"amount"`
+}
+
+// Quote is a price with the adjustments that produced it
+type Quote struct {
+	BasePrice   float64      `json:"base_price"`
+	Adjustments []Adjustment `json:"adjustments"`
+	Price       float64      `json:"price"`
+	QuotedAt    time.Time    `json:"quoted_at"`
+}
+
+// StayQuote is the nightly quote for a hotel stay and its total
+type StayQuote struct {
+	Quote
+	Nights     int     `json:"nights"`
+	TotalPrice float64 `json:"total_price"`
+}
+
+// RuleStore provides the pricing rules currently in force
+type RuleStore interface {
+	FindActive(ctx context.Context, product string) ([]entity.PricingRule, error)
+}
+
+// Engine prices inventory by applying every matching rule to the base
+// price. Adjustments are percentages of the base price and are summed, so
+// the result does not depend on rule order and is fully determined by the
+// rules, the input and the clock.
+type Engine struct {
+	rules RuleStore
+	clock util.Clock
+}
+
+func NewEngine(rules RuleStore, clock util.Clock) *Engine {
+	return &Engine{
+		rules: rules,
+		clock: clock,
+	}
+}
+
+func (e *Engine) Quote(ctx context.Context, in Input) (*Quote, error) {
+	rules, err := e.rules.FindActive(ctx, in.Product)
+	if err != nil {
+		return nil, err
+	}
+	return apply(rules, in, e.clock.Now()), nil
+}
+
+// QuoteAll prices several inputs at the same instant, loading each
+// product's rules once, and returns the quotes in input order
+func (e *Engine) QuoteAll(ctx context.Context, inputs []Input) ([]Quote, error) {
+	now := e.clock.Now()
+	rulesByProduct := make(map[string][]entity.PricingRule)
+	quotes := make([]Quote, len(inputs))
+	for i, in := range inputs {
+		rules, ok := rulesByProduct[in.Product]
+		if !ok {
+			var err error
+			if rules, err = e.rules.FindActive(ctx, in.Product); err != nil {
+				return nil, err
+			}
+			rulesByProduct[in.Product] = rules
+		}
+		quotes[i] = *apply(rules, in, now)
+	}
+	return quotes, nil
+}
+
+// apply prices in with every rule that applies to it at now
+func apply(rules []entity.PricingRule, in Input, now time.Time) *Quote {
+	quote := &Quote{
+		BasePrice:   in.BasePrice,
+		Adjustments: []Adjustment{},
+		QuotedAt:    now,
+	}
+
+	total := 0.0
+	for i := range rules {
+		condition, err := Compile(&rules[i])
+		if err != nil {
+			// Rules are validated when saved, so this only skips rules
+			// written to the store by other means
+			continue
+		}
+		if !condition.Applies(in, now) {
+			continue
+		}
+
+		amount := roundCents(in.BasePrice * rules[i].Percent / 100)
+		quote.Adjustments = append(quote.Adjustments, Adjustment{
+			RuleID:  rules[i].ID,
+			Rule:    rules[i].Name,
+			Percent: rules[i].Percent,
+			Amount:  amount,
+		})
+		total += amount
+	}
+
+	quote.Price = math.Max(0, roundCents(in.BasePrice+total))
+	return quote
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// NoRules is a RuleStore without any rules, for running with dynamic
+// pricing switched off
+type NoRules struct{}
+
+func (NoRules) FindActive(ctx context.Context, product string) ([]entity.PricingRule, error) {
+	return nil, nil
+}
@@ -0,0 +1,138 @@
+package pricing
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Condition decides whether a pricing rule applies to an input at the
+// given instant
+type Condition interface {
+	Applies(in Input, now time.Time) bool
+}
+
+// LoadFactor applies when the fraction of inventory already sold is within
+// [Min, Max]
+type LoadFactor struct {
+	Min, Max float64
+}
+
+func (c LoadFactor) Applies(in Input, now time.Time) bool {
+	if in.Capacity <= 0 {
+		return false
+	}
+	sold := float64(in.Capacity-in.Remaining) / float64(in.Capacity)
+	return sold >= c.Min && sold <= c.Max
+}
+
+// DaysBefore applies when the number of whole days until departure or
+// check-in is within [Min, Max]
+type DaysBefore struct {
+	Min, Max int
+}
+
+func (c DaysBefore) Applies(in Input, now time.Time) bool {
+	days := int(in.StartsAt.Sub(now).Hours() / 24)
+	return days >= c.Min && days <= c.Max
+}
+
+// DayOfWeek applies when departure or check-in falls on one of the given
+// weekdays, in the local time of StartsAt
+type DayOfWeek struct {
+	Days map[time.Weekday]bool
+}
+
+func (c DayOfWeek) Applies(in Input, now time.Time) bool {
+	return c.Days[in.StartsAt.Weekday()]
+}
+
+// Season applies when departure or check-in falls between two calendar
+// days, inclusive. A season ending before it starts wraps over new year.
+type Season struct {
+	Start, End monthDay
+}
+
+func (c Season) Applies(in Input, now time.Time) bool {
+	day := monthDay{in.StartsAt.Month(), in.StartsAt.Day()}
+	if c.Start.after(c.End) {
+		return !day.before(c.Start) || !day.after(c.End)
+	}
+	return !day.before(c.Start) && !day.after(c.End)
+}
+
+type monthDay struct {
+	Month time.Month
+	Day   int
+}
+
+func (d monthDay) before(o monthDay) bool {
+	return d.Month < o.Month || (d.Month == o.Month && d.Day < o.Day)
+}
+
+func (d monthDay) after(o monthDay) bool {
+	return o.before(d)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Compile turns a stored pricing rule into its condition, rejecting rules
+// whose parameters don't make sense for their type
+func Compile(rule *entity.PricingRule) (Condition, error) {
+	switch rule.Type {
+	case "load_factor":
+		if rule.Min < 0 || rule.Max > 1 || rule.Min > rule.Max {
+			return nil, fmt.Errorf("%w: load factor bounds must satisfy 0 <= min <= max <= 1", errors.ErrInvalidPricingRule)
+		}
+		return LoadFactor{Min: rule.Min, Max: rule.Max}, nil
+
+	case "days_before":
+		if rule.Min < 0 || rule.Min > rule.Max {
+			return nil, fmt.Errorf("%w: days before bounds must satisfy 0 <= min <= max", errors.ErrInvalidPricingRule)
+		}
+		return DaysBefore{Min: int(rule.Min), Max: int(rule.Max)}, nil
+
+	case "day_of_week":
+		days := make(map[time.Weekday]bool)
+		for _, name := range strings.Split(rule.Weekdays, ",") {
+			day, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown weekday %q", errors.ErrInvalidPricingRule, name)
+			}
+			days[day] = true
+		}
+		return DayOfWeek{Days: days}, nil
+
+	case "season":
+		start, err := parseMonthDay(rule.SeasonStart)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseMonthDay(rule.SeasonEnd)
+		if err != nil {
+			return nil, err
+		}
+		return Season{Start: start, End: end}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown rule type %q", errors.ErrInvalidPricingRule, rule.Type)
+}
+
+func parseMonthDay(value string) (monthDay, error) {
+	// Parse against a leap year so 02-29 is accepted
+	t, err := time.Parse("2006-01-02", "2024-"+value)
+	if err != nil {
+		return monthDay{}, fmt.Errorf("%w: season dates must be MM-DD, got %q", errors.ErrInvalidPricingRule, value)
+	}
+	return monthDay{t.Month(), t.Day()}, nil
+}
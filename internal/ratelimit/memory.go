@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+const (
+	memoryShards = 64
+	sweepEvery   = time.Minute
+)
+
+// MemoryStore keeps buckets in this process. Keys are spread over shards
+// with their own locks so unrelated clients don't contend, and buckets that
+// have refilled are dropped, as they're the same as absent ones.
+type MemoryStore struct {
+	shards [memoryShards]memoryShard
+}
+
+type memoryShard struct {
+	mu        sync.Mutex
+	full      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{}
+	for i := range s.shards {
+		s.shards[i].full = make(map[string]time.Time)
+	}
+	return s
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	key = policy.Name + ":" + key
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	shard := &s.shards[h.Sum32()%memoryShards]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.Sub(shard.lastSweep) > sweepEvery {
+		for k, full := range shard.full {
+			if !full.After(now) {
+				delete(shard.full, k)
+			}
+		}
+		shard.lastSweep = now
+	}
+
+	full, result := policy.take(shard.full[key], now)
+	shard.full[key] = full
+	return result, nil
+}
@@ -0,0 +1,61 @@
+// Package ratelimit implements token-bucket rate limiting over a pluggable
+// store, so limits can be kept in memory for a single instance or shared
+// between instances in Redis.
+//
+// Buckets are tracked with the generic cell rate algorithm: each key stores
+// only the time its bucket will be full again, which makes every check a
+// single read-modify-write.
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Policy allows Requests per Period, in bursts of up to Requests
+type Policy struct {
+	Name     string // distinguishes buckets of different policies for the same client
+	Requests int
+	Period   time.Duration
+}
+
+// Result is the outcome of spending one request
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until the next request would be allowed, if denied
+	Reset      time.Duration // until the bucket is full again
+}
+
+// Store keeps buckets. Take must be atomic per key.
+type Store interface {
+	// Take spends one request from key's bucket under policy
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+// take applies one request to a bucket full again at full, returning when
+// it will be full after the request and the result. A zero full is an
+// untouched bucket.
+func (p Policy) take(full, now time.Time) (time.Time, Result) {
+	interval := p.Period / time.Duration(p.Requests)
+	if full.Before(now) {
+		full = now
+	}
+
+	next := full.Add(interval)
+	if allowedAt := next.Add(-p.Period); now.Before(allowedAt) {
+		return full, Result{
+			Limit:      p.Requests,
+			RetryAfter: allowedAt.Sub(now),
+			Reset:      full.Sub(now),
+		}
+	}
+
+	return next, Result{
+		Allowed:   true,
+		Limit:     p.Requests,
+		Remaining: int((p.Period - next.Sub(now)) / interval),
+		Reset:     next.Sub(now),
+	}
+}
@@ -0,0 +1,275 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	redisKeyPrefix = "ratelimit:"
+	redisTimeout   = time.Second
+
+	// Concurrent requests for the same key retry their transaction when
+	// another wins the race, after a short random pause
+	redisAttempts = 10
+	redisBackoff  = 2 * time.Millisecond
+)
+
+// RedisOptions configures a RedisStore
+type RedisOptions struct {
+	Addr     string
+	Password string
+	DB       int
+	PoolSize int // idle connections kept open
+
+	// Dial opens connections, by default over TCP to Addr. Replacing it lets
+	// the store run against an in-process fake.
+	Dial func(ctx context.Context) (net.Conn, error)
+}
+
+// RedisStore keeps buckets in Redis, or anything speaking its protocol, so
+// every instance of the API shares the same limits. It updates buckets with
+// optimistic WATCH/MULTI/EXEC transactions and needs no scripting support.
+type RedisStore struct {
+	opts RedisOptions
+	idle chan *redisConn
+}
+
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.Dial == nil {
+		dialer := &net.Dialer{Timeout: redisTimeout}
+		opts.Dial = func(ctx context.Context) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", opts.Addr)
+		}
+	}
+	return &RedisStore{
+		opts: opts,
+		idle: make(chan *redisConn, opts.PoolSize),
+	}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	conn, err := s.get(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result, err := s.take(conn, redisKeyPrefix+policy.Name+":"+key, policy, now)
+	s.put(conn, err)
+	return result, err
+}
+
+func (s *RedisStore) take(conn *redisConn, key string, policy Policy, now time.Time) (Result, error) {
+	for attempt := 0; attempt < redisAttempts; attempt++ {
+		if _, err := conn.do("WATCH", key); err != nil {
+			return Result{}, err
+		}
+		reply, err := conn.do("GET", key)
+		if err != nil {
+			return Result{}, err
+		}
+
+		var full time.Time
+		if value, ok := reply.(string); ok {
+			nanos, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return Result{}, fmt.Errorf("ratelimit: bad bucket value %q: %w", value, err)
+			}
+			full = time.Unix(0, nanos)
+		}
+
+		next, result := policy.take(full, now)
+		if !result.Allowed {
+			_, err := conn.do("UNWATCH")
+			return result, err
+		}
+
+		// The key expires once the bucket has refilled
+		ttl := next.Sub(now).Milliseconds() + 1
+		replies, err := conn.pipeline(
+			[]string{"MULTI"},
+			[]string{"SET", key, strconv.FormatInt(next.UnixNano(), 10), "PX", strconv.FormatInt(ttl, 10)},
+			[]string{"EXEC"},
+		)
+		if err != nil {
+			return Result{}, err
+		}
+		if replies[2] != nil {
+			return result, nil
+		}
+		// Another request changed the key after WATCH; try again
+		time.Sleep(time.Duration(rand.Int63n(int64(redisBackoff) * int64(attempt+1))))
+	}
+
+	// Only one client's requests share a key, so losing every race means it
+	// is sending a flood of them at once: refuse this one rather than let
+	// the flood through unlimited
+	return Result{Limit: policy.Requests, RetryAfter: time.Second, Reset: policy.Period}, nil
+}
+
+func (s *RedisStore) get(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-s.idle:
+		return conn, nil
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	netConn, err := s.opts.Dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ratelimit: connecting to redis: %w", err)
+	}
+	conn := &redisConn{conn: netConn, r: bufio.NewReader(netConn), w: bufio.NewWriter(netConn)}
+
+	if s.opts.Password != "" {
+		if _, err := conn.do("AUTH", s.opts.Password); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	if s.opts.DB != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(s.opts.DB)); err != nil {
+			netConn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// put returns a connection to the pool, unless it failed and may be left
+// mid-reply or mid-transaction
+func (s *RedisStore) put(conn *redisConn, err error) {
+	if err != nil {
+		conn.conn.Close()
+		return
+	}
+	select {
+	case s.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+// Close closes the idle connections
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case conn := <-s.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisConn speaks RESP, the Redis protocol
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "ratelimit: redis: " + string(e)
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	replies, err := c.pipeline(args)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+// pipeline sends commands together and reads their replies. Error replies
+// are returned as the error.
+func (c *redisConn) pipeline(commands ...[]string) ([]interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(redisTimeout))
+
+	for _, args := range commands {
+		fmt.Fprintf(c.w, "*%d\r\n", len(args))
+		for _, arg := range args {
+			fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	var replyErr error
+	for i := range commands {
+		reply, err := c.read()
+		if e, ok := err.(redisError); ok {
+			// Keep reading so the connection stays in step
+			if replyErr == nil {
+				replyErr = e
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, replyErr
+}
+
+// read parses one reply: a string for simple and bulk strings, an int64
+// for integers, a []interface{} for arrays, and nil for null replies
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("ratelimit: malformed redis reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("ratelimit: unknown redis reply type %q", kind)
+	}
+}
@@ -0,0 +1,382 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves the handful of commands RedisStore sends over
+// in-process connections, with WATCH tracking each key's version
+type fakeRedis struct {
+	password string
+
+	mu       sync.Mutex
+	values   map[string]string
+	ttls     map[string]int64 // milliseconds, as given to SET PX
+	versions map[string]int
+	execs    int
+	aborted  int
+
+	// beforeExec, if set, runs before each EXEC is applied, without the
+	// lock held, so tests can change watched keys in between
+	beforeExec func()
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: make(map[string]string), ttls: make(map[string]int64), versions: make(map[string]int)}
+}
+
+func (f *fakeRedis) store(opts RedisOptions) *RedisStore {
+	opts.Dial = func(ctx context.Context) (net.Conn, error) {
+		client, server := net.Pipe()
+		go f.serve(server)
+		return client, nil
+	}
+	return NewRedisStore(opts)
+}
+
+// set changes key as another client would, breaking transactions watching it
+func (f *fakeRedis) set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value
+	f.versions[key]++
+}
+
+func (f *fakeRedis) get(key string) (string, int64, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.values[key]
+	return value, f.ttls[key], ok
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	authed := f.password == ""
+	watched := make(map[string]int)
+	var queued [][]string
+	multi := false
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(args[0])
+
+		switch {
+		case cmd == "AUTH":
+			if len(args) != 2 || args[1] != f.password {
+				fmt.Fprint(w, "-WRONGPASS invalid password\r\n")
+				break
+			}
+			authed = true
+			fmt.Fprint(w, "+OK\r\n")
+		case !authed:
+			fmt.Fprint(w, "-NOAUTH Authentication required.\r\n")
+		case cmd == "SELECT":
+			fmt.Fprint(w, "+OK\r\n")
+		case cmd == "WATCH":
+			f.mu.Lock()
+			for _, key := range args[1:] {
+				watched[key] = f.versions[key]
+			}
+			f.mu.Unlock()
+			fmt.Fprint(w, "+OK\r\n")
+		case cmd == "UNWATCH":
+			clear(watched)
+			fmt.Fprint(w, "+OK\r\n")
+		case cmd == "MULTI":
+			multi = true
+			fmt.Fprint(w, "+OK\r\n")
+		case cmd == "EXEC":
+			if f.beforeExec != nil {
+				f.beforeExec()
+			}
+			f.mu.Lock()
+			f.execs++
+			conflict := false
+			for key, version := range watched {
+				if f.versions[key] != version {
+					conflict = true
+				}
+			}
+			if conflict {
+				f.aborted++
+				fmt.Fprint(w, "*-1\r\n")
+			} else {
+				fmt.Fprintf(w, "*%d\r\n", len(queued))
+				for _, q := range queued {
+					f.apply(q)
+					fmt.Fprint(w, "+OK\r\n")
+				}
+			}
+			f.mu.Unlock()
+			clear(watched)
+			queued, multi = nil, false
+		case multi:
+			queued = append(queued, args)
+			fmt.Fprint(w, "+QUEUED\r\n")
+		case cmd == "GET":
+			f.mu.Lock()
+			value, ok := f.values[args[1]]
+			f.mu.Unlock()
+			if !ok {
+				fmt.Fprint(w, "$-1\r\n")
+				break
+			}
+			fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+		case cmd == "SET":
+			f.mu.Lock()
+			f.apply(args)
+			f.mu.Unlock()
+			fmt.Fprint(w, "+OK\r\n")
+		default:
+			fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// apply runs SET key value [PX ms] with f.mu held
+func (f *fakeRedis) apply(args []string) {
+	key := args[1]
+	f.values[key] = args[2]
+	f.versions[key]++
+	delete(f.ttls, key)
+	if len(args) == 5 && strings.EqualFold(args[3], "PX") {
+		f.ttls[key], _ = strconv.ParseInt(args[4], 10, 64)
+	}
+}
+
+// readCommand reads one command, sent as an array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("expected an array, got %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(header[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func TestRedisStoreTake(t *testing.T) {
+	fake := newFakeRedis()
+	store := fake.store(RedisOptions{})
+	defer store.Close()
+	ctx := context.Background()
+	policy := Policy{Name: "api", Requests: 2, Period: time.Second}
+	start := time.Unix(1_800_000_000, 0)
+
+	tests := []struct {
+		name       string
+		at         time.Duration // after start
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{name: "first request", allowed: true, remaining: 1, reset: 500 * time.Millisecond},
+		{name: "second request empties the bucket", allowed: true, remaining: 0, reset: time.Second},
+		{name: "third request is denied", allowed: false, retryAfter: 500 * time.Millisecond, reset: time.Second},
+		{name: "denied requests cost nothing", at: 100 * time.Millisecond, allowed: false, retryAfter: 400 * time.Millisecond, reset: 900 * time.Millisecond},
+		{name: "allowed once a request has refilled", at: 500 * time.Millisecond, allowed: true, remaining: 0, reset: time.Second},
+		{name: "full again after the period", at: 3 * time.Second, allowed: true, remaining: 1, reset: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.Take(ctx, "client", policy, start.Add(tt.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Result{Allowed: tt.allowed, Limit: 2, Remaining: tt.remaining, RetryAfter: tt.retryAfter, Reset: tt.reset}
+			if result != want {
+				t.Errorf("Take = %+v, want %+v", result, want)
+			}
+		})
+	}
+
+	value, ttl, ok := fake.get(redisKeyPrefix + "api:client")
+	if !ok {
+		t.Fatal("bucket was not stored under its prefixed key")
+	}
+	wantFull := start.Add(3*time.Second + 500*time.Millisecond)
+	if value != strconv.FormatInt(wantFull.UnixNano(), 10) {
+		t.Errorf("stored %s, want the bucket full at %d", value, wantFull.UnixNano())
+	}
+	if ttl != 501 {
+		t.Errorf("key expires in %dms, want 501ms, once the bucket has refilled", ttl)
+	}
+}
+
+func TestRedisStoreKeysArePerPolicyAndClient(t *testing.T) {
+	fake := newFakeRedis()
+	store := fake.store(RedisOptions{})
+	defer store.Close()
+	ctx := context.Background()
+	now := time.Unix(1_800_000_000, 0)
+	one := Policy{Name: "one", Requests: 1, Period: time.Minute}
+	other := Policy{Name: "other", Requests: 1, Period: time.Minute}
+
+	for _, take := range []struct {
+		key    string
+		policy Policy
+	}{{"a", one}, {"b", one}, {"a", other}} {
+		result, err := store.Take(ctx, take.key, take.policy, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed {
+			t.Errorf("%s under %s was denied; buckets are shared", take.key, take.policy.Name)
+		}
+	}
+}
+
+func TestRedisStoreRetriesLostRaces(t *testing.T) {
+	policy := Policy{Name: "api", Requests: 10, Period: time.Second}
+	now := time.Unix(1_800_000_000, 0)
+	key := redisKeyPrefix + "api:client"
+
+	tests := []struct {
+		name      string
+		conflicts int
+		allowed   bool
+		execs     int
+	}{
+		{name: "no race", conflicts: 0, allowed: true, execs: 1},
+		{name: "wins on a retry", conflicts: 3, allowed: true, execs: 4},
+		{name: "refused after losing every attempt", conflicts: redisAttempts, allowed: false, execs: redisAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRedis()
+			conflicts := tt.conflicts
+			fake.beforeExec = func() {
+				if conflicts > 0 {
+					conflicts--
+					fake.set(key, strconv.FormatInt(now.UnixNano(), 10))
+				}
+			}
+			store := fake.store(RedisOptions{})
+			defer store.Close()
+
+			result, err := store.Take(context.Background(), "client", policy, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("Allowed = %v, want %v", result.Allowed, tt.allowed)
+			}
+			if !tt.allowed && result.RetryAfter <= 0 {
+				t.Errorf("refused without a Retry-After: %+v", result)
+			}
+			if fake.execs != tt.execs || fake.aborted != tt.conflicts {
+				t.Errorf("ran %d transactions with %d aborted, want %d with %d", fake.execs, fake.aborted, tt.execs, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestRedisStoreConcurrentTakesLoseNoUpdates(t *testing.T) {
+	fake := newFakeRedis()
+	store := fake.store(RedisOptions{PoolSize: 8})
+	defer store.Close()
+	policy := Policy{Name: "api", Requests: 1000, Period: time.Second}
+	now := time.Unix(1_800_000_000, 0)
+
+	const workers, each = 8, 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < each; j++ {
+				result, err := store.Take(context.Background(), "client", policy, now)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if result.Allowed {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every allowed request must be counted in the bucket, whatever the
+	// interleaving of the transactions
+	value, _, _ := fake.get(redisKeyPrefix + "api:client")
+	interval := policy.Period / time.Duration(policy.Requests)
+	want := now.Add(time.Duration(allowed) * interval)
+	if value != strconv.FormatInt(want.UnixNano(), 10) {
+		t.Errorf("bucket full at %s after %d allowed requests, want %d", value, allowed, want.UnixNano())
+	}
+	if allowed == 0 {
+		t.Error("no request was allowed")
+	}
+}
+
+func TestRedisStoreAuthenticates(t *testing.T) {
+	policy := Policy{Name: "api", Requests: 1, Period: time.Second}
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "right password", password: "secret"},
+		{name: "wrong password", password: "guess", wantErr: true},
+		{name: "no password", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRedis()
+			fake.password = "secret"
+			store := fake.store(RedisOptions{Password: tt.password, DB: 2})
+			defer store.Close()
+
+			_, err := store.Take(context.Background(), "client", policy, time.Now())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Take = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
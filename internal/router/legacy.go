@@ -0,0 +1,132 @@
+package router
+
+import (
+	"fledge-restapi/internal/middleware"
+	"fledge-restapi/internal/openapi"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legacyDeprecated is when the unversioned paths were deprecated in favour
+// of /v1
+var legacyDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// legacyRoute is an unversioned path kept working, with its original
+// authentication, until its sunset
+type legacyRoute struct {
+	Method    string
+	Path      string
+	Handler   gin.HandlerFunc
+	Auth      bool
+	Role      string
+	Successor string                   // method and path of the /v1 route replacing it, e.g. "GET /flights/:id"
+	Doc       func(*openapi.Operation) // adjusts the successor's documentation where the two differ
+}
+
+func legacyRoutes(h Handlers) []legacyRoute {
+	return []legacyRoute{
+		{Method: http.MethodPost, Path: "/auth/signup", Handler: h.User.Signup, Successor: "POST /auth/signup"},
+		{Method: http.MethodPost, Path: "/auth/login", Handler: h.User.Login, Successor: "POST /auth/login"},
+
+		{Method: http.MethodPost, Path: "/api/flights/search", Handler: h.Flight.SearchFlights, Successor: "POST /flights/search"},
+		{
+			Method: http.MethodGet, Path: "/api/flights/get-all", Handler: h.Flight.ListAllFlights, Successor: "GET /flights",
+			Doc: func(op *openapi.Operation) {
+				op.Summary, op.Description, op.Params = "List all flights", "", nil
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/api/flights/search/origin", Handler: h.Flight.ListFlightsByOrigin, Successor: "GET /flights",
+			Doc: func(op *openapi.Operation) {
+				op.Summary, op.Description = "List flights from an origin", ""
+				op.Params = []openapi.Param{openapi.Query("origin", "Departure city", "", true)}
+				op.Responses = append(op.Responses, badRequest)
+			},
+		},
+		{Method: http.MethodGet, Path: "/api/flights/:id", Handler: h.Flight.GetFlight, Auth: true, Successor: "GET /flights/:id"},
+		{Method: http.MethodPost, Path: "/api/flights/:id/book", Handler: h.Flight.LegacyBookFlight, Auth: true, Successor: "POST /flights/:id/book", Doc: travellersOptional},
+		{Method: http.MethodGet, Path: "/api/flights/:id/fares/:fareId/quote", Handler: h.Flight.QuoteFare, Auth: true, Successor: "GET /flights/:id/fares/:fareId/quote"},
+		{Method: http.MethodGet, Path: "/api/flights/:id/seats", Handler: h.Seat.GetSeatMap, Auth: true, Successor: "GET /flights/:id/seats"},
+		{Method: http.MethodPost, Path: "/api/flights/:id/seats", Handler: h.Seat.CreateSeatMap, Auth: true, Role: "admin", Successor: "POST /flights/:id/seats"},
+
+		{Method: http.MethodGet, Path: "/api/hotels/search", Handler: h.Hotel.SearchHotels, Auth: true, Successor: "POST /hotels/search"},
+		{Method: http.MethodGet, Path: "/api/hotels/:id", Handler: h.Hotel.GetHotel, Auth: true, Successor: "GET /hotels/:id"},
+		{Method: http.MethodGet, Path: "/api/hotels/:id/quote", Handler: h.Hotel.QuoteStay, Auth: true, Successor: "GET /hotels/:id/quote"},
+		{Method: http.MethodPost, Path: "/api/hotels/:id/book", Handler: h.Hotel.LegacyBookHotel, Auth: true, Successor: "POST /hotels/:id/book", Doc: travellersOptional},
+
+		{Method: http.MethodGet, Path: "/api/bookings", Handler: h.Booking.ListBookings, Auth: true, Successor: "GET /bookings"},
+		{Method: http.MethodGet, Path: "/api/bookings/:id", Handler: h.Booking.GetBooking, Auth: true, Successor: "GET /bookings/:id"},
+		{Method: http.MethodPatch, Path: "/api/bookings/:id", Handler: h.Booking.UpdateBooking, Auth: true, Successor: "PATCH /bookings/:id"},
+		{Method: http.MethodDelete, Path: "/api/bookings/:id", Handler: h.Booking.CancelBooking, Auth: true, Successor: "DELETE /bookings/:id"},
+		{Method: http.MethodPost, Path: "/api/bookings/:id/confirm", Handler: h.Booking.ConfirmBooking, Auth: true, Successor: "POST /bookings/:id/confirm"},
+		{Method: http.MethodPut, Path: "/api/bookings/:id/seats", Handler: h.Seat.AssignSeats, Auth: true, Successor: "PUT /bookings/:id/seats"},
+
+		{Method: http.MethodGet, Path: "/api/saved-searches", Handler: h.SavedSearch.ListSavedSearches, Auth: true, Successor: "GET /saved-searches"},
+		{Method: http.MethodPost, Path: "/api/saved-searches", Handler: h.SavedSearch.CreateSavedSearch, Auth: true, Successor: "POST /saved-searches"},
+		{Method: http.MethodDelete, Path: "/api/saved-searches/:id", Handler: h.SavedSearch.DeleteSavedSearch, Auth: true, Successor: "DELETE /saved-searches/:id"},
+		{Method: http.MethodGet, Path: "/api/alerts", Handler: h.SavedSearch.ListAlerts, Auth: true, Successor: "GET /alerts"},
+		{Method: http.MethodPost, Path: "/api/alerts/:id/read", Handler: h.SavedSearch.MarkAlertRead, Auth: true, Successor: "POST /alerts/:id/read"},
+
+		{Method: http.MethodGet, Path: "/api/profile", Handler: h.User.GetProfile, Auth: true, Successor: "GET /profile"},
+		{Method: http.MethodGet, Path: "/api/profile/travellers", Handler: h.Traveller.ListSavedTravellers, Auth: true, Successor: "GET /profile/travellers"},
+		{Method: http.MethodPost, Path: "/api/profile/travellers", Handler: h.Traveller.SaveTraveller, Auth: true, Successor: "POST /profile/travellers"},
+		{Method: http.MethodDelete, Path: "/api/profile/travellers/:id", Handler: h.Traveller.DeleteSavedTraveller, Auth: true, Successor: "DELETE /profile/travellers/:id"},
+
+		{Method: http.MethodGet, Path: "/api/admin/pricing-rules", Handler: h.Pricing.ListRules, Auth: true, Role: "admin", Successor: "GET /admin/pricing-rules"},
+		{Method: http.MethodPost, Path: "/api/admin/pricing-rules", Handler: h.Pricing.CreateRule, Auth: true, Role: "admin", Successor: "POST /admin/pricing-rules"},
+		{Method: http.MethodPut, Path: "/api/admin/pricing-rules/:id", Handler: h.Pricing.UpdateRule, Auth: true, Role: "admin", Successor: "PUT /admin/pricing-rules/:id"},
+		{Method: http.MethodDelete, Path: "/api/admin/pricing-rules/:id", Handler: h.Pricing.DeleteRule, Auth: true, Role: "admin", Successor: "DELETE /admin/pricing-rules/:id"},
+	}
+}
+
+// travellersOptional documents the unversioned booking paths, which accept
+// bookings without travellers as they did before travellers were recorded
+func travellersOptional(op *openapi.Operation) {
+	op.Description += ". Travellers may be left out, booking every guest as an adult"
+}
+
+// registerLegacy adds the unversioned routes, each documented like its
+// successor in successors but marked deprecated
+func registerLegacy(r *gin.Engine, routes []legacyRoute, successors Version, opts Options) []openapi.Operation {
+	byKey := make(map[string]Route, len(successors.Routes))
+	for _, route := range successors.Routes {
+		byKey[route.Method+" "+route.Path] = route
+	}
+
+	ops := make([]openapi.Operation, 0, len(routes))
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route.Successor, " ")
+		successorPath := "/" + successors.Name + path
+
+		deprecated := middleware.Deprecated(legacyDeprecated, opts.LegacySunset, successorPath)
+		r.Handle(route.Method, route.Path, append([]gin.HandlerFunc{deprecated}, chain(route.Handler, route.Auth, route.Role, "", opts)...)...)
+
+		// A successor missing from the table leaves the route undocumented,
+		// which openapi.Build reports
+		successor, ok := byKey[route.Successor]
+		if !ok {
+			continue
+		}
+		doc := successor.Doc
+		doc.Params = slices.Clone(doc.Params)
+		doc.Responses = slices.Clone(doc.Responses)
+		if route.Auth && !successor.Auth {
+			doc.Responses = append(doc.Responses, unauthorized)
+		}
+		if route.Doc != nil {
+			route.Doc(&doc)
+		}
+
+		note := "Deprecated: use " + method + " " + openapi.Path(successorPath)
+		if doc.Description != "" {
+			note = doc.Description + ". " + note
+		}
+		doc.Method, doc.Path, doc.Auth, doc.Deprecated, doc.Description = route.Method, route.Path, route.Auth, true, note
+		ops = append(ops, doc)
+	}
+	return ops
+}
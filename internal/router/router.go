@@ -0,0 +1,167 @@
+// Package router holds the API's route table. Routes are grouped into
+// versions served side by side under their own prefix (/v1, /v2, ...), and
+// the unversioned /api and /auth paths the API started with are kept as
+// deprecated aliases of their /v1 successors.
+package router
+
+import (
+	"fledge-restapi/internal/handler"
+	"fledge-restapi/internal/middleware"
+	"fledge-restapi/internal/openapi"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers are the handlers routes are served by
+type Handlers struct {
+	User        *handler.UserHandler
+	Flight      *handler.FlightHandler
+	Hotel       *handler.HotelHandler
+	Booking     *handler.BookingHandler
+	Seat        *handler.SeatHandler
+	Traveller   *handler.TravellerHandler
+	Pricing     *handler.PricingHandler
+	SavedSearch *handler.SavedSearchHandler
+	Keys        *handler.KeysHandler
+	APIKey      *handler.APIKeyHandler
+}
+
+type Options struct {
+	Auth         gin.HandlerFunc                    // authenticates requests to routes that require it
+	APIKey       func(scope string) gin.HandlerFunc // authenticates API keys, allowing those with the route's scope
+	IPRateLimit  gin.HandlerFunc                    // runs before authentication, so failed attempts are limited too
+	RateLimit    gin.HandlerFunc                    // runs after authentication so it can limit per user
+	LegacySunset time.Time                          // when the unversioned paths will be removed
+}
+
+// Route is one endpoint of an API version
+type Route struct {
+	Method  string
+	Path    string // relative to the version prefix, in gin's syntax
+	Handler gin.HandlerFunc
+	Auth    bool   // requires a bearer token
+	Role    string // role required, if any
+	Scope   string // API key scope allowed to call the route; keys are refused without one
+	Doc     openapi.Operation
+}
+
+// Version is a set of routes served under /<Name>. Once a newer version
+// replaces it, set Deprecated and Sunset so clients are warned.
+type Version struct {
+	Name       string
+	Routes     []Route
+	Deprecated time.Time
+	Sunset     time.Time
+}
+
+// Derive returns a version named name with v's routes, where each change
+// replaces the route with the same method and path or adds a new one. It
+// lets a v2 change only the endpoints that differ from v1.
+func (v Version) Derive(name string, changes ...Route) Version {
+	derived := Version{Name: name, Routes: append([]Route(nil), v.Routes...)}
+	for _, change := range changes {
+		replaced := false
+		for i, route := range derived.Routes {
+			if route.Method == change.Method && route.Path == change.Path {
+				derived.Routes[i] = change
+				replaced = true
+			}
+		}
+		if !replaced {
+			derived.Routes = append(derived.Routes, change)
+		}
+	}
+	return derived
+}
+
+// Register adds every API version, the legacy paths and the API
+// documentation to r
+func Register(r *gin.Engine, h Handlers, opts Options) error {
+	v1 := V1(h)
+	versions := []Version{v1}
+
+	var ops []openapi.Operation
+	for _, version := range versions {
+		ops = append(ops, registerVersion(r, version, opts)...)
+	}
+	ops = append(ops, registerLegacy(r, legacyRoutes(h), v1, opts)...)
+	if opts.IPRateLimit != nil || opts.RateLimit != nil {
+		for i := range ops {
+			limited := slices.ContainsFunc(ops[i].Responses, func(r openapi.Response) bool {
+				return r.Status == http.StatusTooManyRequests
+			})
+			if !limited {
+				ops[i].Responses = append(slices.Clone(ops[i].Responses),
+					failure(http.StatusTooManyRequests, "Rate limit exceeded; retry after the Retry-After header's seconds"))
+			}
+		}
+	}
+
+	spec, err := openapi.Build(openapi.Info{
+		Title:       "Fledge API",
+		Description: "Search and book flights, hotels and vacation packages",
+		Version:     "1.0",
+	}, r.Routes(), ops)
+	if err != nil {
+		return err
+	}
+	docs := handler.NewDocsHandler(spec)
+	r.GET("/openapi.json", docs.Spec)
+	r.GET("/docs", docs.UI)
+	r.GET("/.well-known/jwks.json", h.Keys.JWKS)
+
+	return nil
+}
+
+// registerVersion adds a version's routes, returning their documentation
+func registerVersion(r *gin.Engine, version Version, opts Options) []openapi.Operation {
+	group := r.Group("/" + version.Name)
+	if !version.Deprecated.IsZero() {
+		group.Use(middleware.Deprecated(version.Deprecated, version.Sunset, ""))
+	}
+
+	ops := make([]openapi.Operation, 0, len(version.Routes))
+	for _, route := range version.Routes {
+		group.Handle(route.Method, route.Path, chain(route.Handler, route.Auth, route.Role, route.Scope, opts)...)
+
+		op := route.Doc
+		op.Method, op.Path, op.Auth = route.Method, group.BasePath()+route.Path, route.Auth
+		if opts.APIKey != nil && route.Scope != "" {
+			op.Scope = route.Scope
+			op.Responses = slices.Clone(op.Responses)
+			if !route.Auth {
+				op.Responses = append(op.Responses, failure(http.StatusUnauthorized, "Invalid, expired or revoked API key"))
+			}
+			op.Responses = append(op.Responses, failure(http.StatusForbidden, "API key lacks the "+route.Scope+" scope"))
+		}
+		op.Deprecated = !version.Deprecated.IsZero()
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// chain returns the handlers for a route: per-IP rate limiting, API key
+// and bearer token authentication if it needs it, per-client rate limiting,
+// a role check if it needs one, then the route's own handler
+func chain(h gin.HandlerFunc, auth bool, role, scope string, opts Options) []gin.HandlerFunc {
+	var handlers []gin.HandlerFunc
+	if opts.IPRateLimit != nil {
+		handlers = append(handlers, opts.IPRateLimit)
+	}
+	if opts.APIKey != nil {
+		handlers = append(handlers, opts.APIKey(scope))
+	}
+	if auth {
+		handlers = append(handlers, opts.Auth)
+	}
+	if opts.RateLimit != nil {
+		handlers = append(handlers, opts.RateLimit)
+	}
+	if role != "" {
+		handlers = append(handlers, middleware.RequireRole(role))
+	}
+	return append(handlers, h)
+}
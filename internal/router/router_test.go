@@ -0,0 +1,165 @@
+package router
+
+import (
+	"encoding/json"
+	"fledge-restapi/internal/openapi"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func pass(c *gin.Context) { c.Next() }
+
+// serving is routes gin serves outside the spec: the spec and docs themselves
+// and the JWKS document
+var serving = map[string]bool{
+	"GET /openapi.json":          true,
+	"GET /docs":                  true,
+	"GET /.well-known/jwks.json": true,
+}
+
+func TestRegisterDocumentsEveryRoute(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{name: "bearer tokens only", opts: Options{Auth: pass}},
+		{name: "API keys and rate limiting", opts: Options{
+			Auth:      pass,
+			APIKey:    func(string) gin.HandlerFunc { return pass },
+			RateLimit: pass,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			if err := Register(r, Handlers{}, tt.opts); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /openapi.json = %d", w.Code)
+			}
+			var spec struct {
+				Paths map[string]map[string]json.RawMessage `json:"paths"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+				t.Fatal(err)
+			}
+
+			documented := make(map[string]bool)
+			for path, methods := range spec.Paths {
+				for method := range methods {
+					documented[strings.ToUpper(method)+" "+path] = true
+				}
+			}
+			registered := make(map[string]bool)
+			for _, route := range r.Routes() {
+				if serving[route.Method+" "+route.Path] {
+					continue
+				}
+				key := route.Method + " " + openapi.Path(route.Path)
+				registered[key] = true
+				if !documented[key] {
+					t.Errorf("registered but missing from the spec: %s", key)
+				}
+			}
+			for key := range documented {
+				if !registered[key] {
+					t.Errorf("in the spec but not registered: %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRejectsRoutesOutOfStep(t *testing.T) {
+	v1 := V1(Handlers{})
+	tests := []struct {
+		name   string
+		legacy []legacyRoute
+		extra  func(r *gin.Engine, ops []openapi.Operation) []openapi.Operation
+		want   string
+	}{
+		{
+			name:   "legacy route without a successor",
+			legacy: []legacyRoute{{Method: http.MethodGet, Path: "/api/gone", Successor: "GET /gone", Handler: pass}},
+			want:   "registered but not documented: GET /api/gone",
+		},
+		{
+			name: "route added outside the table",
+			extra: func(r *gin.Engine, ops []openapi.Operation) []openapi.Operation {
+				r.GET("/v1/hidden", pass)
+				return ops
+			},
+			want: "registered but not documented: GET /v1/hidden",
+		},
+		{
+			name: "operation without a route",
+			extra: func(r *gin.Engine, ops []openapi.Operation) []openapi.Operation {
+				return append(ops, openapi.Operation{Method: http.MethodDelete, Path: "/v1/flights/:id"})
+			},
+			want: "documented but not registered: DELETE /v1/flights/:id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			opts := Options{Auth: pass}
+			ops := registerVersion(r, v1, opts)
+			ops = append(ops, registerLegacy(r, tt.legacy, v1, opts)...)
+			if tt.extra != nil {
+				ops = tt.extra(r, ops)
+			}
+			_, err := openapi.Build(openapi.Info{Title: "test"}, r.Routes(), ops)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Build = %v, want an error reporting %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChainLimitsPerIPBeforeAuthentication(t *testing.T) {
+	var ran []string
+	step := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) { ran = append(ran, name) }
+	}
+	opts := Options{
+		IPRateLimit: step("ip limit"),
+		APIKey:      func(string) gin.HandlerFunc { return step("api key") },
+		Auth:        step("auth"),
+		RateLimit:   step("client limit"),
+	}
+
+	tests := []struct {
+		name string
+		auth bool
+		want []string
+	}{
+		{name: "authenticated route", auth: true, want: []string{"ip limit", "api key", "auth", "client limit", "handler"}},
+		{name: "public route", want: []string{"ip limit", "api key", "client limit", "handler"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran = nil
+			for _, h := range chain(step("handler"), tt.auth, "", "flights:read", opts) {
+				h(nil)
+			}
+			if !slices.Equal(ran, tt.want) {
+				t.Errorf("chain runs %v, want %v", ran, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,623 @@
+package router
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/openapi"
+	"fledge-restapi/internal/pricing"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+)
+
+// Responses shared by many operations
+var (
+	messageBody  = entity.MessageResponse{}
+	badRequest   = failure(http.StatusBadRequest, "Invalid request")
+	unauthorized = failure(http.StatusUnauthorized, "Missing or invalid token")
+	forbidden    = failure(http.StatusForbidden, "Requires the admin role")
+)
+
+func ok(body interface{}) openapi.Response {
+	return openapi.Response{Status: http.StatusOK, Body: body}
+}
+
+func created(body interface{}) openapi.Response {
+	return openapi.Response{Status: http.StatusCreated, Body: body}
+}
+
+func accepted(body interface{}) openapi.Response {
+	return openapi.Response{Status: http.StatusAccepted, Body: body}
+}
+
+func failure(status int, description string) openapi.Response {
+	return openapi.Response{Status: status, Description: description, Body: errors.ErrorResponse{}}
+}
+
+// V1 is the first versioned API. Browsing flights and hotels is public;
+// booking and everything tied to an account needs a bearer token.
+func V1(h Handlers) Version {
+	return Version{Name: "v1", Routes: []Route{
+		// Authentication
+		{
+			Method: http.MethodPost, Path: "/auth/signup", Handler: h.User.Signup,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Register a new user",
+				Description: "The password must meet the server's password policy. " +
+					"Invalid fields are each described in the error response's fields.",
+				Body: entity.SignupRequest{},
+				Responses: []openapi.Response{
+					created(messageBody), badRequest,
+					failure(http.StatusConflict, "Email already registered"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/login", Handler: h.User.Login,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Log in",
+				Description: "Exchange an email and password for an access token. " +
+					"Repeated failures make further attempts for the email wait, then lock it out for a while. " +
+					"Accounts with two-factor authentication get mfa_required and an mfa_token to complete at /v1/auth/2fa/verify instead.",
+				Body: entity.LoginRequest{},
+				Responses: []openapi.Response{
+					ok(entity.LoginResult{}), badRequest,
+					failure(http.StatusUnauthorized, "Invalid credentials"),
+					failure(http.StatusForbidden, "Email address not verified"),
+					failure(http.StatusTooManyRequests, "Too many attempts; retry after the Retry-After header's seconds"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/verify-email/request", Handler: h.User.RequestEmailVerification,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Resend the email verification link",
+				Description: "Email a new verification link if the address belongs to an unverified account. " +
+					"The response is the same either way.",
+				Body:      entity.EmailRequest{},
+				Responses: []openapi.Response{accepted(messageBody), badRequest},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/verify-email/confirm", Handler: h.User.VerifyEmail,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Verify an email address",
+				Description: "Redeem the token from a verification email",
+				Body:        entity.VerifyEmailRequest{},
+				Responses:   []openapi.Response{ok(messageBody), failure(http.StatusBadRequest, "Invalid or expired token")},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/password-reset/request", Handler: h.User.RequestPasswordReset,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Request a password reset",
+				Description: "Email a password reset link if the address belongs to an account. " +
+					"The response is the same either way.",
+				Body:      entity.EmailRequest{},
+				Responses: []openapi.Response{accepted(messageBody), badRequest},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/password-reset/confirm", Handler: h.User.ResetPassword,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Reset a password",
+				Description: "Set a new password with the token from a password reset email",
+				Body:        entity.ResetPasswordRequest{},
+				Responses:   []openapi.Response{ok(messageBody), failure(http.StatusBadRequest, "Invalid or expired token")},
+			},
+		},
+
+		// Single sign-on
+		{
+			Method: http.MethodPost, Path: "/auth/oidc/:provider/start", Handler: h.User.StartOIDCLogin,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Start signing in with an identity provider",
+				Description: "Get the OpenID Connect provider URL to send the browser to. " +
+					"The web app should keep the state and check the provider sends the same one back.",
+				Responses: []openapi.Response{
+					ok(entity.OIDCStartResponse{}),
+					failure(http.StatusNotFound, "Unknown provider"),
+					failure(http.StatusBadGateway, "Provider unavailable"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/oidc/:provider/callback", Handler: h.User.CompleteOIDCLogin,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Finish signing in with an identity provider",
+				Description: "Exchange the code and state the provider redirected back with for an access token, or a two-factor challenge as from login. " +
+					"The first sign-in links the account with the provider's verified email, or creates one.",
+				Body: entity.OIDCCallbackRequest{},
+				Responses: []openapi.Response{
+					ok(entity.LoginResult{}),
+					failure(http.StatusBadRequest, "Invalid or expired state"),
+					failure(http.StatusUnauthorized, "Provider rejected the sign-in"),
+					failure(http.StatusForbidden, "Provider did not confirm an email address"),
+					failure(http.StatusNotFound, "Unknown provider"),
+					failure(http.StatusBadGateway, "Provider unavailable"),
+				},
+			},
+		},
+
+		// Two-factor authentication
+		{
+			Method: http.MethodPost, Path: "/auth/2fa/verify", Handler: h.User.VerifyTwoFactor,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Complete a two-factor login",
+				Description: "Exchange the mfa_token from login and an authenticator or recovery code for an access token. " +
+					"Wrong codes count as failed logins.",
+				Body: entity.TwoFactorLoginRequest{},
+				Responses: []openapi.Response{
+					ok(entity.TokenResponse{}), badRequest,
+					failure(http.StatusUnauthorized, "Invalid code, or invalid or expired mfa_token"),
+					failure(http.StatusTooManyRequests, "Too many attempts; retry after the Retry-After header's seconds"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/2fa/setup", Handler: h.User.SetUpTwoFactor, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Start setting up two-factor authentication",
+				Description: "Generate a new authenticator secret, replacing any unconfirmed one. It takes effect once confirmed at /v1/auth/2fa/enable.",
+				Responses: []openapi.Response{
+					ok(entity.TwoFactorSetupResponse{}), unauthorized,
+					failure(http.StatusConflict, "Two-factor authentication already enabled"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/2fa/enable", Handler: h.User.EnableTwoFactor, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Turn on two-factor authentication",
+				Description: "Confirm the secret from setup with a current code. The recovery codes are only shown once.",
+				Body:        entity.TwoFactorCodeRequest{},
+				Responses: []openapi.Response{
+					ok(entity.RecoveryCodesResponse{}), badRequest, unauthorized,
+					failure(http.StatusConflict, "Two-factor authentication already enabled or not set up"),
+					failure(http.StatusTooManyRequests, "Too many attempts; retry after the Retry-After header's seconds"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/2fa/disable", Handler: h.User.DisableTwoFactor, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Turn off two-factor authentication",
+				Description: "Remove the authenticator and recovery codes, given an authenticator or recovery code",
+				Body:        entity.TwoFactorCodeRequest{},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusConflict, "Two-factor authentication not enabled"),
+					failure(http.StatusTooManyRequests, "Too many attempts; retry after the Retry-After header's seconds"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/2fa/recovery-codes", Handler: h.User.RegenerateRecoveryCodes, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Replace the recovery codes",
+				Description: "Issue a new set of recovery codes, given a current authenticator code. The old codes stop working.",
+				Body:        entity.TwoFactorCodeRequest{},
+				Responses: []openapi.Response{
+					ok(entity.RecoveryCodesResponse{}), badRequest, unauthorized,
+					failure(http.StatusConflict, "Two-factor authentication not enabled"),
+					failure(http.StatusTooManyRequests, "Too many attempts; retry after the Retry-After header's seconds"),
+				},
+			},
+		},
+
+		// Sessions
+		{
+			Method: http.MethodGet, Path: "/auth/sessions", Handler: h.User.ListSessions, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "List signed-in sessions",
+				Description: "List the devices the user is signed in on, most recently active first. " +
+					"The one making the request is marked current.",
+				Responses: []openapi.Response{ok([]entity.Session{}), unauthorized},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/auth/sessions/:id", Handler: h.User.RevokeSession, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Sign a session out",
+				Description: "Revoke one of the user's sessions. Its access token stops working at once; revoking the current session signs out.",
+				Params:      []openapi.Param{{Name: "id", In: "path", Description: "Session ID", Required: true, Type: ""}},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Session not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/auth/sessions/revoke-others", Handler: h.User.RevokeOtherSessions, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "auth", Summary: "Sign out everywhere else",
+				Description: "Revoke every session of the user's except the one making the request",
+				Responses:   []openapi.Response{ok(entity.RevokedSessionsResponse{}), unauthorized},
+			},
+		},
+
+		// Flights
+		{
+			Method: http.MethodGet, Path: "/flights", Handler: h.Flight.ListFlights, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "List flights",
+				Description: "List every flight, or only those from one city",
+				Params:      []openapi.Param{openapi.Query("origin", "Departure city", "", false)},
+				Responses:   []openapi.Response{ok([]entity.FlightResponse{})},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/flights/search", Handler: h.Flight.SearchFlights, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "Search for flights",
+				Description: "Search for flights based on criteria",
+				Body:        entity.FlightSearchRequest{},
+				Responses:   []openapi.Response{ok([]entity.FlightResponse{}), badRequest},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/flights/:id", Handler: h.Flight.GetFlight, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "Get flight details",
+				Description: "Get detailed information about a specific flight",
+				Params:      []openapi.Param{openapi.PathID("id", "Flight ID")},
+				Responses: []openapi.Response{
+					ok(entity.FlightResponse{}), badRequest,
+					failure(http.StatusNotFound, "Flight not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/flights/:id/fares/:fareId/quote", Handler: h.Flight.QuoteFare, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "Quote a fare",
+				Description: "Get the current price of one adult seat on a fare, with the pricing adjustments applied",
+				Params: []openapi.Param{
+					openapi.PathID("id", "Flight ID"),
+					openapi.PathID("fareId", "Fare class ID"),
+				},
+				Responses: []openapi.Response{
+					ok(pricing.Quote{}), badRequest,
+					failure(http.StatusNotFound, "Flight or fare not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/flights/:id/seats", Handler: h.Seat.GetSeatMap, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "Get flight seat map",
+				Description: "Get every seat on a flight with its attributes and availability",
+				Params:      []openapi.Param{openapi.PathID("id", "Flight ID")},
+				Responses: []openapi.Response{
+					ok([]entity.SeatResponse{}), badRequest,
+					failure(http.StatusNotFound, "Flight or seat map not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/flights/:id/seats", Handler: h.Seat.CreateSeatMap,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "Create flight seat map",
+				Description: "Generate a flight's seats from a cabin layout",
+				Params:      []openapi.Param{openapi.PathID("id", "Flight ID")},
+				Body:        entity.SeatMapRequest{},
+				Responses: []openapi.Response{
+					created([]entity.SeatResponse{}), badRequest, unauthorized, forbidden,
+					failure(http.StatusNotFound, "Flight not found"),
+					failure(http.StatusConflict, "Seat map already exists"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/flights/:id/book", Handler: h.Flight.BookFlight, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "flights", Summary: "Book a flight",
+				Description: "Hold seats on a fare until the booking is confirmed or the hold expires",
+				Params:      []openapi.Param{openapi.PathID("id", "Flight ID")},
+				Body:        entity.BookingRequest{},
+				Responses: []openapi.Response{
+					created(entity.Booking{}), badRequest, unauthorized,
+					failure(http.StatusConflict, "Seat unavailable"),
+				},
+			},
+		},
+
+		// Hotels
+		{
+			Method: http.MethodPost, Path: "/hotels/search", Handler: h.Hotel.SearchHotels, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "hotels", Summary: "Search for hotels",
+				Description: "Search for hotels based on criteria",
+				Body:        entity.HotelSearchRequest{},
+				Responses:   []openapi.Response{ok([]entity.Hotel{}), badRequest},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/hotels/:id", Handler: h.Hotel.GetHotel, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "hotels", Summary: "Get hotel details",
+				Description: "Get detailed information about a specific hotel",
+				Params:      []openapi.Param{openapi.PathID("id", "Hotel ID")},
+				Responses: []openapi.Response{
+					ok(entity.Hotel{}), badRequest,
+					failure(http.StatusNotFound, "Hotel not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/hotels/:id/quote", Handler: h.Hotel.QuoteStay, Scope: entity.APIScopeSearch,
+			Doc: openapi.Operation{
+				Tag: "hotels", Summary: "Quote a hotel stay",
+				Description: "Get the current nightly rate and total for a stay, with the pricing adjustments applied",
+				Params: []openapi.Param{
+					openapi.PathID("id", "Hotel ID"),
+					openapi.Query("check_in", "Check-in date (YYYY-MM-DD)", "", true),
+					openapi.Query("check_out", "Check-out date (YYYY-MM-DD)", "", true),
+				},
+				Responses: []openapi.Response{
+					ok(pricing.StayQuote{}), badRequest,
+					failure(http.StatusNotFound, "Hotel not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/hotels/:id/book", Handler: h.Hotel.BookHotel, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "hotels", Summary: "Book a hotel",
+				Description: "Hold a room until the booking is confirmed or the hold expires",
+				Params:      []openapi.Param{openapi.PathID("id", "Hotel ID")},
+				Body:        entity.BookingRequest{},
+				Responses:   []openapi.Response{created(entity.Booking{}), badRequest, unauthorized},
+			},
+		},
+
+		// Bookings
+		{
+			Method: http.MethodGet, Path: "/bookings", Handler: h.Booking.ListBookings, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "bookings", Summary: "List the user's bookings",
+				Responses: []openapi.Response{ok([]entity.Booking{}), unauthorized},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/bookings/:id", Handler: h.Booking.GetBooking, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "bookings", Summary: "Get booking details",
+				Params: []openapi.Param{openapi.PathID("id", "Booking ID")},
+				Responses: []openapi.Response{
+					ok(entity.Booking{}), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Booking not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPatch, Path: "/bookings/:id", Handler: h.Booking.UpdateBooking, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "bookings", Summary: "Update a booking",
+				Description: "Change a booking's special requests",
+				Params:      []openapi.Param{openapi.PathID("id", "Booking ID")},
+				Body:        map[string]interface{}{},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Booking not found"),
+					failure(http.StatusConflict, "Booking can no longer be changed"),
+				},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/bookings/:id", Handler: h.Booking.CancelBooking, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "bookings", Summary: "Cancel a booking",
+				Description: "Cancel a held booking, or a confirmed one up to 24 hours before it starts",
+				Params:      []openapi.Param{openapi.PathID("id", "Booking ID")},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Booking not found"),
+					failure(http.StatusConflict, "Booking can no longer be changed"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/bookings/:id/confirm", Handler: h.Booking.ConfirmBooking, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "bookings", Summary: "Confirm a held booking",
+				Description: "Record payment for a held booking before its hold expires",
+				Params:      []openapi.Param{openapi.PathID("id", "Booking ID")},
+				Body:        entity.ConfirmBookingRequest{},
+				Responses: []openapi.Response{
+					ok(entity.Booking{}), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Booking not found"),
+					failure(http.StatusConflict, "Booking is not held or the hold has expired"),
+				},
+			},
+		},
+		{
+			Method: http.MethodPut, Path: "/bookings/:id/seats", Handler: h.Seat.AssignSeats, Auth: true, Scope: entity.APIScopeBook,
+			Doc: openapi.Operation{
+				Tag: "bookings", Summary: "Choose seats for a booking",
+				Description: "Assign the given seats to a flight booking, or pick seats of the user's preferred type if none are given",
+				Params:      []openapi.Param{openapi.PathID("id", "Booking ID")},
+				Body:        entity.SeatAssignmentRequest{},
+				Responses: []openapi.Response{
+					ok([]entity.SeatResponse{}), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Booking not found"),
+					failure(http.StatusConflict, "Seat unavailable"),
+				},
+			},
+		},
+
+		// Saved searches and alerts
+		{
+			Method: http.MethodGet, Path: "/saved-searches", Handler: h.SavedSearch.ListSavedSearches, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "saved-searches", Summary: "List saved searches",
+				Responses: []openapi.Response{ok([]entity.SavedSearch{}), unauthorized},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/saved-searches", Handler: h.SavedSearch.CreateSavedSearch, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "saved-searches", Summary: "Save a search",
+				Description: "Save a flight or hotel search to be re-run periodically, with an optional target price for alerts",
+				Body:        entity.SavedSearchRequest{},
+				Responses:   []openapi.Response{created(entity.SavedSearch{}), badRequest, unauthorized},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/saved-searches/:id", Handler: h.SavedSearch.DeleteSavedSearch, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "saved-searches", Summary: "Delete a saved search",
+				Params: []openapi.Param{openapi.PathID("id", "Saved search ID")},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Saved search not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/alerts", Handler: h.SavedSearch.ListAlerts, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "saved-searches", Summary: "List price alerts",
+				Description: "List the user's price alerts, newest first",
+				Params:      []openapi.Param{openapi.Query("unread", "Only list unread alerts", false, false)},
+				Responses:   []openapi.Response{ok([]entity.PriceAlert{}), unauthorized},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/alerts/:id/read", Handler: h.SavedSearch.MarkAlertRead, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "saved-searches", Summary: "Mark a price alert as read",
+				Params: []openapi.Param{openapi.PathID("id", "Price alert ID")},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Price alert not found"),
+				},
+			},
+		},
+
+		// Profile
+		{
+			Method: http.MethodGet, Path: "/profile", Handler: h.User.GetProfile, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "profile", Summary: "Get the user's profile",
+				Responses: []openapi.Response{ok(entity.User{}), unauthorized},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/profile/travellers", Handler: h.Traveller.ListSavedTravellers, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "profile", Summary: "List saved travellers",
+				Description: "List the traveller profiles saved on the user's account",
+				Responses:   []openapi.Response{ok([]entity.SavedTraveller{}), unauthorized},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/profile/travellers", Handler: h.Traveller.SaveTraveller, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "profile", Summary: "Save a traveller",
+				Description: "Save a traveller profile on the user's account for future bookings",
+				Body:        entity.TravellerDetails{},
+				Responses:   []openapi.Response{created(entity.SavedTraveller{}), badRequest, unauthorized},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/profile/travellers/:id", Handler: h.Traveller.DeleteSavedTraveller, Auth: true,
+			Doc: openapi.Operation{
+				Tag: "profile", Summary: "Delete a saved traveller",
+				Params: []openapi.Param{openapi.PathID("id", "Saved traveller ID")},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized,
+					failure(http.StatusNotFound, "Saved traveller not found"),
+				},
+			},
+		},
+
+		// Admin
+		{
+			Method: http.MethodPost, Path: "/admin/users/unlock", Handler: h.User.UnlockAccount,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "Unlock an account",
+				Description: "Lift the delay or lockout left on an email by failed logins",
+				Body:        entity.UnlockAccountRequest{},
+				Responses:   []openapi.Response{ok(messageBody), badRequest, unauthorized, forbidden},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/api-keys", Handler: h.APIKey.ListKeys,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "List API keys",
+				Description: "List the partner API keys that haven't been revoked",
+				Responses:   []openapi.Response{ok([]entity.APIKey{}), unauthorized, forbidden},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/api-keys", Handler: h.APIKey.CreateKey,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "Issue an API key",
+				Description: "Issue a key a partner's server can call the API with as the given account, " +
+					"limited to its scopes. The key is only shown in this response.",
+				Body: entity.APIKeyRequest{},
+				Responses: []openapi.Response{
+					created(entity.NewAPIKeyResponse{}), badRequest, unauthorized, forbidden,
+					failure(http.StatusNotFound, "User not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/api-keys/:id", Handler: h.APIKey.RevokeKey,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "Revoke an API key",
+				Params: []openapi.Param{openapi.PathID("id", "API key ID")},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized, forbidden,
+					failure(http.StatusNotFound, "API key not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/admin/pricing-rules", Handler: h.Pricing.ListRules,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "List pricing rules",
+				Responses: []openapi.Response{ok([]entity.PricingRule{}), unauthorized, forbidden},
+			},
+		},
+		{
+			Method: http.MethodPost, Path: "/admin/pricing-rules", Handler: h.Pricing.CreateRule,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "Create a pricing rule",
+				Body:      entity.PricingRuleRequest{},
+				Responses: []openapi.Response{created(entity.PricingRule{}), badRequest, unauthorized, forbidden},
+			},
+		},
+		{
+			Method: http.MethodPut, Path: "/admin/pricing-rules/:id", Handler: h.Pricing.UpdateRule,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "Update a pricing rule",
+				Params: []openapi.Param{openapi.PathID("id", "Pricing rule ID")},
+				Body:   entity.PricingRuleRequest{},
+				Responses: []openapi.Response{
+					ok(entity.PricingRule{}), badRequest, unauthorized, forbidden,
+					failure(http.StatusNotFound, "Pricing rule not found"),
+				},
+			},
+		},
+		{
+			Method: http.MethodDelete, Path: "/admin/pricing-rules/:id", Handler: h.Pricing.DeleteRule,
+			Auth: true, Role: "admin",
+			Doc: openapi.Operation{
+				Tag: "admin", Summary: "Delete a pricing rule",
+				Params: []openapi.Param{openapi.PathID("id", "Pricing rule ID")},
+				Responses: []openapi.Response{
+					ok(messageBody), badRequest, unauthorized, forbidden,
+					failure(http.StatusNotFound, "Pricing rule not found"),
+				},
+			},
+		},
+	}}
+}
@@ -0,0 +1,93 @@
+package seed
+
+// City is an airport city used to build routes, hotels and packages
+type City struct {
+	Name     string
+	Country  string
+	Timezone string
+	Lat, Lon float64
+}
+
+var cities = []City{
+	{"London", "United Kingdom", "Europe/London", 51.47, -0.45},
+	{"Paris", "France", "Europe/Paris", 49.01, 2.55},
+	{"Frankfurt", "Germany", "Europe/Berlin", 50.03, 8.57},
+	{"Amsterdam", "Netherlands", "Europe/Amsterdam", 52.31, 4.76},
+	{"Madrid", "Spain", "Europe/Madrid", 40.47, -3.56},
+	{"Rome", "Italy", "Europe/Rome", 41.80, 12.25},
+	{"Warsaw", "Poland", "Europe/Warsaw", 52.17, 20.97},
+	{"Istanbul", "Turkey", "Europe/Istanbul", 41.26, 28.74},
+	{"Dubai", "United Arab Emirates", "Asia/Dubai", 25.25, 55.36},
+	{"New York", "United States", "America/New_York", 40.64, -73.78},
+	{"Los Angeles", "United States", "America/Los_Angeles", 33.94, -118.41},
+	{"Chicago", "United States", "America/Chicago", 41.97, -87.91},
+	{"Toronto", "Canada", "America/Toronto", 43.68, -79.63},
+	{"Mexico City", "Mexico", "America/Mexico_City", 19.44, -99.07},
+	{"Sao Paulo", "Brazil", "America/Sao_Paulo", -23.43, -46.47},
+	{"Buenos Aires", "Argentina", "America/Argentina/Buenos_Aires", -34.82, -58.54},
+	{"Tokyo", "Japan", "Asia/Tokyo", 35.55, 139.78},
+	{"Seoul", "South Korea", "Asia/Seoul", 37.46, 126.44},
+	{"Singapore", "Singapore", "Asia/Singapore", 1.36, 103.99},
+	{"Bangkok", "Thailand", "Asia/Bangkok", 13.69, 100.75},
+	{"Hong Kong", "Hong Kong", "Asia/Hong_Kong", 22.31, 113.91},
+	{"Delhi", "India", "Asia/Kolkata", 28.56, 77.10},
+	{"Sydney", "Australia", "Australia/Sydney", -33.95, 151.18},
+	{"Auckland", "New Zealand", "Pacific/Auckland", -37.01, 174.79},
+	{"Johannesburg", "South Africa", "Africa/Johannesburg", -26.14, 28.25},
+	{"Cairo", "Egypt", "Africa/Cairo", 30.12, 31.41},
+	{"Nairobi", "Kenya", "Africa/Nairobi", -1.32, 36.93},
+	{"Lisbon", "Portugal", "Europe/Lisbon", 38.77, -9.13},
+	{"Reykjavik", "Iceland", "Atlantic/Reykjavik", 63.99, -22.62},
+	{"Honolulu", "United States", "Pacific/Honolulu", 21.32, -157.92},
+}
+
+// MaxCities is the number of cities available to the generator
+var MaxCities = len(cities)
+
+var airlines = []struct {
+	Name string
+	Code string
+}{
+	{"Fledge Air", "FL"},
+	{"Northwind Airways", "NW"},
+	{"Meridian", "MD"},
+	{"Blue Horizon", "BH"},
+	{"Polar Express Air", "PX"},
+	{"Sunline", "SL"},
+}
+
+var amenities = []struct {
+	Name        string
+	Description string
+}{
+	{"Free WiFi", "Wireless internet throughout the property"},
+	{"Pool", "Outdoor swimming pool"},
+	{"Spa", "Full service spa and sauna"},
+	{"Gym", "24 hour fitness centre"},
+	{"Parking", "On-site parking"},
+	{"Airport Shuttle", "Scheduled shuttle to the airport"},
+	{"Restaurant", "On-site restaurant"},
+	{"Bar", "Lounge bar"},
+	{"Room Service", "In-room dining"},
+	{"Breakfast Included", "Breakfast included in the room rate"},
+	{"Pet Friendly", "Pets allowed on request"},
+	{"Business Centre", "Meeting rooms and printing"},
+}
+
+var hotelPrefixes = []string{"Grand", "Royal", "Central", "Harbour", "Park", "City", "Garden", "Riverside", "Skyline", "Old Town"}
+
+var hotelSuffixes = []string{"Hotel", "Inn", "Suites", "Residence", "Lodge", "Palace", "Resort"}
+
+var streets = []string{"High Street", "Station Road", "Market Square", "Park Avenue", "Harbour Lane", "King Street", "Main Street"}
+
+var packageThemes = []struct {
+	Name     string
+	Includes string
+}{
+	{"City Break", "Flights, hotel, city tour"},
+	{"Beach Escape", "Flights, resort stay, airport transfers"},
+	{"Culture Trail", "Flights, hotel, museum passes, guided walks"},
+	{"Food and Wine", "Flights, boutique hotel, tasting dinners"},
+	{"Adventure Week", "Flights, lodge stay, guided excursions"},
+	{"Family Holiday", "Flights, family suite, theme park tickets"},
+}
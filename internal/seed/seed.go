@@ -0,0 +1,241 @@
+// Package seed generates deterministic synthetic inventory for local
+// development and load testing. The same options always produce the same
+// data.
+package seed
+
+import (
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/util"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Options controls the scale and contents of the generated data
+type Options struct {
+	Seed            int64
+	Cities          int       // number of cities to connect, up to MaxCities
+	StartDate       time.Time // first departure date
+	Days            int       // number of days with departures
+	FlightsPerRoute int       // daily departures on each route
+	HotelsPerCity   int
+	Packages        int
+}
+
+// Generator produces inventory from a seeded random source. Each kind of
+// inventory uses its own source, so flights, hotels and packages are each
+// deterministic whichever are generated and in whatever order.
+type Generator struct {
+	opts   Options
+	rand   *rand.Rand
+	cities []City
+}
+
+func NewGenerator(opts Options) (*Generator, error) {
+	if opts.Cities < 2 || opts.Cities > MaxCities {
+		return nil, fmt.Errorf("cities must be between 2 and %d", MaxCities)
+	}
+	if opts.Days < 1 || opts.FlightsPerRoute < 0 || opts.HotelsPerCity < 0 || opts.Packages < 0 {
+		return nil, fmt.Errorf("days must be positive and counts must not be negative")
+	}
+
+	return &Generator{
+		opts:   opts,
+		cities: cities[:opts.Cities],
+	}, nil
+}
+
+// Amenities returns the amenities hotels are given. They must be saved
+// before Hotels is called so hotels can reference their IDs.
+func (g *Generator) Amenities() []entity.Amenity {
+	result := make([]entity.Amenity, len(amenities))
+	for i, a := range amenities {
+		result[i] = entity.Amenity{Name: a.Name, Description: a.Description}
+	}
+	return result
+}
+
+// Flights calls fn with the flights departing on each day of the range, in
+// date order, so large data sets never have to be held in memory at once
+func (g *Generator) Flights(fn func(day time.Time, flights []entity.Flight) error) error {
+	g.reseed(1)
+	start := g.startDate()
+
+	for d := 0; d < g.opts.Days; d++ {
+		day := start.AddDate(0, 0, d)
+		var flights []entity.Flight
+
+		for i, origin := range g.cities {
+			for j, destination := range g.cities {
+				if i == j {
+					continue
+				}
+				for n := 0; n < g.opts.FlightsPerRoute; n++ {
+					flight, err := g.flight(day, origin, destination)
+					if err != nil {
+						return err
+					}
+					flights = append(flights, flight)
+				}
+			}
+		}
+
+		if err := fn(day, flights); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) flight(day time.Time, origin, destination City) (entity.Flight, error) {
+	departureLoc, err := util.LoadLocation(origin.Timezone)
+	if err != nil {
+		return entity.Flight{}, err
+	}
+
+	airline := airlines[g.rand.Intn(len(airlines))]
+	distance := distanceKm(origin, destination)
+
+	// Departures between 06:00 and 22:55 local time, in 5 minute steps
+	minutes := 6*60 + g.rand.Intn(17*12)*5
+	departure := time.Date(day.Year(), day.Month(), day.Day(), 0, minutes, 0, 0, departureLoc)
+	duration := time.Duration(distance/820*60+35) * time.Minute
+
+	return entity.Flight{
+		FlightNumber:       fmt.Sprintf("%s%d", airline.Code, 100+g.rand.Intn(9000)),
+		Airline:            airline.Name,
+		DepartureCity:      origin.Name,
+		ArrivalCity:        destination.Name,
+		DestinationCountry: destination.Country,
+		DepartureTimezone:  origin.Timezone,
+		ArrivalTimezone:    destination.Timezone,
+		DepartureTime:      departure.UTC(),
+		ArrivalTime:        departure.Add(duration).UTC(),
+		Status:             "scheduled",
+		Fares:              g.fares(distance),
+	}, nil
+}
+
+// fares builds a fare ladder priced by distance. Longer flights offer more
+// cabins.
+func (g *Generator) fares(distance float64) []entity.FareClass {
+	base := math.Round(40 + distance*0.09*(0.8+g.rand.Float64()*0.4))
+
+	fares := []entity.FareClass{
+		g.fare("economy", "V", base, 0, false, 50, false, 90),
+		g.fare("economy", "Y", base*1.4, 1, true, 0, true, 60),
+	}
+	if distance > 1500 {
+		fares = append(fares, g.fare("premium", "W", base*2.1, 2, true, 0, true, 28))
+	}
+	if distance > 800 {
+		fares = append(fares, g.fare("business", "J", base*4, 2, true, 0, true, 24))
+	}
+	if distance > 6000 {
+		fares = append(fares, g.fare("first", "F", base*7.5, 3, true, 0, true, 8))
+	}
+	return fares
+}
+
+func (g *Generator) fare(cabin, code string, price float64, bags int, changeable bool, changeFee float64, refundable bool, capacity int) entity.FareClass {
+	// Some seats are already sold so load factor pricing has something to
+	// work with
+	sold := g.rand.Intn(capacity/2 + 1)
+	return entity.FareClass{
+		Cabin:            cabin,
+		Code:             code,
+		Price:            math.Round(price*100) / 100,
+		BaggageAllowance: bags,
+		Changeable:       changeable,
+		ChangeFee:        changeFee,
+		Refundable:       refundable,
+		AvailableSeats:   capacity - sold,
+		Capacity:         capacity,
+	}
+}
+
+// Hotels returns the hotels for every city, each with a random subset of
+// the given saved amenities
+func (g *Generator) Hotels(saved []entity.Amenity) []entity.Hotel {
+	g.reseed(2)
+	var hotels []entity.Hotel
+	for _, city := range g.cities {
+		for i := 0; i < g.opts.HotelsPerCity; i++ {
+			// Ratings from 1 to 5 in half stars, weighted towards the middle
+			rating := float32(math.Round((1+g.rand.Float64()*2+g.rand.Float64()*2)*2) / 2)
+			rooms := 20 + g.rand.Intn(280)
+
+			var hotelAmenities []entity.Amenity
+			for _, amenity := range saved {
+				if g.rand.Float32() < 0.2+rating/10 {
+					hotelAmenities = append(hotelAmenities, amenity)
+				}
+			}
+
+			hotels = append(hotels, entity.Hotel{
+				Name: fmt.Sprintf("%s %s %s",
+					hotelPrefixes[g.rand.Intn(len(hotelPrefixes))], city.Name, hotelSuffixes[g.rand.Intn(len(hotelSuffixes))]),
+				Address:        fmt.Sprintf("%d %s", 1+g.rand.Intn(250), streets[g.rand.Intn(len(streets))]),
+				City:           city.Name,
+				Country:        city.Country,
+				Rating:         rating,
+				Price:          math.Round(float64(rating)*float64(rating)*12+30+g.rand.Float64()*60) - 0.01,
+				AvailableRooms: rooms - g.rand.Intn(rooms/3+1),
+				TotalRooms:     rooms,
+				Amenities:      hotelAmenities,
+			})
+		}
+	}
+	return hotels
+}
+
+// Packages returns vacation packages to random destinations starting within
+// the date range
+func (g *Generator) Packages() []entity.VacationPackage {
+	g.reseed(3)
+	start := g.startDate()
+
+	packages := make([]entity.VacationPackage, g.opts.Packages)
+	for i := range packages {
+		city := g.cities[g.rand.Intn(len(g.cities))]
+		theme := packageThemes[g.rand.Intn(len(packageThemes))]
+		days := 3 + g.rand.Intn(12)
+		startDate := start.AddDate(0, 0, g.rand.Intn(g.opts.Days))
+
+		packages[i] = entity.VacationPackage{
+			Name:        fmt.Sprintf("%s %s", city.Name, theme.Name),
+			Description: fmt.Sprintf("%d days in %s, %s", days, city.Name, city.Country),
+			Destination: city.Name,
+			Duration:    days,
+			StartDate:   startDate,
+			EndDate:     startDate.AddDate(0, 0, days),
+			Price:       float64(days*(80+g.rand.Intn(220))) - 0.01,
+			Includes:    theme.Includes,
+			MaxPeople:   2 + g.rand.Intn(7),
+			Available:   true,
+		}
+	}
+	return packages
+}
+
+func (g *Generator) reseed(stream int64) {
+	g.rand = rand.New(rand.NewSource(g.opts.Seed*10 + stream))
+}
+
+func (g *Generator) startDate() time.Time {
+	y, m, d := g.opts.StartDate.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// distanceKm returns the great-circle distance between two cities
+func distanceKm(a, b City) float64 {
+	const earthRadius = 6371
+	rad := math.Pi / 180
+	dLat := (b.Lat - a.Lat) * rad
+	dLon := (b.Lon - a.Lon) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Lat*rad)*math.Cos(b.Lat*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fledge-restapi/internal/config"
+	"log"
+	"net"
+	"net/http"
+)
+
+// Server is an HTTP server that drains in-flight requests when its context
+// is cancelled
+type Server struct {
+	http *http.Server
+	cfg  config.ServerConfig
+}
+
+func New(cfg config.ServerConfig, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              ":" + cfg.Port,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		cfg: cfg,
+	}
+}
+
+// Run listens on the configured port and serves until ctx is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve accepts connections on listener until ctx is cancelled, then stops
+// accepting new connections and waits up to the shutdown timeout for
+// in-flight requests to complete
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if s.cfg.TLSEnabled() {
+			log.Printf("Listening on %s (TLS)", listener.Addr())
+			errCh <- s.http.ServeTLS(listener, s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		} else {
+			log.Printf("Listening on %s", listener.Addr())
+			errCh <- s.http.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		// The server failed before it was asked to stop
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fledge-restapi/internal/config"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// slowHandler answers once release is closed, signalling started as each
+// request arrives
+type slowHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newSlowHandler() *slowHandler {
+	return &slowHandler{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (h *slowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.started <- struct{}{}
+	<-h.release
+	io.WriteString(w, "done")
+}
+
+// serve starts s on a local port, returning its address and a channel
+// receiving what Serve returns
+func serve(t *testing.T, ctx context.Context, s *Server) (string, <-chan error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, listener) }()
+	return listener.Addr().String(), done
+}
+
+// get requests path in the background, sending the body or error
+func get(url string) <-chan string {
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			result <- "error: " + err.Error()
+			return
+		}
+		result <- string(body)
+	}()
+	return result
+}
+
+// waitRefused waits for addr to stop accepting connections
+func waitRefused(t *testing.T, addr string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err != nil {
+			return
+		}
+		conn.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server still accepts connections after shutdown began")
+}
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	handler := newSlowHandler()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, done := serve(t, ctx, New(config.ServerConfig{ShutdownTimeout: 5 * time.Second}, handler))
+
+	response := get("http://" + addr + "/")
+	<-handler.started
+
+	cancel()
+	waitRefused(t, addr)
+
+	select {
+	case err := <-done:
+		t.Fatalf("Serve returned %v with a request still in flight", err)
+	default:
+	}
+
+	close(handler.release)
+	if body := <-response; body != "done" {
+		t.Fatalf("in-flight request got %q, want it to complete", body)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve = %v, want nil after draining", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return once requests drained")
+	}
+}
+
+func TestServeGivesUpAfterShutdownTimeout(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	handler := newSlowHandler()
+	defer close(handler.release)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	addr, done := serve(t, ctx, New(config.ServerConfig{ShutdownTimeout: timeout}, handler))
+
+	get("http://" + addr + "/")
+	<-handler.started
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Serve = %v, want %v", err, context.DeadlineExceeded)
+		}
+		if elapsed := time.Since(start); elapsed < timeout {
+			t.Fatalf("Serve gave up after %s, before the %s shutdown timeout", elapsed, timeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve ignored its shutdown timeout")
+	}
+}
+
+func TestServeReturnsListenerErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.Close()
+
+	s := New(config.ServerConfig{ShutdownTimeout: time.Second}, http.NotFoundHandler())
+	if err := s.Serve(context.Background(), listener); err == nil {
+		t.Fatal("Serve on a closed listener returned nil")
+	}
+}
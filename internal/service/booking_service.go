@@ -2,22 +2,44 @@ package service
 
 import (
 	"context"
-	"errors"
+	stderrors "errors"
+	"fmt"
 	"time"
 
 	"fledge-restapi/internal/domain/entity"
 	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
 
 	"github.com/google/uuid"
 )
 
+// Booking fields a user may change after booking
+var updatableBookingFields = map[string]bool{
+	"special_requests": true,
+}
+
 type BookingService struct {
 	bookingRepo repository.BookingRepository
+	seatRepo    repository.SeatRepository
+	flightRepo  repository.FlightRepository
+	hotelRepo   repository.HotelRepository
+	clock       util.Clock
 }
 
-func NewBookingService(bookingRepo repository.BookingRepository) *BookingService {
+func NewBookingService(
+	bookingRepo repository.BookingRepository,
+	seatRepo repository.SeatRepository,
+	flightRepo repository.FlightRepository,
+	hotelRepo repository.HotelRepository,
+	clock util.Clock,
+) *BookingService {
 	return &BookingService{
 		bookingRepo: bookingRepo,
+		seatRepo:    seatRepo,
+		flightRepo:  flightRepo,
+		hotelRepo:   hotelRepo,
+		clock:       clock,
 	}
 }
 
@@ -28,11 +50,11 @@ func (s *BookingService) ListBookings(ctx context.Context, userID uuid.UUID) ([]
 func (s *BookingService) GetBooking(ctx context.Context, id uint, userID uuid.UUID) (*entity.Booking, error) {
 	booking, err := s.bookingRepo.FindByID(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, errors.ErrBookingNotFound
 	}
 
 	if booking.UserID != userID {
-		return nil, errors.New("unauthorized access to booking")
+		return nil, errors.ErrBookingAccessDenied
 	}
 
 	return booking, nil
@@ -45,27 +67,136 @@ func (s *BookingService) UpdateBooking(ctx context.Context, id uint, userID uuid
 	}
 
 	// Validate that the booking can be updated
-	if booking.Status == "cancelled" {
-		return errors.New("cannot update cancelled booking")
+	if booking.Status == "cancelled" || booking.Status == "expired" {
+		return errors.ErrBookingNotUpdatable
+	}
+	for field := range updates {
+		if !updatableBookingFields[field] {
+			return errors.ErrFieldNotUpdatable
+		}
 	}
 
 	return s.bookingRepo.Update(ctx, id, updates)
 }
 
+// ConfirmBooking converts a held booking into a confirmed one once it has
+// been paid for, provided the hold has not expired
+func (s *BookingService) ConfirmBooking(ctx context.Context, id uint, userID uuid.UUID, req *entity.ConfirmBookingRequest) (*entity.Booking, error) {
+	booking, err := s.GetBooking(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != "held" {
+		return nil, errors.ErrBookingNotHeld
+	}
+
+	confirmed, err := s.bookingRepo.ConfirmHold(ctx, id, s.clock.Now(), map[string]interface{}{
+		"status":            "confirmed",
+		"payment_status":    "paid",
+		"payment_reference": req.PaymentReference,
+		"hold_expires_at":   nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, errors.ErrHoldExpired
+	}
+
+	return s.bookingRepo.FindByID(ctx, id)
+}
+
 func (s *BookingService) CancelBooking(ctx context.Context, id uint, userID uuid.UUID) error {
 	booking, err := s.GetBooking(ctx, id, userID)
 	if err != nil {
 		return err
 	}
 
-	// Check if booking is within cancellation period
-	if time.Until(booking.CheckInDate) < 24*time.Hour {
-		return errors.New("booking cannot be cancelled within 24 hours of start date")
+	// Unpaid holds can be dropped at any time; confirmed bookings only
+	// until 24 hours before the start date
+	if booking.Status == "confirmed" {
+		startsAt, err := s.startDate(ctx, booking)
+		if err != nil {
+			return err
+		}
+		if startsAt.Sub(s.clock.Now()) < 24*time.Hour {
+			return errors.ErrCancellationClosed
+		}
 	}
 
-	updates := map[string]interface{}{
+	cancelled, err := s.bookingRepo.UpdateIfStatus(ctx, id, []string{"held", "confirmed"}, map[string]interface{}{
 		"status": "cancelled",
+	})
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.ErrBookingNotUpdatable
 	}
 
-	return s.bookingRepo.Update(ctx, id, updates)
+	return s.releaseInventory(ctx, booking)
+}
+
+// ReleaseExpiredHolds expires every held booking past its hold expiry and
+// returns its inventory, reporting how many holds were released
+func (s *BookingService) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	bookings, err := s.bookingRepo.FindExpiredHolds(ctx, s.clock.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	released := 0
+	var errs []error
+	for i := range bookings {
+		expired, err := s.bookingRepo.UpdateIfStatus(ctx, bookings[i].ID, []string{"held"}, map[string]interface{}{
+			"status":         "expired",
+			"payment_status": "abandoned",
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("booking %d: %w", bookings[i].ID, err))
+			continue
+		}
+		if !expired {
+			// Paid or cancelled since it was loaded
+			continue
+		}
+
+		if err := s.releaseInventory(ctx, &bookings[i]); err != nil {
+			errs = append(errs, fmt.Errorf("booking %d: %w", bookings[i].ID, err))
+			continue
+		}
+		released++
+	}
+
+	return released, stderrors.Join(errs...)
+}
+
+// releaseInventory returns the seats or room held by a booking
+func (s *BookingService) releaseInventory(ctx context.Context, booking *entity.Booking) error {
+	switch booking.BookingType {
+	case "flight":
+		if booking.FareClassID != nil {
+			if err := s.flightRepo.ReleaseFareSeats(ctx, *booking.FareClassID, booking.SeatCount); err != nil {
+				return err
+			}
+		}
+		// Free any seats so they can be selected by other bookings
+		return s.seatRepo.ReleaseByBookingID(ctx, booking.ID)
+	case "hotel":
+		if booking.HotelID != nil {
+			return s.hotelRepo.ReleaseRooms(ctx, *booking.HotelID, 1)
+		}
+	}
+	return nil
+}
+
+func (s *BookingService) startDate(ctx context.Context, booking *entity.Booking) (time.Time, error) {
+	if booking.BookingType == "flight" && booking.FlightID != nil {
+		flight, err := s.flightRepo.FindByID(ctx, *booking.FlightID)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return flight.DepartureTime, nil
+	}
+	return booking.CheckInDate, nil
 }
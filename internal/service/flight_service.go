@@ -4,7 +4,10 @@ import (
 	"context"
 	"fledge-restapi/internal/domain/entity"
 	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/pricing"
+	"fledge-restapi/internal/util"
 	"fledge-restapi/pkg/errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,28 +19,53 @@ type FlightService interface {
 	BookFlight(ctx context.Context, userID uuid.UUID, bookingReq *entity.BookingRequest) (*entity.Booking, error)
 	ListAllFlights(ctx context.Context) ([]entity.Flight, error)
 	ListFlightsByOrigin(ctx context.Context, origin string) ([]entity.Flight, error)
+	QuoteFare(ctx context.Context, flightID, fareID uint) (*pricing.Quote, error)
 }
 
 type flightService struct {
-	flightRepo  repository.FlightRepository
-	bookingRepo repository.BookingRepository
+	flightRepo       repository.FlightRepository
+	bookingRepo      repository.BookingRepository
+	seatService      SeatService
+	travellerService TravellerService
+	pricer           *pricing.Engine
+	holdTTL          time.Duration
+	clock            util.Clock
 }
 
-func NewFlightService(flightRepo repository.FlightRepository, bookingRepo repository.BookingRepository) FlightService {
+func NewFlightService(
+	flightRepo repository.FlightRepository,
+	bookingRepo repository.BookingRepository,
+	seatService SeatService,
+	travellerService TravellerService,
+	pricer *pricing.Engine,
+	holdTTL time.Duration,
+	clock util.Clock,
+) FlightService {
 	return &flightService{
-		flightRepo:  flightRepo,
-		bookingRepo: bookingRepo,
+		flightRepo:       flightRepo,
+		bookingRepo:      bookingRepo,
+		seatService:      seatService,
+		travellerService: travellerService,
+		pricer:           pricer,
+		holdTTL:          holdTTL,
+		clock:            clock,
 	}
 }
 
 func (s *flightService) SearchFlights(ctx context.Context, req *entity.FlightSearchRequest) ([]entity.Flight, error) {
-	// Validate search criteria
-	if req.DepartureDate.Before(time.Now()) {
+	// Validate search criteria. Dates are calendar days in airport local time,
+	// so a date is only in the past once it has ended in every timezone.
+	_, latestEnd := util.CalendarDayWindow(req.DepartureDate)
+	if latestEnd.Before(s.clock.Now()) {
 		return nil, errors.ErrInvalidDepartureDate
 	}
 
-	if req.ReturnDate != nil && req.ReturnDate.Before(req.DepartureDate) {
-		return nil, errors.ErrInvalidReturnDate
+	if req.ReturnDate != nil {
+		departureDay, _ := util.LocalDayBounds(req.DepartureDate, time.UTC)
+		returnDay, _ := util.LocalDayBounds(*req.ReturnDate, time.UTC)
+		if returnDay.Before(departureDay) {
+			return nil, errors.ErrInvalidReturnDate
+		}
 	}
 
 	// Search for flights
@@ -53,15 +81,27 @@ func (s *flightService) SearchFlights(ctx context.Context, req *entity.FlightSea
 		return nil, err
 	}
 
-	return flights, nil
+	return flights, s.priceFares(ctx, flights)
 }
 
 func (s *flightService) GetFlightByID(ctx context.Context, id uint) (*entity.Flight, error) {
-	return s.flightRepo.FindByID(ctx, id)
+	flight, err := s.flightRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	flights := []entity.Flight{*flight}
+	if err := s.priceFares(ctx, flights); err != nil {
+		return nil, err
+	}
+	return &flights[0], nil
 }
 
 func (s *flightService) ListAllFlights(ctx context.Context) ([]entity.Flight, error) {
-	return s.flightRepo.FindAll(ctx)
+	flights, err := s.flightRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return flights, s.priceFares(ctx, flights)
 }
 
 func (s *flightService) ListFlightsByOrigin(ctx context.Context, origin string) ([]entity.Flight, error) {
@@ -71,45 +111,160 @@ func (s *flightService) ListFlightsByOrigin(ctx context.Context, origin string)
 		return nil, err
 	}
 
-	return flights, nil
+	return flights, s.priceFares(ctx, flights)
+}
+
+// priceFares sets each fare's quoted price, so the prices shown are the
+// ones a booking made now would be charged
+func (s *flightService) priceFares(ctx context.Context, flights []entity.Flight) error {
+	var inputs []pricing.Input
+	for i := range flights {
+		for j := range flights[i].Fares {
+			inputs = append(inputs, fareInput(&flights[i], &flights[i].Fares[j]))
+		}
+	}
+	quotes, err := s.pricer.QuoteAll(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for i := range flights {
+		for j := range flights[i].Fares {
+			flights[i].Fares[j].QuotedPrice = &quotes[n].Price
+			n++
+		}
+	}
+	return nil
 }
 
 func (s *flightService) BookFlight(ctx context.Context, userID uuid.UUID, bookingReq *entity.BookingRequest) (*entity.Booking, error) {
-	// Validate flight exists and has availability
+	// Validate flight and fare exist and have availability
 	flight, err := s.flightRepo.FindByID(ctx, *bookingReq.FlightID)
 	if err != nil {
 		return nil, err
 	}
 
-	if flight.AvailableSeats < bookingReq.NumGuests {
+	if bookingReq.FareClassID == nil {
+		return nil, errors.ErrFareNotFound
+	}
+	fare, ok := flight.Fare(*bookingReq.FareClassID)
+	if !ok {
+		return nil, errors.ErrFareNotFound
+	}
+
+	travellers, err := s.travellerService.PrepareTravellers(ctx, userID, bookingReq, flight.DepartureTime)
+	if err != nil {
+		return nil, err
+	}
+
+	seatCount := seatsNeeded(travellers, bookingReq.NumGuests)
+	if fare.AvailableSeats < seatCount {
 		return nil, errors.ErrInsufficientSeats
 	}
 
-	// Create booking
+	// Price each passenger by type from the fare's current adult price
+	quote, err := s.quoteFare(ctx, flight, fare)
+	if err != nil {
+		return nil, err
+	}
+	totalPrice := 0.0
+	for i := range travellers {
+		travellers[i].Price = passengerFare(quote.Price, travellers[i].PassengerType)
+		totalPrice += travellers[i].Price
+	}
+	if len(travellers) == 0 {
+		totalPrice = quote.Price * float64(bookingReq.NumGuests)
+	}
+
+	// Take the seats from the fare's inventory before creating the booking
+	// so concurrent bookings cannot oversell it
+	if err := s.flightRepo.ReserveFareSeats(ctx, fare.ID, seatCount); err != nil {
+		return nil, err
+	}
+
+	// Create booking, holding the seats until it is paid for
+	now := s.clock.Now()
+	holdExpiresAt := now.Add(s.holdTTL)
 	booking := &entity.Booking{
 		UserID:          userID,
 		BookingType:     "flight",
 		FlightID:        bookingReq.FlightID,
-		Status:          "confirmed",
-		BookingDate:     time.Now(),
-		TotalPrice:      float64(bookingReq.NumGuests) * flight.Price,
+		FareClassID:     &fare.ID,
+		Status:          "held",
+		BookingDate:     now,
+		TotalPrice:      totalPrice,
 		PaymentStatus:   "pending",
+		HoldExpiresAt:   &holdExpiresAt,
 		NumGuests:       bookingReq.NumGuests,
+		SeatCount:       seatCount,
 		SpecialRequests: bookingReq.SpecialRequests,
+		Travellers:      travellers,
 	}
 
 	// Save booking
 	err = s.bookingRepo.Create(ctx, booking)
 	if err != nil {
+		if releaseErr := s.flightRepo.ReleaseFareSeats(ctx, fare.ID, seatCount); releaseErr != nil {
+			log.Printf("booking: releasing %d seats on fare %d after a failed booking: %v", seatCount, fare.ID, releaseErr)
+		}
 		return nil, err
 	}
 
-	// Update flight availability
-	flight.AvailableSeats -= bookingReq.NumGuests
-	err = s.flightRepo.Update(ctx, flight)
-	if err != nil {
+	// Assign seats if the flight has a seat map; flights without one can
+	// only be booked without choosing seats
+	_, err = s.seatService.AssignSeats(ctx, userID, booking.ID, bookingReq.SeatNumbers)
+	if err != nil && !(err == errors.ErrSeatMapNotFound && len(bookingReq.SeatNumbers) == 0) {
+		s.abandonBooking(ctx, booking)
 		return nil, err
 	}
 
 	return booking, nil
 }
+
+// abandonBooking cancels a held booking that could not be completed and
+// returns its seats. Failures are logged rather than returned, as the
+// caller reports why the booking failed; a booking that stays held has its
+// seats returned by the hold reaper once the hold expires.
+func (s *flightService) abandonBooking(ctx context.Context, booking *entity.Booking) {
+	cancelled, err := s.bookingRepo.UpdateIfStatus(ctx, booking.ID, []string{"held"}, map[string]interface{}{
+		"status": "cancelled",
+	})
+	if err != nil {
+		log.Printf("booking %d: cancelling after failed seat assignment: %v", booking.ID, err)
+		return
+	}
+	if !cancelled {
+		return
+	}
+	if err := s.flightRepo.ReleaseFareSeats(ctx, *booking.FareClassID, booking.SeatCount); err != nil {
+		log.Printf("booking %d: releasing %d seats on fare %d: %v", booking.ID, booking.SeatCount, *booking.FareClassID, err)
+	}
+}
+
+func (s *flightService) QuoteFare(ctx context.Context, flightID, fareID uint) (*pricing.Quote, error) {
+	flight, err := s.flightRepo.FindByID(ctx, flightID)
+	if err != nil {
+		return nil, errors.ErrFlightNotFound
+	}
+	fare, ok := flight.Fare(fareID)
+	if !ok {
+		return nil, errors.ErrFareNotFound
+	}
+	return s.quoteFare(ctx, flight, fare)
+}
+
+// quoteFare prices a single adult seat on a fare
+func (s *flightService) quoteFare(ctx context.Context, flight *entity.Flight, fare *entity.FareClass) (*pricing.Quote, error) {
+	return s.pricer.Quote(ctx, fareInput(flight, fare))
+}
+
+func fareInput(flight *entity.Flight, fare *entity.FareClass) pricing.Input {
+	return pricing.Input{
+		Product:   "flight",
+		BasePrice: fare.Price,
+		Capacity:  fare.Capacity,
+		Remaining: fare.AvailableSeats,
+		StartsAt:  flight.DepartureTime.In(flight.DepartureLocation()),
+	}
+}
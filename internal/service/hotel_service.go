@@ -4,7 +4,10 @@ import (
 	"context"
 	"fledge-restapi/internal/domain/entity"
 	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/pricing"
+	"fledge-restapi/internal/util"
 	"fledge-restapi/pkg/errors"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,23 +17,39 @@ type HotelService interface {
 	SearchHotels(ctx context.Context, req *entity.HotelSearchRequest) ([]entity.Hotel, error)
 	GetHotelByID(ctx context.Context, id uint) (*entity.Hotel, error)
 	BookHotel(ctx context.Context, userID uuid.UUID, bookingReq *entity.BookingRequest) (*entity.Booking, error)
+	QuoteStay(ctx context.Context, hotelID uint, checkIn, checkOut time.Time) (*pricing.StayQuote, error)
 }
 
 type hotelService struct {
-	hotelRepo   repository.HotelRepository
-	bookingRepo repository.BookingRepository
+	hotelRepo        repository.HotelRepository
+	bookingRepo      repository.BookingRepository
+	travellerService TravellerService
+	pricer           *pricing.Engine
+	holdTTL          time.Duration
+	clock            util.Clock
 }
 
-func NewHotelService(hotelRepo repository.HotelRepository, bookingRepo repository.BookingRepository) HotelService {
+func NewHotelService(
+	hotelRepo repository.HotelRepository,
+	bookingRepo repository.BookingRepository,
+	travellerService TravellerService,
+	pricer *pricing.Engine,
+	holdTTL time.Duration,
+	clock util.Clock,
+) HotelService {
 	return &hotelService{
-		hotelRepo:   hotelRepo,
-		bookingRepo: bookingRepo,
+		hotelRepo:        hotelRepo,
+		bookingRepo:      bookingRepo,
+		travellerService: travellerService,
+		pricer:           pricer,
+		holdTTL:          holdTTL,
+		clock:            clock,
 	}
 }
 
 func (s *hotelService) SearchHotels(ctx context.Context, req *entity.HotelSearchRequest) ([]entity.Hotel, error) {
 	// Validate dates
-	if req.CheckIn.Before(time.Now()) {
+	if req.CheckIn.Before(s.clock.Now()) {
 		return nil, errors.ErrInvalidCheckInDate
 	}
 
@@ -71,39 +90,88 @@ func (s *hotelService) BookHotel(ctx context.Context, userID uuid.UUID, bookingR
 		return nil, errors.ErrNoRoomsAvailable
 	}
 
-	// Calculate total nights
-	nights := int(bookingReq.CheckOutDate.Sub(*bookingReq.CheckInDate).Hours() / 24)
-	if nights < 1 {
+	// Price the stay
+	if bookingReq.CheckInDate == nil || bookingReq.CheckOutDate == nil {
 		return nil, errors.ErrInvalidStayDuration
 	}
+	quote, err := s.quoteStay(ctx, hotel, *bookingReq.CheckInDate, *bookingReq.CheckOutDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rooms are priced per night regardless of guests, so travellers only
+	// need validating
+	travellers, err := s.travellerService.PrepareTravellers(ctx, userID, bookingReq, *bookingReq.CheckInDate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Take a room before creating the booking so concurrent bookings
+	// cannot oversell the hotel
+	if err := s.hotelRepo.ReserveRooms(ctx, hotel.ID, 1); err != nil {
+		return nil, err
+	}
 
-	// Create booking
+	// Create booking, holding the room until it is paid for
+	now := s.clock.Now()
+	holdExpiresAt := now.Add(s.holdTTL)
 	booking := &entity.Booking{
 		UserID:          userID,
 		BookingType:     "hotel",
 		HotelID:         bookingReq.HotelID,
-		Status:          "confirmed",
-		BookingDate:     time.Now(),
-		TotalPrice:      float64(nights) * hotel.Price,
+		Status:          "held",
+		BookingDate:     now,
+		TotalPrice:      quote.TotalPrice,
 		PaymentStatus:   "pending",
+		HoldExpiresAt:   &holdExpiresAt,
 		CheckInDate:     *bookingReq.CheckInDate,
 		CheckOutDate:    *bookingReq.CheckOutDate,
 		NumGuests:       bookingReq.NumGuests,
 		SpecialRequests: bookingReq.SpecialRequests,
+		Travellers:      travellers,
 	}
 
 	// Save booking
 	err = s.bookingRepo.Create(ctx, booking)
 	if err != nil {
+		if releaseErr := s.hotelRepo.ReleaseRooms(ctx, hotel.ID, 1); releaseErr != nil {
+			log.Printf("booking: releasing a room at hotel %d after a failed booking: %v", hotel.ID, releaseErr)
+		}
 		return nil, err
 	}
 
-	// Update hotel availability
-	hotel.AvailableRooms--
-	err = s.hotelRepo.Update(ctx, hotel)
+	return booking, nil
+}
+
+func (s *hotelService) QuoteStay(ctx context.Context, hotelID uint, checkIn, checkOut time.Time) (*pricing.StayQuote, error) {
+	hotel, err := s.hotelRepo.FindByID(ctx, hotelID)
+	if err != nil {
+		return nil, errors.ErrHotelNotFound
+	}
+	return s.quoteStay(ctx, hotel, checkIn, checkOut)
+}
+
+// quoteStay prices a stay at the nightly rate in force at check-in
+func (s *hotelService) quoteStay(ctx context.Context, hotel *entity.Hotel, checkIn, checkOut time.Time) (*pricing.StayQuote, error) {
+	nights := int(checkOut.Sub(checkIn).Hours() / 24)
+	if nights < 1 {
+		return nil, errors.ErrInvalidStayDuration
+	}
+
+	quote, err := s.pricer.Quote(ctx, pricing.Input{
+		Product:   "hotel",
+		BasePrice: hotel.Price,
+		Capacity:  hotel.TotalRooms,
+		Remaining: hotel.AvailableRooms,
+		StartsAt:  checkIn,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return booking, nil
+	return &pricing.StayQuote{
+		Quote:      *quote,
+		Nights:     nights,
+		TotalPrice: quote.Price * float64(nights),
+	}, nil
 }
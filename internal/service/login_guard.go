@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"log"
+	"time"
+)
+
+// LoginPolicy sets how repeated failed logins are slowed down and locked out
+type LoginPolicy struct {
+	// FreeAttempts is how many failures an email may have before each
+	// further attempt must wait
+	FreeAttempts int
+	// BaseDelay is the wait after the first delayed failure, doubling
+	// with each one after it up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold failures lock an email for LockoutDuration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// IPLockoutThreshold failures from one IP, whatever the email, lock
+	// the IP for LockoutDuration
+	IPLockoutThreshold int
+	// FailureWindow is how long a failure is remembered; the count starts
+	// again after this long without one
+	FailureWindow time.Duration
+}
+
+// LockoutNotifier is told when repeated failed logins lock an account, so
+// its owner can be warned
+type LockoutNotifier interface {
+	AccountLocked(ctx context.Context, user *entity.User, until time.Time)
+}
+
+// LogLockoutNotifier logs lockouts
+type LogLockoutNotifier struct{}
+
+func (LogLockoutNotifier) AccountLocked(ctx context.Context, user *entity.User, until time.Time) {
+	log.Printf("login: account %s locked until %s after repeated failed logins", user.ID, until.Format(time.RFC3339))
+}
+
+// LoginThrottledError is returned while an email or IP must wait before
+// trying to log in again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return errors.ErrLoginThrottled.Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return errors.ErrLoginThrottled
+}
+
+// LoginGuard tracks failed logins per email and per client IP. Emails that
+// don't belong to an account are tracked exactly like ones that do, so
+// being throttled reveals nothing about which are registered.
+type LoginGuard struct {
+	throttles repository.LoginThrottleRepository
+	policy    LoginPolicy
+	notifier  LockoutNotifier
+	clock     util.Clock
+}
+
+func NewLoginGuard(throttles repository.LoginThrottleRepository, policy LoginPolicy, notifier LockoutNotifier, clock util.Clock) *LoginGuard {
+	return &LoginGuard{
+		throttles: throttles,
+		policy:    policy,
+		notifier:  notifier,
+		clock:     clock,
+	}
+}
+
+// LoginAttempt is a login attempt reserved by Reserve. It counts as a
+// failure from the moment it is reserved, so concurrent attempts can't all
+// slip past a throttle before any of them is recorded, until Passed or
+// Succeeded takes it back.
+type LoginAttempt struct {
+	guard *LoginGuard
+	email string
+	ip    string
+	at    time.Time
+
+	emailReserved reservation
+	ipReserved    reservation
+}
+
+// reservation is what reserving an attempt changed on one throttle, so
+// that taking it back leaves other attempts' changes alone
+type reservation struct {
+	previousFailedAt time.Time
+	retryAt          *time.Time
+	lockedUntil      *time.Time
+}
+
+// Reserve returns a LoginThrottledError if the email or IP may not attempt
+// a login yet, and otherwise counts the attempt as a failure of both.
+// Checking and counting are one update of each throttle, so every attempt
+// sees the ones before it.
+func (g *LoginGuard) Reserve(ctx context.Context, email, ip string) (*LoginAttempt, error) {
+	// As precise as the database keeps it, so taking the attempt back can
+	// recognise the times it set
+	now := g.clock.Now().Truncate(time.Microsecond)
+	attempt := &LoginAttempt{guard: g, email: email, ip: ip, at: now}
+
+	var wait time.Duration
+	_, err := g.throttles.Update(ctx, emailKey(email), func(throttle *entity.LoginThrottle) {
+		if wait = waitFor(throttle, now); wait > 0 {
+			return
+		}
+		attempt.emailReserved = g.countFailure(throttle, now)
+		switch {
+		case throttle.Failures >= g.policy.LockoutThreshold:
+			until := now.Add(g.policy.LockoutDuration)
+			throttle.LockedUntil, attempt.emailReserved.lockedUntil = &until, &until
+		case throttle.Failures > g.policy.FreeAttempts:
+			retry := now.Add(g.delay(throttle.Failures - g.policy.FreeAttempts))
+			throttle.RetryAt, attempt.emailReserved.retryAt = &retry, &retry
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		return nil, &LoginThrottledError{RetryAfter: wait}
+	}
+
+	// IPs aren't delayed, since many users can share one, only locked
+	// out once they fail far more often than one person would
+	_, err = g.throttles.Update(ctx, ipKey(ip), func(throttle *entity.LoginThrottle) {
+		if wait = waitFor(throttle, now); wait > 0 {
+			return
+		}
+		attempt.ipReserved = g.countFailure(throttle, now)
+		if throttle.Failures >= g.policy.IPLockoutThreshold {
+			until := now.Add(g.policy.LockoutDuration)
+			throttle.LockedUntil, attempt.ipReserved.lockedUntil = &until, &until
+		}
+	})
+	if err == nil && wait > 0 {
+		err = &LoginThrottledError{RetryAfter: wait}
+	}
+	if err != nil {
+		if undoErr := g.undo(ctx, emailKey(email), now, attempt.emailReserved, g.policy.LockoutThreshold); undoErr != nil {
+			log.Printf("login: taking back attempt for %s: %v", emailKey(email), undoErr)
+		}
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// Failed confirms the attempt failed. user is the account the email
+// belongs to, or nil if there is none.
+func (a *LoginAttempt) Failed(ctx context.Context, user *entity.User) {
+	if until := a.emailReserved.lockedUntil; until != nil && user != nil {
+		a.guard.notifier.AccountLocked(ctx, user, *until)
+	}
+	if until := a.ipReserved.lockedUntil; until != nil {
+		log.Printf("login: IP %s locked until %s after repeated failed logins", a.ip, until.Format(time.RFC3339))
+	}
+}
+
+// Passed takes the attempt back without forgiving earlier failures, for a
+// right password that still needs a second factor or a verified email
+func (a *LoginAttempt) Passed(ctx context.Context) error {
+	if err := a.guard.undo(ctx, emailKey(a.email), a.at, a.emailReserved, a.guard.policy.LockoutThreshold); err != nil {
+		return err
+	}
+	return a.guard.undo(ctx, ipKey(a.ip), a.at, a.ipReserved, a.guard.policy.IPLockoutThreshold)
+}
+
+// Succeeded takes the attempt back and forgets the email's failures, like
+// LoginGuard.Succeeded
+func (a *LoginAttempt) Succeeded(ctx context.Context) error {
+	if err := a.guard.undo(ctx, ipKey(a.ip), a.at, a.ipReserved, a.guard.policy.IPLockoutThreshold); err != nil {
+		return err
+	}
+	return a.guard.Succeeded(ctx, a.email)
+}
+
+// Succeeded forgets the email's failures. The IP's are kept, so an
+// attacker can't reset them by logging in to an account of their own.
+func (g *LoginGuard) Succeeded(ctx context.Context, email string) error {
+	return g.throttles.Delete(ctx, emailKey(email))
+}
+
+// Unlock lifts any delay or lockout on the email
+func (g *LoginGuard) Unlock(ctx context.Context, email string) error {
+	return g.throttles.Delete(ctx, emailKey(email))
+}
+
+// countFailure adds a failure, first forgetting old ones, returning what
+// it replaced
+func (g *LoginGuard) countFailure(throttle *entity.LoginThrottle, now time.Time) reservation {
+	reserved := reservation{previousFailedAt: throttle.LastFailedAt}
+	if now.Sub(throttle.LastFailedAt) > g.policy.FailureWindow {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailedAt = now
+	throttle.RetryAt = nil
+	return reserved
+}
+
+// undo takes back a failure reserved at at, clearing the delay or lockout
+// it set unless a later attempt has replaced it
+func (g *LoginGuard) undo(ctx context.Context, key string, at time.Time, reserved reservation, lockoutThreshold int) error {
+	_, err := g.throttles.Update(ctx, key, func(throttle *entity.LoginThrottle) {
+		if throttle.Failures > 0 {
+			throttle.Failures--
+		}
+		if throttle.LastFailedAt.Equal(at) {
+			throttle.LastFailedAt = reserved.previousFailedAt
+		}
+		if sameTime(throttle.RetryAt, reserved.retryAt) {
+			throttle.RetryAt = nil
+		}
+		if sameTime(throttle.LockedUntil, reserved.lockedUntil) && throttle.Failures < lockoutThreshold {
+			throttle.LockedUntil = nil
+		}
+	})
+	return err
+}
+
+// waitFor is how long the throttle makes the next attempt wait
+func waitFor(throttle *entity.LoginThrottle, now time.Time) time.Duration {
+	var wait time.Duration
+	for _, until := range []*time.Time{throttle.RetryAt, throttle.LockedUntil} {
+		if until != nil && until.Sub(now) > wait {
+			wait = until.Sub(now)
+		}
+	}
+	return wait
+}
+
+func sameTime(a, b *time.Time) bool {
+	return a != nil && b != nil && a.Equal(*b)
+}
+
+// delay is the wait after the nth delayed failure
+func (g *LoginGuard) delay(n int) time.Duration {
+	delay := g.policy.BaseDelay
+	for i := 1; i < n && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, g.policy.MaxDelay)
+}
+
+func emailKey(email string) string {
+	return "email:" + normalizeEmail(email)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/domain/repository/memory"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testLoginPolicy = LoginPolicy{
+	FreeAttempts:       3,
+	BaseDelay:          time.Second,
+	MaxDelay:           time.Minute,
+	LockoutThreshold:   10,
+	LockoutDuration:    15 * time.Minute,
+	IPLockoutThreshold: 100,
+	FailureWindow:      time.Hour,
+}
+
+type recordingNotifier struct {
+	locked []time.Time
+}
+
+func (n *recordingNotifier) AccountLocked(ctx context.Context, user *entity.User, until time.Time) {
+	n.locked = append(n.locked, until)
+}
+
+func newTestGuard(policy LoginPolicy, now time.Time) (*LoginGuard, repository.LoginThrottleRepository, *recordingNotifier) {
+	throttles := memory.NewLoginThrottleRepository(memory.NewStore())
+	notifier := &recordingNotifier{}
+	return NewLoginGuard(throttles, policy, notifier, util.FixedClock(now)), throttles, notifier
+}
+
+func failures(t *testing.T, throttles repository.LoginThrottleRepository, key string) int {
+	t.Helper()
+	found, err := throttles.Find(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) == 0 {
+		return 0
+	}
+	return found[0].Failures
+}
+
+func TestLoginGuardReservesConcurrentAttempts(t *testing.T) {
+	guard, throttles, _ := newTestGuard(testLoginPolicy, time.Unix(1_800_000_000, 0))
+	ctx := context.Background()
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt, err := guard.Reserve(ctx, "victim@example.com", "203.0.113.7")
+			var throttled *LoginThrottledError
+			switch {
+			case err == nil:
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+				attempt.Failed(ctx, nil)
+			case !stderrors.As(err, &throttled):
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The free attempts, then the one that starts the delay
+	if want := testLoginPolicy.FreeAttempts + 1; reserved != want {
+		t.Errorf("%d concurrent attempts got through, want %d", reserved, want)
+	}
+	if got := failures(t, throttles, emailKey("victim@example.com")); got != reserved {
+		t.Errorf("email has %d failures, want %d", got, reserved)
+	}
+	if got := failures(t, throttles, ipKey("203.0.113.7")); got != reserved {
+		t.Errorf("IP has %d failures, want %d", got, reserved)
+	}
+}
+
+func TestLoginGuardAttemptOutcomes(t *testing.T) {
+	const email, ip = "user@example.com", "203.0.113.7"
+	ctx := context.Background()
+	user := &entity.User{Email: email}
+
+	tests := []struct {
+		name       string
+		earlier    int // failures of the email before the attempt
+		finish     func(*LoginAttempt) error
+		failures   int
+		ipFailures int
+		throttled  bool // whether the next attempt has to wait
+		locked     int  // lockout notifications
+	}{
+		{
+			name:     "failed",
+			finish:   func(a *LoginAttempt) error { a.Failed(ctx, user); return nil },
+			failures: 1, ipFailures: 1,
+		},
+		{
+			name:     "failure past the free attempts delays the next",
+			earlier:  3,
+			finish:   func(a *LoginAttempt) error { a.Failed(ctx, user); return nil },
+			failures: 4, ipFailures: 4, throttled: true,
+		},
+		{
+			name:     "failure at the threshold locks the account",
+			earlier:  9,
+			finish:   func(a *LoginAttempt) error { a.Failed(ctx, user); return nil },
+			failures: 10, ipFailures: 10, throttled: true, locked: 1,
+		},
+		{
+			name:     "passed keeps earlier failures but adds none",
+			earlier:  2,
+			finish:   func(a *LoginAttempt) error { return a.Passed(ctx) },
+			failures: 2, ipFailures: 2,
+		},
+		{
+			name:     "passed takes back the delay it would have set",
+			earlier:  3,
+			finish:   func(a *LoginAttempt) error { return a.Passed(ctx) },
+			failures: 3, ipFailures: 3,
+		},
+		{
+			name:     "passed takes back the lockout it would have set",
+			earlier:  9,
+			finish:   func(a *LoginAttempt) error { return a.Passed(ctx) },
+			failures: 9, ipFailures: 9,
+		},
+		{
+			name:     "succeeded forgets the email's failures but not the IP's",
+			earlier:  3,
+			finish:   func(a *LoginAttempt) error { return a.Succeeded(ctx) },
+			failures: 0, ipFailures: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Earlier failures each waited out their delay
+			start := time.Unix(1_800_000_000, 0)
+			guard, throttles, notifier := newTestGuard(testLoginPolicy, start)
+			for i := 0; i < tt.earlier; i++ {
+				guard.clock = util.FixedClock(start.Add(time.Duration(i) * time.Minute))
+				attempt, err := guard.Reserve(ctx, email, ip)
+				if err != nil {
+					t.Fatalf("earlier failure %d: %v", i+1, err)
+				}
+				attempt.Failed(ctx, user)
+			}
+			now := start.Add(time.Duration(tt.earlier) * time.Minute)
+			guard.clock = util.FixedClock(now)
+
+			attempt, err := guard.Reserve(ctx, email, ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.finish(attempt); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := failures(t, throttles, emailKey(email)); got != tt.failures {
+				t.Errorf("email failures = %d, want %d", got, tt.failures)
+			}
+			if got := failures(t, throttles, ipKey(ip)); got != tt.ipFailures {
+				t.Errorf("IP failures = %d, want %d", got, tt.ipFailures)
+			}
+			_, err = guard.Reserve(ctx, email, ip)
+			if throttled := stderrors.Is(err, errors.ErrLoginThrottled); throttled != tt.throttled {
+				t.Errorf("next attempt throttled = %v (%v), want %v", throttled, err, tt.throttled)
+			}
+			if len(notifier.locked) != tt.locked {
+				t.Errorf("%d lockout notifications, want %d", len(notifier.locked), tt.locked)
+			}
+		})
+	}
+}
+
+func TestLoginGuardLockedIPTakesBackEmailAttempt(t *testing.T) {
+	policy := testLoginPolicy
+	policy.IPLockoutThreshold = 2
+	guard, throttles, _ := newTestGuard(policy, time.Unix(1_800_000_000, 0))
+	ctx := context.Background()
+	const ip = "203.0.113.7"
+
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		attempt, err := guard.Reserve(ctx, email, ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attempt.Failed(ctx, nil)
+	}
+
+	if _, err := guard.Reserve(ctx, "c@example.com", ip); !stderrors.Is(err, errors.ErrLoginThrottled) {
+		t.Fatalf("Reserve from a locked IP = %v, want %v", err, errors.ErrLoginThrottled)
+	}
+	if got := failures(t, throttles, emailKey("c@example.com")); got != 0 {
+		t.Errorf("refused attempt left %d failures on the email", got)
+	}
+}
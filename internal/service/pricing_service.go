@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/pricing"
+	"fledge-restapi/pkg/errors"
+)
+
+type PricingService interface {
+	ListRules(ctx context.Context) ([]entity.PricingRule, error)
+	CreateRule(ctx context.Context, req *entity.PricingRuleRequest) (*entity.PricingRule, error)
+	UpdateRule(ctx context.Context, id uint, req *entity.PricingRuleRequest) (*entity.PricingRule, error)
+	DeleteRule(ctx context.Context, id uint) error
+}
+
+type pricingService struct {
+	ruleRepo repository.PricingRuleRepository
+}
+
+func NewPricingService(ruleRepo repository.PricingRuleRepository) PricingService {
+	return &pricingService{
+		ruleRepo: ruleRepo,
+	}
+}
+
+func (s *pricingService) ListRules(ctx context.Context) ([]entity.PricingRule, error) {
+	return s.ruleRepo.FindAll(ctx)
+}
+
+func (s *pricingService) CreateRule(ctx context.Context, req *entity.PricingRuleRequest) (*entity.PricingRule, error) {
+	rule := &entity.PricingRule{}
+	applyPricingRuleRequest(rule, req)
+
+	if _, err := pricing.Compile(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Create(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *pricingService) UpdateRule(ctx context.Context, id uint, req *entity.PricingRuleRequest) (*entity.PricingRule, error) {
+	rule, err := s.ruleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.ErrPricingRuleNotFound
+	}
+	applyPricingRuleRequest(rule, req)
+
+	if _, err := pricing.Compile(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *pricingService) DeleteRule(ctx context.Context, id uint) error {
+	if _, err := s.ruleRepo.FindByID(ctx, id); err != nil {
+		return errors.ErrPricingRuleNotFound
+	}
+	return s.ruleRepo.Delete(ctx, id)
+}
+
+func applyPricingRuleRequest(rule *entity.PricingRule, req *entity.PricingRuleRequest) {
+	rule.Name = req.Name
+	rule.Product = req.Product
+	rule.Type = req.Type
+	rule.Min = req.Min
+	rule.Max = req.Max
+	rule.Weekdays = req.Weekdays
+	rule.SeasonStart = req.SeasonStart
+	rule.SeasonEnd = req.SeasonEnd
+	rule.Percent = req.Percent
+	rule.Priority = req.Priority
+	rule.Active = req.Active == nil || *req.Active
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SavedSearchService interface {
+	CreateSavedSearch(ctx context.Context, userID uuid.UUID, req *entity.SavedSearchRequest) (*entity.SavedSearch, error)
+	ListSavedSearches(ctx context.Context, userID uuid.UUID) ([]entity.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, userID uuid.UUID, id uint) error
+	ListAlerts(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]entity.PriceAlert, error)
+	MarkAlertRead(ctx context.Context, userID uuid.UUID, id uint) error
+	EvaluateDue(ctx context.Context, interval time.Duration) error
+}
+
+type savedSearchService struct {
+	savedSearchRepo repository.SavedSearchRepository
+	alertRepo       repository.PriceAlertRepository
+	flightService   FlightService
+	hotelService    HotelService
+	clock           util.Clock
+}
+
+func NewSavedSearchService(
+	savedSearchRepo repository.SavedSearchRepository,
+	alertRepo repository.PriceAlertRepository,
+	flightService FlightService,
+	hotelService HotelService,
+	clock util.Clock,
+) SavedSearchService {
+	return &savedSearchService{
+		savedSearchRepo: savedSearchRepo,
+		alertRepo:       alertRepo,
+		flightService:   flightService,
+		hotelService:    hotelService,
+		clock:           clock,
+	}
+}
+
+func (s *savedSearchService) CreateSavedSearch(ctx context.Context, userID uuid.UUID, req *entity.SavedSearchRequest) (*entity.SavedSearch, error) {
+	search := &entity.SavedSearch{
+		UserID:      userID,
+		Name:        req.Name,
+		SearchType:  req.SearchType,
+		TargetPrice: req.TargetPrice,
+		Active:      true,
+	}
+
+	switch req.SearchType {
+	case "flight":
+		if req.Flight == nil {
+			return nil, errors.ErrMissingSearchCriteria
+		}
+		search.FlightCriteria = req.Flight
+	case "hotel":
+		if req.Hotel == nil {
+			return nil, errors.ErrMissingSearchCriteria
+		}
+		search.HotelCriteria = req.Hotel
+	default:
+		return nil, errors.ErrInvalidInput
+	}
+
+	if err := s.savedSearchRepo.Create(ctx, search); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+func (s *savedSearchService) ListSavedSearches(ctx context.Context, userID uuid.UUID) ([]entity.SavedSearch, error) {
+	return s.savedSearchRepo.FindByUserID(ctx, userID)
+}
+
+func (s *savedSearchService) DeleteSavedSearch(ctx context.Context, userID uuid.UUID, id uint) error {
+	search, err := s.savedSearchRepo.FindByID(ctx, id)
+	if err != nil || search.UserID != userID {
+		return errors.ErrSavedSearchNotFound
+	}
+	return s.savedSearchRepo.Delete(ctx, id)
+}
+
+func (s *savedSearchService) ListAlerts(ctx context.Context, userID uuid.UUID, unreadOnly bool) ([]entity.PriceAlert, error) {
+	return s.alertRepo.FindByUserID(ctx, userID, unreadOnly)
+}
+
+func (s *savedSearchService) MarkAlertRead(ctx context.Context, userID uuid.UUID, id uint) error {
+	return s.alertRepo.MarkRead(ctx, userID, id, s.clock.Now())
+}
+
+// EvaluateDue re-runs every active saved search that hasn't run within the
+// interval, raising alerts for prices below target. A failing search does
+// not stop the others from being evaluated.
+func (s *savedSearchService) EvaluateDue(ctx context.Context, interval time.Duration) error {
+	now := s.clock.Now()
+	searches, err := s.savedSearchRepo.FindDue(ctx, now.Add(-interval))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range searches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.evaluate(ctx, &searches[i], now); err != nil {
+			errs = append(errs, fmt.Errorf("saved search %d: %w", searches[i].ID, err))
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (s *savedSearchService) evaluate(ctx context.Context, search *entity.SavedSearch, now time.Time) error {
+	price, itemID, found, err := s.lowestPrice(ctx, search)
+	switch err {
+	case nil:
+	case errors.ErrInvalidDepartureDate, errors.ErrInvalidCheckInDate:
+		// The searched dates have passed, so the search can never match again
+		search.Active = false
+	default:
+		return err
+	}
+
+	search.LastRunAt = &now
+	if found {
+		// Without a target, any drop below the last run's lowest price is
+		// worth an alert. Never alert twice for the same or a higher price.
+		threshold := search.TargetPrice
+		if threshold == nil {
+			threshold = search.LowestPrice
+		}
+		if threshold != nil && price < *threshold && (search.AlertedPrice == nil || price < *search.AlertedPrice) {
+			alert := &entity.PriceAlert{
+				UserID:        search.UserID,
+				SavedSearchID: search.ID,
+				ItemType:      search.SearchType,
+				ItemID:        itemID,
+				Price:         price,
+				PreviousPrice: search.LowestPrice,
+				TargetPrice:   search.TargetPrice,
+			}
+			if err := s.alertRepo.Create(ctx, alert); err != nil {
+				return err
+			}
+			search.AlertedPrice = &price
+		}
+		search.LowestPrice = &price
+	}
+
+	return s.savedSearchRepo.Update(ctx, search)
+}
+
+// lowestPrice runs a saved search and returns the lowest current price among
+// its results, per passenger for flights and per night for hotels
+func (s *savedSearchService) lowestPrice(ctx context.Context, search *entity.SavedSearch) (float64, uint, bool, error) {
+	var (
+		lowest float64
+		itemID uint
+		found  bool
+	)
+	consider := func(id uint, price float64) {
+		if !found || price < lowest {
+			lowest, itemID, found = price, id, true
+		}
+	}
+
+	switch search.SearchType {
+	case "flight":
+		if search.FlightCriteria == nil {
+			return 0, 0, false, errors.ErrMissingSearchCriteria
+		}
+		flights, err := s.flightService.SearchFlights(ctx, search.FlightCriteria)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		for i := range flights {
+			// Search results come priced, at the quote a booking would pay
+			for _, fare := range flights[i].CheapestFares(search.FlightCriteria.Passengers) {
+				consider(flights[i].ID, fare.CurrentPrice())
+			}
+		}
+
+	case "hotel":
+		criteria := search.HotelCriteria
+		if criteria == nil {
+			return 0, 0, false, errors.ErrMissingSearchCriteria
+		}
+		hotels, err := s.hotelService.SearchHotels(ctx, criteria)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		for _, hotel := range hotels {
+			quote, err := s.hotelService.QuoteStay(ctx, hotel.ID, criteria.CheckIn, criteria.CheckOut)
+			if err != nil {
+				return 0, 0, false, err
+			}
+			consider(hotel.ID, quote.Price)
+		}
+
+	default:
+		return 0, 0, false, errors.ErrInvalidInput
+	}
+
+	return lowest, itemID, found, nil
+}
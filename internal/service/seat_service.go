@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/pkg/errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type SeatService interface {
+	GetSeatMap(ctx context.Context, flightID uint) ([]entity.Seat, error)
+	CreateSeatMap(ctx context.Context, flightID uint, req *entity.SeatMapRequest) ([]entity.Seat, error)
+	AssignSeats(ctx context.Context, userID uuid.UUID, bookingID uint, seatNumbers []string) ([]entity.Seat, error)
+}
+
+type seatService struct {
+	seatRepo    repository.SeatRepository
+	flightRepo  repository.FlightRepository
+	bookingRepo repository.BookingRepository
+	userRepo    repository.UserRepository
+}
+
+func NewSeatService(
+	seatRepo repository.SeatRepository,
+	flightRepo repository.FlightRepository,
+	bookingRepo repository.BookingRepository,
+	userRepo repository.UserRepository,
+) SeatService {
+	return &seatService{
+		seatRepo:    seatRepo,
+		flightRepo:  flightRepo,
+		bookingRepo: bookingRepo,
+		userRepo:    userRepo,
+	}
+}
+
+func (s *seatService) GetSeatMap(ctx context.Context, flightID uint) ([]entity.Seat, error) {
+	if _, err := s.flightRepo.FindByID(ctx, flightID); err != nil {
+		return nil, errors.ErrFlightNotFound
+	}
+
+	seats, err := s.seatRepo.FindByFlightID(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+	if len(seats) == 0 {
+		return nil, errors.ErrSeatMapNotFound
+	}
+
+	return seats, nil
+}
+
+func (s *seatService) CreateSeatMap(ctx context.Context, flightID uint, req *entity.SeatMapRequest) ([]entity.Seat, error) {
+	if _, err := s.flightRepo.FindByID(ctx, flightID); err != nil {
+		return nil, errors.ErrFlightNotFound
+	}
+
+	existing, err := s.seatRepo.FindByFlightID(ctx, flightID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, errors.ErrSeatMapExists
+	}
+
+	seats, err := buildSeatMap(flightID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.seatRepo.CreateMany(ctx, seats); err != nil {
+		return nil, err
+	}
+
+	return seats, nil
+}
+
+func (s *seatService) AssignSeats(ctx context.Context, userID uuid.UUID, bookingID uint, seatNumbers []string) ([]entity.Seat, error) {
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+	if err != nil {
+		return nil, errors.ErrBookingNotFound
+	}
+	if booking.UserID != userID {
+		return nil, errors.ErrBookingAccessDenied
+	}
+	if booking.BookingType != "flight" || booking.FlightID == nil || booking.FareClassID == nil {
+		return nil, errors.ErrInvalidBookingType
+	}
+	if booking.Status == "cancelled" {
+		return nil, errors.ErrBookingCancelled
+	}
+
+	// Seats can only be chosen in the cabin of the booked fare
+	fare, err := s.flightRepo.FindFare(ctx, *booking.FlightID, *booking.FareClassID)
+	if err != nil {
+		return nil, errors.ErrFareNotFound
+	}
+
+	seats, err := s.seatRepo.FindByFlightID(ctx, fare.FlightID)
+	if err != nil {
+		return nil, err
+	}
+	if len(seats) == 0 {
+		return nil, errors.ErrSeatMapNotFound
+	}
+
+	var chosen []entity.Seat
+	if len(seatNumbers) > 0 {
+		chosen, err = selectSeats(seats, fare.Cabin, booking, seatNumbers)
+	} else {
+		preferredSeat := ""
+		if preferences, err := s.userRepo.FindPreferences(ctx, userID); err == nil {
+			preferredSeat = preferences.PreferredSeat
+		}
+		chosen, err = pickSeats(seats, fare.Cabin, booking, preferredSeat)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seatIDs := make([]uint, len(chosen))
+	for i, seat := range chosen {
+		seatIDs[i] = seat.ID
+	}
+	if err := s.seatRepo.Assign(ctx, booking.ID, seatIDs); err != nil {
+		return nil, err
+	}
+
+	return s.seatRepo.FindByBookingID(ctx, booking.ID)
+}
+
+// selectSeats resolves explicitly requested seat numbers against the seat map
+func selectSeats(seats []entity.Seat, cabin string, booking *entity.Booking, seatNumbers []string) ([]entity.Seat, error) {
+	if len(seatNumbers) != booking.SeatCount {
+		return nil, errors.ErrSeatCountMismatch
+	}
+
+	byNumber := make(map[string]entity.Seat, len(seats))
+	for _, seat := range seats {
+		byNumber[seat.Number()] = seat
+	}
+
+	chosen := make([]entity.Seat, 0, len(seatNumbers))
+	seen := make(map[string]bool, len(seatNumbers))
+	for _, number := range seatNumbers {
+		number = strings.ToUpper(strings.TrimSpace(number))
+		seat, ok := byNumber[number]
+		if !ok || seat.Cabin != cabin {
+			return nil, errors.ErrSeatNotFound
+		}
+		if seen[number] || !assignableTo(seat, booking) {
+			return nil, errors.ErrSeatUnavailable
+		}
+		seen[number] = true
+		chosen = append(chosen, seat)
+	}
+
+	return chosen, nil
+}
+
+// pickSeats chooses seats for every seated guest on a booking, preferring the
+// user's preferred seat type and then the front of the cabin
+func pickSeats(seats []entity.Seat, cabin string, booking *entity.Booking, preferredSeat string) ([]entity.Seat, error) {
+	var candidates []entity.Seat
+	for _, seat := range seats {
+		if seat.Cabin == cabin && assignableTo(seat, booking) {
+			candidates = append(candidates, seat)
+		}
+	}
+	if len(candidates) < booking.SeatCount {
+		return nil, errors.ErrSeatUnavailable
+	}
+
+	preferredSeat = strings.ToLower(preferredSeat)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iPreferred := candidates[i].SeatType() == preferredSeat
+		jPreferred := candidates[j].SeatType() == preferredSeat
+		return iPreferred && !jPreferred
+	})
+
+	return candidates[:booking.SeatCount], nil
+}
+
+// assignableTo reports whether a seat is free or already held by the booking
+func assignableTo(seat entity.Seat, booking *entity.Booking) bool {
+	if seat.Blocked {
+		return false
+	}
+	return seat.BookingID == nil || *seat.BookingID == booking.ID
+}
+
+// buildSeatMap expands a seat map layout into individual seats
+func buildSeatMap(flightID uint, req *entity.SeatMapRequest) ([]entity.Seat, error) {
+	var seats []entity.Seat
+	byNumber := make(map[string]int)
+
+	for _, section := range req.Sections {
+		groups := strings.Split(strings.ToUpper(section.Layout), "-")
+		exitRows := make(map[int]bool, len(section.ExitRows))
+		for _, row := range section.ExitRows {
+			exitRows[row] = true
+		}
+
+		for row := section.FirstRow; row <= section.LastRow; row++ {
+			for g, group := range groups {
+				if group == "" {
+					return nil, errors.ErrInvalidSeatLayout
+				}
+				for i, letter := range group {
+					if letter < 'A' || letter > 'Z' {
+						return nil, errors.ErrInvalidSeatLayout
+					}
+
+					seat := entity.Seat{
+						FlightID: flightID,
+						Row:      row,
+						Letter:   string(letter),
+						Cabin:    section.Cabin,
+						Window:   (g == 0 && i == 0) || (g == len(groups)-1 && i == len(group)-1),
+						Aisle:    (g > 0 && i == 0) || (g < len(groups)-1 && i == len(group)-1),
+						Exit:     exitRows[row],
+					}
+					if _, dup := byNumber[seat.Number()]; dup {
+						return nil, errors.ErrInvalidSeatLayout
+					}
+					byNumber[seat.Number()] = len(seats)
+					seats = append(seats, seat)
+				}
+			}
+		}
+	}
+
+	for _, number := range req.Blocked {
+		i, ok := byNumber[strings.ToUpper(strings.TrimSpace(number))]
+		if !ok {
+			return nil, errors.ErrInvalidSeatLayout
+		}
+		seats[i].Blocked = true
+	}
+
+	return seats, nil
+}
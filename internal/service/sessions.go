@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sessionTouchInterval is how stale a session's last seen time may get,
+// so busy clients don't write to the database on every request
+const sessionTouchInterval = time.Minute
+
+// maxUserAgentLength bounds the user agent kept for a session
+const maxUserAgentLength = 512
+
+// Client is who a request came from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Sessions issues access tokens tied to a session per sign-in, so users
+// can see where they are signed in and sign devices out. A session lasts
+// as long as its token; revoking it deletes it, and tokens naming a
+// missing session are refused.
+type Sessions struct {
+	sessions repository.SessionRepository
+	jwt      *util.JWTManager
+	clock    util.Clock
+}
+
+func NewSessions(sessions repository.SessionRepository, jwt *util.JWTManager, clock util.Clock) *Sessions {
+	return &Sessions{
+		sessions: sessions,
+		jwt:      jwt,
+		clock:    clock,
+	}
+}
+
+// Start opens a session for the user on client and returns its access
+// token
+func (s *Sessions) Start(ctx context.Context, user *entity.User, client Client) (string, error) {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+
+	now := s.clock.Now()
+	session := &entity.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IPAddress:  client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.jwt.TTL()),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return "", err
+	}
+	return s.jwt.Generate(user.ID, session.ID, user.Email, user.Role, now)
+}
+
+// Check fails with ErrSessionRevoked unless the user's session is still
+// active, and records that it was seen from clientIP
+func (s *Sessions) Check(ctx context.Context, userID, sessionID uuid.UUID, clientIP string) error {
+	session, err := s.sessions.Find(ctx, sessionID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	if session.UserID != userID || !session.ExpiresAt.After(now) {
+		return errors.ErrSessionRevoked
+	}
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval || session.IPAddress != clientIP {
+		return s.sessions.Touch(ctx, sessionID, clientIP, now)
+	}
+	return nil
+}
+
+// List returns the user's active sessions, marking current
+func (s *Sessions) List(ctx context.Context, userID, current uuid.UUID) ([]entity.Session, error) {
+	sessions, err := s.sessions.FindActiveByUser(ctx, userID, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return sessions, nil
+}
+
+// Revoke signs one of the user's sessions out
+func (s *Sessions) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	return s.sessions.Delete(ctx, userID, id)
+}
+
+// RevokeOthers signs out every session of the user's but current, and
+// returns how many there were
+func (s *Sessions) RevokeOthers(ctx context.Context, userID, current uuid.UUID) (int64, error) {
+	return s.sessions.DeleteOthers(ctx, userID, current)
+}
+
+// RevokeAll signs the user out everywhere
+func (s *Sessions) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	_, err := s.sessions.DeleteOthers(ctx, userID, uuid.Nil)
+	return err
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/oidc"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SingleSignOn signs users in through OpenID Connect providers. The
+// browser goes to the provider with a random state and comes back to the
+// web app, which posts the code and state to the API. The PKCE verifier
+// and nonce that go with the state never leave the server.
+type SingleSignOn struct {
+	providers  map[string]*oidc.Provider
+	logins     repository.OIDCLoginRepository
+	identities repository.UserIdentityRepository
+	users      repository.UserRepository
+	ttl        time.Duration // how long the user has to sign in at the provider
+	clock      util.Clock
+}
+
+func NewSingleSignOn(providers []*oidc.Provider, logins repository.OIDCLoginRepository, identities repository.UserIdentityRepository, users repository.UserRepository, ttl time.Duration, clock util.Clock) *SingleSignOn {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &SingleSignOn{
+		providers:  byName,
+		logins:     logins,
+		identities: identities,
+		users:      users,
+		ttl:        ttl,
+		clock:      clock,
+	}
+}
+
+// Start begins a sign-in with the named provider
+func (s *SingleSignOn) Start(ctx context.Context, name string) (*entity.OIDCStartResponse, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, errors.ErrProviderNotFound
+	}
+
+	var secrets [3]string // state, nonce and PKCE verifier
+	for i := range secrets {
+		secret, err := oidc.NewVerifier()
+		if err != nil {
+			return nil, err
+		}
+		secrets[i] = secret
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return nil, providerError(name, err)
+	}
+
+	now := s.clock.Now()
+	err = s.logins.Create(ctx, &entity.OIDCLogin{
+		CreatedAt:    now,
+		StateHash:    hashState(state),
+		Provider:     name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    now.Add(s.ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &entity.OIDCStartResponse{AuthorizationURL: authURL, State: state}, nil
+}
+
+// Complete redeems the code the provider sent back and returns the user it
+// identifies, linking or creating the account on first sign-in. Each state
+// can be completed once.
+func (s *SingleSignOn) Complete(ctx context.Context, name string, req *entity.OIDCCallbackRequest) (*entity.User, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, errors.ErrProviderNotFound
+	}
+	login, err := s.logins.Consume(ctx, hashState(req.State), s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if login.Provider != name {
+		return nil, errors.ErrInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(ctx, req.Code, login.CodeVerifier, login.Nonce, s.clock.Now())
+	if err != nil {
+		return nil, providerError(name, err)
+	}
+	return s.resolve(ctx, name, claims)
+}
+
+// resolve finds the user for a verified ID token. A subject seen before
+// maps to its user. Otherwise the provider must vouch for the email, and
+// the identity is linked to the account with that email, or to a new one.
+func (s *SingleSignOn) resolve(ctx context.Context, name string, claims *oidc.Claims) (*entity.User, error) {
+	identity, err := s.identities.Find(ctx, name, claims.Subject)
+	if err == nil {
+		return s.users.FindByID(ctx, identity.UserID)
+	}
+	if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.ErrOIDCEmailMissing
+	}
+	email := normalizeEmail(claims.Email)
+	now := s.clock.Now()
+
+	user, err := s.users.FindByEmail(ctx, email)
+	if err == nil {
+		if user.EmailVerifiedAt == nil {
+			// Whoever chose the password never proved they own the
+			// address, and may not be the person now signing in, so
+			// the password stops working; it can be reset by email
+			if err := s.users.ClearPassword(ctx, user.ID, now); err != nil {
+				return nil, err
+			}
+			user.EmailVerifiedAt = &now
+			user.Password = ""
+		}
+	} else {
+		user = &entity.User{
+			ID:              uuid.New(),
+			Email:           email,
+			FirstName:       strings.TrimSpace(claims.GivenName),
+			LastName:        strings.TrimSpace(claims.FamilyName),
+			EmailVerifiedAt: &now,
+		}
+		if user.FirstName == "" && user.LastName == "" {
+			user.FirstName = strings.TrimSpace(claims.Name)
+		}
+		if err := s.users.Create(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.identities.Create(ctx, &entity.UserIdentity{
+		UserID:   user.ID,
+		Provider: name,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if stderrors.Is(err, gorm.ErrDuplicatedKey) {
+		// Linked by a concurrent sign-in; use whichever account won
+		return s.resolve(ctx, name, claims)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// providerError logs what went wrong with a provider and returns the
+// error to show for it
+func providerError(name string, err error) error {
+	switch {
+	case stderrors.Is(err, oidc.ErrUnavailable):
+		log.Printf("oidc: provider %s: %v", name, err)
+		return errors.ErrProviderUnavailable
+	case stderrors.Is(err, oidc.ErrRejected):
+		log.Printf("oidc: provider %s: %v", name, err)
+		return errors.ErrOIDCLoginFailed
+	}
+	return err
+}
+
+// hashState keeps the state out of the database. It is random enough
+// that a fast hash is safe.
+func hashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/domain/repository/memory"
+	"fledge-restapi/internal/oidc"
+	"fledge-restapi/internal/oidc/oidctest"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const ssoTTL = 10 * time.Minute
+
+// ssoFixture signs in through a local issuer registered as "test", and
+// again as "other" to check states stay with their provider
+type ssoFixture struct {
+	issuer *oidctest.Issuer
+	sso    *SingleSignOn
+	users  repository.UserRepository
+}
+
+func newSSOFixture(t *testing.T, user oidctest.User) *ssoFixture {
+	t.Helper()
+	issuer := oidctest.NewIssuer(user)
+	t.Cleanup(issuer.Close)
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	providers := []*oidc.Provider{
+		oidc.NewProvider(issuer.Config("test", "https://app.example/sso/callback"), http.DefaultClient),
+		oidc.NewProvider(issuer.Config("other", "https://app.example/sso/callback"), http.DefaultClient),
+	}
+	sso := NewSingleSignOn(providers, memory.NewOIDCLoginRepository(store), memory.NewUserIdentityRepository(store),
+		users, ssoTTL, util.FixedClock(time.Now()))
+	return &ssoFixture{issuer: issuer, sso: sso, users: users}
+}
+
+// signIn starts a sign-in with the provider and has the issuer approve it,
+// returning the callback the web app would post
+func (f *ssoFixture) signIn(t *testing.T, provider string) *entity.OIDCCallbackRequest {
+	t.Helper()
+	start, err := f.sso.Start(context.Background(), provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err := f.issuer.Authorize(start.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != start.State {
+		t.Fatalf("issuer returned state %q, want %q", state, start.State)
+	}
+	return &entity.OIDCCallbackRequest{Code: code, State: state}
+}
+
+func TestSingleSignOnStartUsesPKCE(t *testing.T) {
+	f := newSSOFixture(t, oidctest.User{Subject: "sub-1", Email: "ada@example.com", EmailVerified: true})
+
+	start, err := f.sso.Start(context.Background(), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := url.Parse(start.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Errorf("authorization URL %s doesn't use PKCE with S256", authURL)
+	}
+	if query.Get("state") != start.State || query.Get("nonce") == "" || query.Get("nonce") == start.State {
+		t.Errorf("authorization URL %s needs the state and its own nonce", authURL)
+	}
+
+	if _, err := f.sso.Start(context.Background(), "nowhere"); err != errors.ErrProviderNotFound {
+		t.Errorf("Start with an unknown provider = %v, want %v", err, errors.ErrProviderNotFound)
+	}
+}
+
+func TestSingleSignOnComplete(t *testing.T) {
+	ctx := context.Background()
+	ada := oidctest.User{Subject: "sub-1", Email: "Ada@Example.com", EmailVerified: true, GivenName: "Ada", FamilyName: "Lovelace"}
+
+	tests := []struct {
+		name string
+		// callback returns what the web app posts back, and the provider
+		// it posts to
+		callback func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest)
+		wantErr  error
+	}{
+		{
+			name: "signs in",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				return "test", f.signIn(t, "test")
+			},
+		},
+		{
+			name: "code redeemed with another sign-in's PKCE verifier",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				first, second := f.signIn(t, "test"), f.signIn(t, "test")
+				return "test", &entity.OIDCCallbackRequest{Code: first.Code, State: second.State}
+			},
+			wantErr: errors.ErrOIDCLoginFailed,
+		},
+		{
+			name: "ID token with another nonce",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				start, err := f.sso.Start(ctx, "test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				authURL, _ := url.Parse(start.AuthorizationURL)
+				query := authURL.Query()
+				query.Set("nonce", "forged")
+				authURL.RawQuery = query.Encode()
+				code, state, err := f.issuer.Authorize(authURL.String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return "test", &entity.OIDCCallbackRequest{Code: code, State: state}
+			},
+			wantErr: errors.ErrOIDCLoginFailed,
+		},
+		{
+			name: "replayed state",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				callback := f.signIn(t, "test")
+				if _, err := f.sso.Complete(ctx, "test", callback); err != nil {
+					t.Fatal(err)
+				}
+				// A fresh code can't revive the used state
+				again := f.signIn(t, "test")
+				return "test", &entity.OIDCCallbackRequest{Code: again.Code, State: callback.State}
+			},
+			wantErr: errors.ErrInvalidOIDCState,
+		},
+		{
+			name: "unknown state",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				callback := f.signIn(t, "test")
+				callback.State = "made-up"
+				return "test", callback
+			},
+			wantErr: errors.ErrInvalidOIDCState,
+		},
+		{
+			name: "state completed at another provider",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				return "other", f.signIn(t, "test")
+			},
+			wantErr: errors.ErrInvalidOIDCState,
+		},
+		{
+			name: "state expired",
+			callback: func(t *testing.T, f *ssoFixture) (string, *entity.OIDCCallbackRequest) {
+				callback := f.signIn(t, "test")
+				f.sso.clock = util.FixedClock(time.Now().Add(ssoTTL + time.Minute))
+				return "test", callback
+			},
+			wantErr: errors.ErrInvalidOIDCState,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSSOFixture(t, ada)
+			provider, callback := tt.callback(t, f)
+			user, err := f.sso.Complete(ctx, provider, callback)
+			if !stderrors.Is(err, tt.wantErr) {
+				t.Fatalf("Complete = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if user.Email != "ada@example.com" || user.FirstName != "Ada" || user.LastName != "Lovelace" || user.EmailVerifiedAt == nil {
+				t.Errorf("signed in %+v", user)
+			}
+		})
+	}
+}
+
+func TestSingleSignOnLinksAccounts(t *testing.T) {
+	ctx := context.Background()
+	verified := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name     string
+		existing *entity.User // account already registered with the email
+		claims   oidctest.User
+		wantErr  error
+		linked   bool // signed in to the existing account
+		password string
+	}{
+		{
+			name:     "verified email links to the account with it",
+			existing: &entity.User{Email: "ada@example.com", Password: "hash", EmailVerifiedAt: &verified},
+			claims:   oidctest.User{Subject: "sub-1", Email: "ADA@example.com", EmailVerified: true},
+			linked:   true,
+			password: "hash",
+		},
+		{
+			name:     "linking an unverified account drops its password",
+			existing: &entity.User{Email: "ada@example.com", Password: "hash"},
+			claims:   oidctest.User{Subject: "sub-1", Email: "ada@example.com", EmailVerified: true},
+			linked:   true,
+			password: "",
+		},
+		{
+			name:     "unverified email links to nothing",
+			existing: &entity.User{Email: "ada@example.com", Password: "hash", EmailVerifiedAt: &verified},
+			claims:   oidctest.User{Subject: "sub-1", Email: "ada@example.com"},
+			wantErr:  errors.ErrOIDCEmailMissing,
+		},
+		{
+			name:    "no email",
+			claims:  oidctest.User{Subject: "sub-1", EmailVerified: true},
+			wantErr: errors.ErrOIDCEmailMissing,
+		},
+		{
+			name:   "verified email of no account creates one",
+			claims: oidctest.User{Subject: "sub-1", Email: "new@example.com", EmailVerified: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newSSOFixture(t, tt.claims)
+			if tt.existing != nil {
+				tt.existing.ID = uuid.New()
+				if err := f.users.Create(ctx, tt.existing); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user, err := f.sso.Complete(ctx, "test", f.signIn(t, "test"))
+			if !stderrors.Is(err, tt.wantErr) {
+				t.Fatalf("Complete = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if linked := tt.existing != nil && user.ID == tt.existing.ID; linked != tt.linked {
+				t.Fatalf("linked to the existing account = %v, want %v", linked, tt.linked)
+			}
+			stored, err := f.users.FindByID(ctx, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.Password != tt.password || stored.EmailVerifiedAt == nil {
+				t.Errorf("account after sign-in has password %q and verified at %v", stored.Password, stored.EmailVerifiedAt)
+			}
+
+			// The subject now maps to the account whatever email it
+			// later reports
+			f.issuer.SetUser(oidctest.User{Subject: tt.claims.Subject, Email: "changed@example.com"})
+			again, err := f.sso.Complete(ctx, "test", f.signIn(t, "test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.ID != user.ID {
+				t.Errorf("second sign-in got account %s, want %s", again.ID, user.ID)
+			}
+		})
+	}
+}
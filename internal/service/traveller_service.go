@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fare multipliers applied to the adult price for each passenger type
+var passengerFareMultipliers = map[string]float64{
+	"adult":  1.0,
+	"child":  0.75,
+	"infant": 0.1,
+}
+
+type TravellerService interface {
+	ListSavedTravellers(ctx context.Context, userID uuid.UUID) ([]entity.SavedTraveller, error)
+	SaveTraveller(ctx context.Context, userID uuid.UUID, details *entity.TravellerDetails) (*entity.SavedTraveller, error)
+	DeleteSavedTraveller(ctx context.Context, userID uuid.UUID, id uint) error
+	PrepareTravellers(ctx context.Context, userID uuid.UUID, req *entity.BookingRequest, travelDate time.Time) ([]entity.Traveller, error)
+}
+
+type travellerService struct {
+	savedTravellerRepo repository.SavedTravellerRepository
+	clock              util.Clock
+}
+
+func NewTravellerService(savedTravellerRepo repository.SavedTravellerRepository, clock util.Clock) TravellerService {
+	return &travellerService{
+		savedTravellerRepo: savedTravellerRepo,
+		clock:              clock,
+	}
+}
+
+func (s *travellerService) ListSavedTravellers(ctx context.Context, userID uuid.UUID) ([]entity.SavedTraveller, error) {
+	return s.savedTravellerRepo.FindByUserID(ctx, userID)
+}
+
+func (s *travellerService) SaveTraveller(ctx context.Context, userID uuid.UUID, details *entity.TravellerDetails) (*entity.SavedTraveller, error) {
+	if err := validatePassport(details, s.clock.Now()); err != nil {
+		return nil, err
+	}
+
+	traveller := &entity.SavedTraveller{
+		UserID:           userID,
+		TravellerDetails: *details,
+	}
+	if err := s.savedTravellerRepo.Create(ctx, traveller); err != nil {
+		return nil, err
+	}
+
+	return traveller, nil
+}
+
+func (s *travellerService) DeleteSavedTraveller(ctx context.Context, userID uuid.UUID, id uint) error {
+	return s.savedTravellerRepo.Delete(ctx, userID, id)
+}
+
+// PrepareTravellers combines the travellers given on a booking request with
+// any referenced saved profiles and validates them against the rules for
+// the booking type. Prices are left for the caller to fill in.
+func (s *travellerService) PrepareTravellers(ctx context.Context, userID uuid.UUID, req *entity.BookingRequest, travelDate time.Time) ([]entity.Traveller, error) {
+	details := append([]entity.TravellerDetails(nil), req.Travellers...)
+
+	if len(req.SavedTravellerIDs) > 0 {
+		saved, err := s.savedTravellerRepo.FindByIDs(ctx, userID, req.SavedTravellerIDs)
+		if err != nil {
+			return nil, err
+		}
+		if len(saved) != len(req.SavedTravellerIDs) {
+			return nil, errors.ErrTravellerNotFound
+		}
+		for _, traveller := range saved {
+			details = append(details, traveller.TravellerDetails)
+		}
+	}
+
+	if len(details) == 0 && req.TravellersOptional {
+		return nil, nil
+	}
+
+	var err error
+	switch req.BookingType {
+	case "flight":
+		err = validateFlightTravellers(details, req.NumGuests, travelDate)
+	case "hotel":
+		err = validateHotelTravellers(details, req.NumGuests, travelDate)
+	default:
+		err = errors.ErrInvalidBookingType
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	travellers := make([]entity.Traveller, len(details))
+	for i, d := range details {
+		travellers[i] = entity.Traveller{TravellerDetails: d}
+	}
+	return travellers, nil
+}
+
+// Every flight passenger needs a date of birth matching their passenger
+// type, and infants fly on an adult's lap so need one adult each
+func validateFlightTravellers(travellers []entity.TravellerDetails, numGuests int, departure time.Time) error {
+	if len(travellers) != numGuests {
+		return errors.ErrTravellerCountMismatch
+	}
+
+	counts := make(map[string]int)
+	for i := range travellers {
+		t := &travellers[i]
+		if t.DateOfBirth == nil {
+			return errors.ErrDateOfBirthRequired
+		}
+		if passengerTypeAt(*t.DateOfBirth, departure) != t.PassengerType {
+			return errors.ErrPassengerTypeMismatch
+		}
+		if err := validatePassport(t, departure); err != nil {
+			return err
+		}
+		counts[t.PassengerType]++
+	}
+
+	if counts["adult"] == 0 {
+		return errors.ErrNoAdultTraveller
+	}
+	if counts["infant"] > counts["adult"] {
+		return errors.ErrTooManyInfants
+	}
+	return nil
+}
+
+// Hotels only need the guests' names, including at least one adult to
+// check in; dates of birth are checked when given
+func validateHotelTravellers(travellers []entity.TravellerDetails, numGuests int, checkIn time.Time) error {
+	if len(travellers) == 0 || len(travellers) > numGuests {
+		return errors.ErrTravellerCountMismatch
+	}
+
+	hasAdult := false
+	for i := range travellers {
+		t := &travellers[i]
+		if t.DateOfBirth != nil && passengerTypeAt(*t.DateOfBirth, checkIn) != t.PassengerType {
+			return errors.ErrPassengerTypeMismatch
+		}
+		if err := validatePassport(t, checkIn); err != nil {
+			return err
+		}
+		if t.PassengerType == "adult" {
+			hasAdult = true
+		}
+	}
+
+	if !hasAdult {
+		return errors.ErrNoAdultTraveller
+	}
+	return nil
+}
+
+func validatePassport(t *entity.TravellerDetails, travelDate time.Time) error {
+	given := 0
+	if t.PassportNumber != "" {
+		given++
+	}
+	if t.PassportCountry != "" {
+		given++
+	}
+	if t.PassportExpiry != nil {
+		given++
+	}
+
+	switch {
+	case given == 0:
+		return nil
+	case given < 3:
+		return errors.ErrIncompletePassport
+	case t.PassportExpiry.Before(travelDate):
+		return errors.ErrPassportExpired
+	}
+	return nil
+}
+
+// passengerTypeAt returns the passenger type for someone born on dob at the
+// given travel date: infants are under 2, children under 12
+func passengerTypeAt(dob, travelDate time.Time) string {
+	switch age := ageAt(dob, travelDate); {
+	case age < 2:
+		return "infant"
+	case age < 12:
+		return "child"
+	default:
+		return "adult"
+	}
+}
+
+func ageAt(dob, date time.Time) int {
+	years := date.Year() - dob.Year()
+	if date.Month() < dob.Month() || (date.Month() == dob.Month() && date.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
+
+// seatsNeeded returns how many fare seats travellers take, as infants fly
+// on an adult's lap. Without travellers every guest is an adult.
+func seatsNeeded(travellers []entity.Traveller, numGuests int) int {
+	if len(travellers) == 0 {
+		return numGuests
+	}
+	seats := 0
+	for _, t := range travellers {
+		if t.PassengerType != "infant" {
+			seats++
+		}
+	}
+	return seats
+}
+
+// passengerFare returns the fare for a passenger type given the adult fare
+func passengerFare(adultFare float64, passengerType string) float64 {
+	multiplier, ok := passengerFareMultipliers[passengerType]
+	if !ok {
+		multiplier = 1.0
+	}
+	return adultFare * multiplier
+}
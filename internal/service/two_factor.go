@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/totp"
+	"fledge-restapi/internal/util"
+	"fledge-restapi/pkg/errors"
+	"strings"
+)
+
+const (
+	recoveryCodeCount = 10
+	// recoveryAlphabet leaves out characters that are easily confused
+	recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// TwoFactor manages users' TOTP authenticators and recovery codes. TOTP
+// secrets are stored encrypted, since they must be read back to check
+// codes; recovery codes only as hashes.
+type TwoFactor struct {
+	userRepo      repository.UserRepository
+	recoveryCodes repository.RecoveryCodeRepository
+	secrets       *util.SecretBox
+	issuer        string // shown as the account's name in authenticator apps
+	clock         util.Clock
+}
+
+func NewTwoFactor(userRepo repository.UserRepository, recoveryCodes repository.RecoveryCodeRepository, secrets *util.SecretBox, issuer string, clock util.Clock) *TwoFactor {
+	return &TwoFactor{
+		userRepo:      userRepo,
+		recoveryCodes: recoveryCodes,
+		secrets:       secrets,
+		issuer:        issuer,
+		clock:         clock,
+	}
+}
+
+// SetUp gives the user a new TOTP secret, which only takes effect once
+// Enable confirms they have added it to their authenticator
+func (f *TwoFactor) SetUp(ctx context.Context, user *entity.User) (*entity.TwoFactorSetupResponse, error) {
+	if user.TwoFactorEnabledAt != nil {
+		return nil, errors.ErrMFAEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := f.secrets.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.userRepo.SetTOTP(ctx, user.ID, sealed, 0, nil); err != nil {
+		return nil, err
+	}
+	user.TOTPSecret, user.TOTPLastStep = sealed, 0
+
+	return &entity.TwoFactorSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(f.issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable turns two-factor authentication on once the user proves their
+// authenticator works with a first code, and returns their recovery codes
+func (f *TwoFactor) Enable(ctx context.Context, user *entity.User, code string) ([]string, error) {
+	if user.TwoFactorEnabledAt != nil {
+		return nil, errors.ErrMFAEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.ErrMFANotSetUp
+	}
+	if err := f.checkTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	// Fails if another setup replaced the secret the code was checked
+	// against
+	now := f.clock.Now()
+	if err := f.userRepo.EnableTOTP(ctx, user.ID, user.TOTPSecret, now); err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabledAt = &now
+	return f.issueRecoveryCodes(ctx, user)
+}
+
+// Disable turns two-factor authentication off, given a current code
+func (f *TwoFactor) Disable(ctx context.Context, user *entity.User, code string) error {
+	if user.TwoFactorEnabledAt == nil {
+		return errors.ErrMFANotSetUp
+	}
+	if err := f.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := f.userRepo.SetTOTP(ctx, user.ID, "", 0, nil); err != nil {
+		return err
+	}
+	user.TOTPSecret, user.TOTPLastStep, user.TwoFactorEnabledAt = "", 0, nil
+	return f.recoveryCodes.DeleteAll(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, given a
+// current authenticator code
+func (f *TwoFactor) RegenerateRecoveryCodes(ctx context.Context, user *entity.User, code string) ([]string, error) {
+	if user.TwoFactorEnabledAt == nil {
+		return nil, errors.ErrMFANotSetUp
+	}
+	if err := f.checkTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return f.issueRecoveryCodes(ctx, user)
+}
+
+// Verify checks a second factor: a code from the user's authenticator or
+// one of their unused recovery codes, which is then used up. It fails
+// with ErrInvalidMFACode.
+func (f *TwoFactor) Verify(ctx context.Context, user *entity.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(strings.ReplaceAll(code, " ", "")) == totp.Digits {
+		return f.checkTOTP(ctx, user, code)
+	}
+	return f.recoveryCodes.Consume(ctx, user.ID, hashRecoveryCode(code), f.clock.Now())
+}
+
+// checkTOTP accepts an authenticator code at most once
+func (f *TwoFactor) checkTOTP(ctx context.Context, user *entity.User, code string) error {
+	secret, err := f.secrets.Open(user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+	step, ok := totp.Validate(secret, code, f.clock.Now())
+	if !ok {
+		return errors.ErrInvalidMFACode
+	}
+	if err := f.userRepo.AdvanceTOTPStep(ctx, user.ID, step); err != nil {
+		return err
+	}
+	user.TOTPLastStep = step
+	return nil
+}
+
+func (f *TwoFactor) issueRecoveryCodes(ctx context.Context, user *entity.User) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	rows := make([]entity.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		rows[i] = entity.RecoveryCode{UserID: user.ID, CodeHash: hashRecoveryCode(code)}
+	}
+	if err := f.recoveryCodes.Replace(ctx, user.ID, rows); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns ten random characters, about 50 bits, as
+// xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := make([]byte, 0, 11)
+	for i, b := range raw {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		// The bias from 256 not dividing evenly is negligible here
+		code = append(code, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+	}
+	return string(code), nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes, however the user
+// types the code. The codes are random enough that a fast hash is safe.
+func hashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+)
+
+func (s *userService) StartOIDCLogin(ctx context.Context, provider string) (*entity.OIDCStartResponse, error) {
+	return s.sso.Start(ctx, provider)
+}
+
+// CompleteOIDCLogin signs in the user the provider identified. Accounts
+// with two-factor authentication still need their second factor.
+func (s *userService) CompleteOIDCLogin(ctx context.Context, provider string, req *entity.OIDCCallbackRequest, client Client) (*entity.LoginResult, error) {
+	user, err := s.sso.Complete(ctx, provider, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeLogin(ctx, user, client)
+}
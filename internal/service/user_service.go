@@ -4,66 +4,330 @@ import (
 	"context"
 	"fledge-restapi/internal/domain/entity"
 	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/mail"
+	"fledge-restapi/internal/password"
 	"fledge-restapi/internal/util"
 	"fledge-restapi/pkg/errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type UserService interface {
 	CreateUser(ctx context.Context, req *entity.SignupRequest) error
-	Login(ctx context.Context, req *entity.LoginRequest) (string, error)
-	GetUserByID(ctx context.Context, id uint) (*entity.User, error)
+	Login(ctx context.Context, req *entity.LoginRequest, client Client) (*entity.LoginResult, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error)
+	UnlockAccount(ctx context.Context, email string) error
+	RequestEmailVerification(ctx context.Context, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, req *entity.ResetPasswordRequest) error
+	CompleteTwoFactorLogin(ctx context.Context, req *entity.TwoFactorLoginRequest, client Client) (string, error)
+	SetUpTwoFactor(ctx context.Context, userID uuid.UUID) (*entity.TwoFactorSetupResponse, error)
+	EnableTwoFactor(ctx context.Context, userID uuid.UUID, code, clientIP string) ([]string, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, code, clientIP string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code, clientIP string) ([]string, error)
+	StartOIDCLogin(ctx context.Context, provider string) (*entity.OIDCStartResponse, error)
+	CompleteOIDCLogin(ctx context.Context, provider string, req *entity.OIDCCallbackRequest, client Client) (*entity.LoginResult, error)
+	ListSessions(ctx context.Context, userID, current uuid.UUID) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID, id uuid.UUID) error
+	RevokeOtherSessions(ctx context.Context, userID, current uuid.UUID) (int64, error)
+}
+
+// AccountPolicy sets the rules for new passwords and how accounts are
+// verified and recovered by email
+type AccountPolicy struct {
+	Passwords            password.Policy
+	RequireVerifiedEmail bool // refuse logins until the email is verified
+	VerificationTTL      time.Duration
+	PasswordResetTTL     time.Duration
+	MFAChallengeTTL      time.Duration // how long a password login waits for its second factor
+	// AppURL is the web app the links in emails open, which posts the
+	// token back to the API
+	AppURL string
+}
+
+// JobQueue runs work after the request that queued it has been answered,
+// reporting false if it can't take any more
+type JobQueue interface {
+	Submit(name string, run func(ctx context.Context) error) bool
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	sessions  *Sessions
+	guard     *LoginGuard
+	tokens    *AccountTokens
+	twoFactor *TwoFactor
+	sso       *SingleSignOn
+	mailer    mail.Mailer
+	jobs      JobQueue
+	policy    AccountPolicy
+	clock     util.Clock
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, sessions *Sessions, guard *LoginGuard, tokens *AccountTokens, twoFactor *TwoFactor, sso *SingleSignOn, mailer mail.Mailer, jobs JobQueue, policy AccountPolicy, clock util.Clock) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		sessions:  sessions,
+		guard:     guard,
+		tokens:    tokens,
+		twoFactor: twoFactor,
+		sso:       sso,
+		mailer:    mailer,
+		jobs:      jobs,
+		policy:    policy,
+		clock:     clock,
 	}
 }
 
+// CreateUser registers a user with their profile. Emails are stored in
+// lower case, so each address can only register once however it's typed.
 func (s *userService) CreateUser(ctx context.Context, req *entity.SignupRequest) error {
-	// Check if user already exists
-	existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
-	if err == nil && existingUser != nil {
-		return errors.ErrEmailAlreadyExists
+	if problem := s.policy.Passwords.Check(req.Password); problem != "" {
+		return &errors.ValidationError{Fields: map[string]string{"password": problem}}
 	}
 
-	// Hash password
 	hashedPassword, err := util.HashPassword(req.Password)
 	if err != nil {
 		return err
 	}
 
 	user := &entity.User{
-		Email:    req.Email,
-		Password: hashedPassword,
+		ID:          uuid.New(),
+		Email:       normalizeEmail(req.Email),
+		Password:    hashedPassword,
+		FirstName:   strings.TrimSpace(req.FirstName),
+		LastName:    strings.TrimSpace(req.LastName),
+		PhoneNumber: req.PhoneNumber,
 	}
 
-	return s.userRepo.Create(ctx, user)
+	// The repository rejects an email that is already registered
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+
+	// The account exists even if the email doesn't go out; the user can
+	// ask for another
+	s.background("signup: sending verification email to user "+user.ID.String(), func(ctx context.Context) error {
+		return s.sendVerification(ctx, user)
+	})
+	return nil
 }
 
-func (s *userService) Login(ctx context.Context, req *entity.LoginRequest) (string, error) {
-	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+// Login checks the email and password from client. Unknown emails take
+// the same path as wrong passwords, including the password hash check, so
+// neither the response nor its timing tells them apart. Accounts with
+// two-factor authentication get a challenge to complete with
+// CompleteTwoFactorLogin instead of an access token.
+func (s *userService) Login(ctx context.Context, req *entity.LoginRequest, client Client) (*entity.LoginResult, error) {
+	attempt, err := s.guard.Reserve(ctx, req.Email, client.IP)
 	if err != nil {
-		return "", errors.ErrInvalidCredentials
+		return nil, err
 	}
 
-	if err := util.CheckPassword(req.Password, user.Password); err != nil {
-		return "", errors.ErrInvalidCredentials
+	user, err := s.userRepo.FindByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		user = nil
+		util.CheckNoPassword(req.Password)
+	} else {
+		err = util.CheckPassword(user.Password, req.Password)
+	}
+	if err != nil {
+		attempt.Failed(ctx, user)
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	// Only said once the password is right, so it reveals nothing about
+	// the account to anyone else
+	if s.policy.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		if err := attempt.Passed(ctx); err != nil {
+			return nil, err
+		}
+		return nil, errors.ErrEmailNotVerified
 	}
 
-	// Generate JWT token
-	token, err := util.GenerateJWT(user.ID, user.Email)
+	// With two-factor authentication the failures aren't forgiven until
+	// the second factor is given too, so knowing the password doesn't
+	// allow unlimited guesses at codes
+	if user.TwoFactorEnabledAt == nil {
+		err = attempt.Succeeded(ctx)
+	} else {
+		err = attempt.Passed(ctx)
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return s.completeLogin(ctx, user, client)
+}
 
-	return token, nil
+// completeLogin starts a session on client for a user who has proved who
+// they are, or issues a challenge for their second factor if they have one
+func (s *userService) completeLogin(ctx context.Context, user *entity.User, client Client) (*entity.LoginResult, error) {
+	if user.TwoFactorEnabledAt != nil {
+		challenge, err := s.tokens.Issue(ctx, user.ID, purposeMFAChallenge, s.policy.MFAChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &entity.LoginResult{MFARequired: true, MFAToken: challenge}, nil
+	}
+
+	token, err := s.sessions.Start(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.LoginResult{Token: token}, nil
 }
 
-func (s *userService) GetUserByID(ctx context.Context, id uint) (*entity.User, error) {
+func (s *userService) GetUserByID(ctx context.Context, id uuid.UUID) (*entity.User, error) {
 	return s.userRepo.FindByID(ctx, id)
 }
+
+// UnlockAccount lifts a login lockout early
+func (s *userService) UnlockAccount(ctx context.Context, email string) error {
+	return s.guard.Unlock(ctx, email)
+}
+
+// RequestEmailVerification emails a new verification link if the email
+// belongs to an unverified account. It succeeds at once either way, doing
+// the work in the background, so neither the response nor its timing can
+// be used to find out which emails are registered.
+func (s *userService) RequestEmailVerification(ctx context.Context, email string) error {
+	s.background("verification request", func(ctx context.Context) error {
+		user, err := s.userRepo.FindByEmail(ctx, normalizeEmail(email))
+		if err != nil || user.EmailVerifiedAt != nil {
+			return nil
+		}
+		return s.sendVerification(ctx, user)
+	})
+	return nil
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, token string) error {
+	redeemed, err := s.tokens.Redeem(ctx, token, purposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.FindByID(ctx, redeemed.UserID)
+	if err != nil {
+		return errors.ErrInvalidEmailToken
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return s.userRepo.MarkEmailVerified(ctx, user.ID, s.clock.Now())
+}
+
+// RequestPasswordReset emails a password reset link if the email belongs
+// to an account, succeeding at once either way like
+// RequestEmailVerification
+func (s *userService) RequestPasswordReset(ctx context.Context, email string) error {
+	s.background("password reset request", func(ctx context.Context) error {
+		user, err := s.userRepo.FindByEmail(ctx, normalizeEmail(email))
+		if err != nil {
+			return nil
+		}
+
+		token, err := s.tokens.Issue(ctx, user.ID, purposeResetPassword, s.policy.PasswordResetTTL)
+		if err != nil {
+			return err
+		}
+		return s.send(ctx, user.Email, "Reset your Fledge password", fmt.Sprintf(
+			"Someone asked to reset the password for your Fledge account.\n\n"+
+				"To choose a new password, open this link within %s:\n\n%s\n\n"+
+				"If it wasn't you, ignore this email; your password hasn't changed.\n",
+			describeDuration(s.policy.PasswordResetTTL), s.link("reset-password", token)))
+	})
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token. Receiving the
+// token proves the user owns their email, so it is marked verified, and
+// any login lockout is lifted. Whoever knew the old password is signed
+// out everywhere.
+func (s *userService) ResetPassword(ctx context.Context, req *entity.ResetPasswordRequest) error {
+	if problem := s.policy.Passwords.Check(req.Password); problem != "" {
+		return &errors.ValidationError{Fields: map[string]string{"password": problem}}
+	}
+
+	redeemed, err := s.tokens.Redeem(ctx, req.Token, purposeResetPassword)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.FindByID(ctx, redeemed.UserID)
+	if err != nil {
+		return errors.ErrInvalidEmailToken
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.SetPassword(ctx, user.ID, hashedPassword, s.clock.Now()); err != nil {
+		return err
+	}
+
+	// Other links sent before this one must not change the password again
+	if err := s.tokens.Revoke(ctx, user.ID, purposeResetPassword); err != nil {
+		return err
+	}
+	if err := s.sessions.RevokeAll(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.guard.Unlock(ctx, user.Email)
+}
+
+func (s *userService) sendVerification(ctx context.Context, user *entity.User) error {
+	token, err := s.tokens.Issue(ctx, user.ID, purposeVerifyEmail, s.policy.VerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.send(ctx, user.Email, "Verify your Fledge email address", fmt.Sprintf(
+		"Welcome to Fledge!\n\n"+
+			"To confirm this is your email address, open this link within %s:\n\n%s\n\n"+
+			"If you didn't sign up, ignore this email.\n",
+		describeDuration(s.policy.VerificationTTL), s.link("verify-email", token)))
+}
+
+func (s *userService) send(ctx context.Context, to, subject, body string) error {
+	if err := s.mailer.Send(ctx, mail.Message{To: to, Subject: subject, Body: body}); err != nil {
+		return fmt.Errorf("mail: sending %q: %w", subject, err)
+	}
+	return nil
+}
+
+// background queues work to run once the request has been answered, so a
+// slow database or mail server doesn't hold up the request and how long a
+// request takes doesn't show whether there was an account to email. Work
+// is dropped if the queue is full; the user can ask again.
+func (s *userService) background(what string, work func(ctx context.Context) error) {
+	if !s.jobs.Submit(what, work) {
+		log.Printf("%s: job queue full, dropped", what)
+	}
+}
+
+// link is the web app page that redeems token
+func (s *userService) link(page, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimRight(s.policy.AppURL, "/"), page, url.QueryEscape(token))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// describeDuration writes d in whole hours or minutes for an email
+func describeDuration(d time.Duration) string {
+	n, unit := int(d/time.Minute), "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unit = int(d/time.Hour), "hour"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/internal/domain/repository"
+	"fledge-restapi/internal/domain/repository/memory"
+	"fledge-restapi/internal/mail"
+	"fledge-restapi/internal/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// gatedUsers holds up email lookups until open is closed
+type gatedUsers struct {
+	repository.UserRepository
+	open chan struct{}
+}
+
+func (u *gatedUsers) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	<-u.open
+	return u.UserRepository.FindByEmail(ctx, email)
+}
+
+// goJobs runs each job in its own goroutine
+type goJobs struct{}
+
+func (goJobs) Submit(name string, run func(ctx context.Context) error) bool {
+	go run(context.Background())
+	return true
+}
+
+type chanMailer chan mail.Message
+
+func (m chanMailer) Send(ctx context.Context, msg mail.Message) error {
+	m <- msg
+	return nil
+}
+
+func TestAccountEmailRequestsAnswerBeforeLookingUpTheEmail(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_800_000_000, 0)
+
+	tests := []struct {
+		name    string
+		request func(s *userService, email string) error
+		subject string
+	}{
+		{
+			name:    "password reset",
+			request: func(s *userService, email string) error { return s.RequestPasswordReset(ctx, email) },
+			subject: "Reset your Fledge password",
+		},
+		{
+			name:    "email verification",
+			request: func(s *userService, email string) error { return s.RequestEmailVerification(ctx, email) },
+			subject: "Verify your Fledge email address",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.NewStore()
+			users := &gatedUsers{UserRepository: memory.NewUserRepository(store), open: make(chan struct{})}
+			if err := users.Create(ctx, &entity.User{ID: uuid.New(), Email: "known@example.com"}); err != nil {
+				t.Fatal(err)
+			}
+			mailer := make(chanMailer, 2)
+			s := &userService{
+				userRepo: users,
+				tokens:   NewAccountTokens(memory.NewUserTokenRepository(store), "secret", util.FixedClock(now)),
+				mailer:   mailer,
+				jobs:     goJobs{},
+				policy:   AccountPolicy{VerificationTTL: time.Hour, PasswordResetTTL: time.Hour, AppURL: "https://app.example"},
+				clock:    util.FixedClock(now),
+			}
+
+			// Both return while the lookups are still held up
+			for _, email := range []string{"known@example.com", "unknown@example.com"} {
+				done := make(chan error, 1)
+				go func() { done <- tt.request(s, email) }()
+				select {
+				case err := <-done:
+					if err != nil {
+						t.Fatalf("%s: %v", email, err)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatalf("%s: request waited for the account lookup", email)
+				}
+			}
+
+			close(users.open)
+			select {
+			case msg := <-mailer:
+				if msg.To != "known@example.com" || msg.Subject != tt.subject || !strings.Contains(msg.Body, "https://app.example/") {
+					t.Errorf("sent %+v", msg)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("no email sent to the registered address")
+			}
+			select {
+			case msg := <-mailer:
+				t.Errorf("sent %q to %s, which isn't registered", msg.Subject, msg.To)
+			case <-time.After(100 * time.Millisecond):
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+
+	"github.com/google/uuid"
+)
+
+func (s *userService) ListSessions(ctx context.Context, userID, current uuid.UUID) ([]entity.Session, error) {
+	return s.sessions.List(ctx, userID, current)
+}
+
+func (s *userService) RevokeSession(ctx context.Context, userID, id uuid.UUID) error {
+	return s.sessions.Revoke(ctx, userID, id)
+}
+
+func (s *userService) RevokeOtherSessions(ctx context.Context, userID, current uuid.UUID) (int64, error) {
+	return s.sessions.RevokeOthers(ctx, userID, current)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fledge-restapi/internal/domain/entity"
+	"fledge-restapi/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+// CompleteTwoFactorLogin exchanges the challenge from Login and a second
+// factor for an access token. Wrong codes count as failed logins, so they
+// are slowed down and locked out like wrong passwords; the challenge stays
+// usable until it expires or succeeds.
+func (s *userService) CompleteTwoFactorLogin(ctx context.Context, req *entity.TwoFactorLoginRequest, client Client) (string, error) {
+	challenge, err := s.tokens.Check(ctx, req.MFAToken, purposeMFAChallenge)
+	if err != nil {
+		return "", errors.ErrInvalidMFAToken
+	}
+	user, err := s.userRepo.FindByID(ctx, challenge.UserID)
+	if err != nil || user.TwoFactorEnabledAt == nil {
+		return "", errors.ErrInvalidMFAToken
+	}
+
+	if err := s.checkSecondFactor(ctx, user, client.IP, func() error {
+		return s.twoFactor.Verify(ctx, user, req.Code)
+	}); err != nil {
+		return "", err
+	}
+
+	// Another request may have used the challenge in the meantime
+	if _, err := s.tokens.Redeem(ctx, req.MFAToken, purposeMFAChallenge); err != nil {
+		return "", errors.ErrInvalidMFAToken
+	}
+	if err := s.guard.Succeeded(ctx, user.Email); err != nil {
+		return "", err
+	}
+	return s.sessions.Start(ctx, user, client)
+}
+
+func (s *userService) SetUpTwoFactor(ctx context.Context, userID uuid.UUID) (*entity.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+	return s.twoFactor.SetUp(ctx, user)
+}
+
+func (s *userService) EnableTwoFactor(ctx context.Context, userID uuid.UUID, code, clientIP string) ([]string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	var codes []string
+	err = s.checkSecondFactor(ctx, user, clientIP, func() (err error) {
+		codes, err = s.twoFactor.Enable(ctx, user, code)
+		return err
+	})
+	return codes, err
+}
+
+func (s *userService) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code, clientIP string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return errors.ErrUserNotFound
+	}
+
+	return s.checkSecondFactor(ctx, user, clientIP, func() error {
+		return s.twoFactor.Disable(ctx, user, code)
+	})
+}
+
+func (s *userService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code, clientIP string) ([]string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.ErrUserNotFound
+	}
+
+	var codes []string
+	err = s.checkSecondFactor(ctx, user, clientIP, func() (err error) {
+		codes, err = s.twoFactor.RegenerateRecoveryCodes(ctx, user, code)
+		return err
+	})
+	return codes, err
+}
+
+// checkSecondFactor runs check, which verifies a code, under the login
+// guard: refused while the account or IP is throttled, and a wrong code
+// counts as a failed login. Anything else takes the attempt back, leaving
+// forgiving earlier failures to the caller.
+func (s *userService) checkSecondFactor(ctx context.Context, user *entity.User, clientIP string, check func() error) error {
+	attempt, err := s.guard.Reserve(ctx, user.Email, clientIP)
+	if err != nil {
+		return err
+	}
+
+	err = check()
+	if err == errors.ErrInvalidMFACode {
+		attempt.Failed(ctx, user)
+		return err
+	}
+	if passErr := attempt.Passed(ctx); passErr != nil && err == nil {
+		return passErr
+	}
+	return err
+}
@@ -0,0 +1,23 @@
+package util
+
+import "time"
+
+// Clock tells the current time. Anything whose result depends on the time
+// takes a Clock so it can be evaluated deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always returns the same instant
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
@@ -1,42 +1,96 @@
 package util
 
 import (
-	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// clockSkew is tolerated between instances when checking token times
+const clockSkew = 30 * time.Second
+
 type JWTClaim struct {
-	UserID uuid.UUID
-	Email  string
+	UserID    uuid.UUID
+	SessionID uuid.UUID `json:"sid"` // the session the token was issued to
+	Email     string
+	Role      string
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(userID uuid.UUID, email string) (string, error) {
+// JWTManager signs and validates access tokens with a KeySet. Tokens carry
+// the kid of the key that signed them and are only accepted with that
+// key's algorithm, our issuer and our audience.
+type JWTManager struct {
+	keys     atomic.Pointer[KeySet]
+	issuer   string
+	audience string
+	ttl      time.Duration
+}
+
+func NewJWTManager(keys *KeySet, issuer, audience string, ttl time.Duration) *JWTManager {
+	m := &JWTManager{
+		issuer:   issuer,
+		audience: audience,
+		ttl:      ttl,
+	}
+	m.keys.Store(keys)
+	return m
+}
+
+// Keys returns the keys in use
+func (m *JWTManager) Keys() *KeySet {
+	return m.keys.Load()
+}
+
+// SetKeys swaps in a new key set, such as after a rotation. Tokens signed
+// by keys left out of it stop validating.
+func (m *JWTManager) SetKeys(keys *KeySet) {
+	m.keys.Store(keys)
+}
+
+// TTL is how long access tokens last
+func (m *JWTManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Generate signs an access token issued at now, so it expires together with
+// the session started at the same instant
+func (m *JWTManager) Generate(userID, sessionID uuid.UUID, email, role string, now time.Time) (string, error) {
 	claims := JWTClaim{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		SessionID: sessionID,
+		Email:     email,
+		Role:      role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    m.issuer,
+			Audience:  jwt.ClaimStrings{m.audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(m.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+	return m.keys.Load().sign(claims)
 }
 
-func ValidateJWT(tokenString string) (*JWTClaim, error) {
+func (m *JWTManager) Validate(tokenString string) (*JWTClaim, error) {
 	claims := &JWTClaim{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET_KEY")), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.keys.Load().verificationKey,
+		jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(m.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
 
-	if err != nil || !token.Valid {
+	if err != nil {
 		return nil, err
 	}
+	if !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
 
 	return claims, nil
 }
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateUsesTheGivenTime(t *testing.T) {
+	keys, err := NewEphemeralKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewJWTManager(keys, "fledge", "fledge-api", 15*time.Minute)
+
+	now := time.Now().Add(-5 * time.Minute).Truncate(time.Second)
+	token, err := m.Generate(uuid.New(), uuid.New(), "a@example.com", "user", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := m.Validate(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.IssuedAt.Time.Equal(now) {
+		t.Errorf("iat = %v, want %v", claims.IssuedAt.Time, now)
+	}
+	if want := now.Add(m.TTL()); !claims.ExpiresAt.Time.Equal(want) {
+		t.Errorf("exp = %v, want %v", claims.ExpiresAt.Time, want)
+	}
+}
@@ -0,0 +1,240 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"fledge-restapi/internal/jwk"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signing algorithms access tokens may use. Symmetric algorithms are left
+// out so a leaked verification key can't mint tokens.
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// minRSABits is the smallest RSA key accepted
+const minRSABits = 2048
+
+var keyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// KeySet holds the keys access tokens are signed and verified with. One
+// private key signs; every key in the set, including public keys of
+// retired signing keys, verifies tokens naming it by kid. Rotating is
+// adding the next key, switching signing to it once every instance has
+// it, and removing the old one when its tokens have expired.
+type KeySet struct {
+	signingID string
+	signer    crypto.Signer
+	keys      map[string]verificationKey
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// LoadKeySet reads every <kid>.pem file in dir: PKCS#8 or PKCS#1 private
+// keys, or PKIX public keys that only verify. signingID picks the key that
+// signs; if it is empty the private key whose ID sorts last does, which
+// is the newest for IDs from NewKeyID.
+func LoadKeySet(dir, signingID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	set := &KeySet{keys: make(map[string]verificationKey)}
+	signers := make(map[string]crypto.Signer)
+	newest := ""
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if !keyIDPattern.MatchString(kid) {
+			return nil, fmt.Errorf("%s: key IDs may only use letters, digits, dots, dashes and underscores", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		public, signer, err := parseKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		method, err := signingMethod(public)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		set.keys[kid] = verificationKey{method: method, public: public}
+		if signer != nil {
+			signers[kid] = signer
+			newest = kid // paths are sorted
+		}
+	}
+
+	if signingID == "" {
+		if newest == "" {
+			return nil, fmt.Errorf("%s has no private keys", dir)
+		}
+		signingID = newest
+	}
+	signer, ok := signers[signingID]
+	if !ok {
+		return nil, fmt.Errorf("%s has no private key %q", dir, signingID)
+	}
+	set.signingID, set.signer = signingID, signer
+	return set, nil
+}
+
+// NewEphemeralKeySet returns a set with one new Ed25519 key, for running
+// without configured keys. Its tokens stop verifying when the process
+// exits, and other instances can't verify them.
+func NewEphemeralKeySet() (*KeySet, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := "ephemeral-" + time.Now().UTC().Format("20060102150405")
+	return &KeySet{
+		signingID: kid,
+		signer:    private,
+		keys:      map[string]verificationKey{kid: {method: jwt.SigningMethodEdDSA, public: public}},
+	}, nil
+}
+
+// SigningID is the kid of the key that signs
+func (s *KeySet) SigningID() string {
+	return s.signingID
+}
+
+// JWKS lists the public keys, for publishing to services that verify our
+// tokens
+func (s *KeySet) JWKS() jwk.Set {
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := jwk.Set{Keys: make([]jwk.Key, 0, len(kids))}
+	for _, kid := range kids {
+		key := s.keys[kid]
+		// Every key was checked to be a supported type when loaded
+		if k, err := jwk.New(kid, key.method.Alg(), key.public); err == nil {
+			set.Keys = append(set.Keys, k)
+		}
+	}
+	return set
+}
+
+func (s *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.keys[s.signingID].method, claims)
+	token.Header["kid"] = s.signingID
+	return token.SignedString(s.signer)
+}
+
+// verificationKey returns the public key a token names, refusing tokens
+// whose algorithm isn't the one that key signs with
+func (s *KeySet) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("key %q signs with %s, not %s", kid, key.method.Alg(), token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// GenerateKeyPEM creates a signing key for alg, RS256 or EdDSA, encoded as
+// a PKCS#8 PEM block
+func GenerateKeyPEM(alg string) ([]byte, error) {
+	var private interface{}
+	var err error
+	switch alg {
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 3072)
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q; use %s or %s", alg, AlgRS256, AlgEdDSA)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// NewKeyID returns a key ID that sorts by creation time
+func NewKeyID() (string, error) {
+	raw := make([]byte, 3)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(raw), nil
+}
+
+// parseKeyPEM returns the public key in a PEM file, and the private key
+// if it holds one
+func parseKeyPEM(data []byte) (crypto.PublicKey, crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if signer, ok := parsed.(crypto.Signer); ok {
+		return signer.Public(), signer, nil
+	}
+	return parsed, nil, nil
+}
+
+// signingMethod is the only algorithm a key may be used with
+func signingMethod(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA keys must be at least %d bits", minRSABits)
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T; use RSA or Ed25519", public)
+}
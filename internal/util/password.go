@@ -1,6 +1,8 @@
 package util
 
 import (
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,3 +17,17 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(hashedPassword, plainPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(plainPassword))
 }
+
+// dummyHash is a hash at the same cost as real ones, for checks that have
+// no real hash to compare against
+var dummyHash = sync.OnceValue(func() string {
+	hashed, _ := HashPassword("fledge-dummy-password")
+	return hashed
+})
+
+// CheckNoPassword takes as long as CheckPassword but always fails. Use it
+// when there is no account, so response times don't reveal which emails
+// are registered.
+func CheckNoPassword(plainPassword string) {
+	_ = CheckPassword(dummyHash(), plainPassword)
+}
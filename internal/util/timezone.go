@@ -0,0 +1,54 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	locationMu    sync.RWMutex
+	locationCache = make(map[string]*time.Location)
+)
+
+// LoadLocation returns the IANA timezone with the given name, caching the
+// result. An empty name resolves to UTC.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	locationMu.RLock()
+	loc, ok := locationCache[name]
+	locationMu.RUnlock()
+	if ok {
+		return loc, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locationMu.Lock()
+	locationCache[name] = loc
+	locationMu.Unlock()
+
+	return loc, nil
+}
+
+// LocalDayBounds returns the instants at which the calendar date of t, as the
+// client wrote it, starts and ends in loc. Days are 23 or 25 hours long
+// across DST transitions.
+func LocalDayBounds(t time.Time, loc *time.Location) (time.Time, time.Time) {
+	y, m, d := t.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	return start.UTC(), end.UTC()
+}
+
+// CalendarDayWindow returns a UTC window wide enough to contain the calendar
+// date of t in every timezone, from UTC+14 to UTC-12.
+func CalendarDayWindow(t time.Time) (time.Time, time.Time) {
+	start, end := LocalDayBounds(t, time.UTC)
+	return start.Add(-14 * time.Hour), end.Add(12 * time.Hour)
+}
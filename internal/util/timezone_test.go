@@ -0,0 +1,115 @@
+package util
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestLocalDayBounds(t *testing.T) {
+	tests := []struct {
+		name       string
+		date       time.Time
+		zone       string
+		start, end string
+		hours      float64
+	}{
+		{
+			name:  "ordinary day",
+			date:  time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC),
+			zone:  "America/New_York",
+			start: "2026-06-15T04:00:00Z",
+			end:   "2026-06-16T04:00:00Z",
+			hours: 24,
+		},
+		{
+			name:  "New York springs forward",
+			date:  time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+			zone:  "America/New_York",
+			start: "2026-03-08T05:00:00Z",
+			end:   "2026-03-09T04:00:00Z",
+			hours: 23,
+		},
+		{
+			name:  "New York falls back",
+			date:  time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+			zone:  "America/New_York",
+			start: "2026-11-01T04:00:00Z",
+			end:   "2026-11-02T05:00:00Z",
+			hours: 25,
+		},
+		{
+			name:  "London springs forward",
+			date:  time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC),
+			zone:  "Europe/London",
+			start: "2026-03-29T00:00:00Z",
+			end:   "2026-03-29T23:00:00Z",
+			hours: 23,
+		},
+		{
+			name:  "London falls back",
+			date:  time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+			zone:  "Europe/London",
+			start: "2026-10-24T23:00:00Z",
+			end:   "2026-10-26T00:00:00Z",
+			hours: 25,
+		},
+		{
+			name:  "date written with an offset keeps its calendar day",
+			date:  time.Date(2026, 3, 8, 23, 30, 0, 0, time.FixedZone("EST", -5*60*60)),
+			zone:  "America/New_York",
+			start: "2026-03-08T05:00:00Z",
+			end:   "2026-03-09T04:00:00Z",
+			hours: 23,
+		},
+		{
+			name:  "empty zone is UTC",
+			date:  time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC),
+			start: "2026-03-08T00:00:00Z",
+			end:   "2026-03-09T00:00:00Z",
+			hours: 24,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatalf("LoadLocation(%q): %v", tt.zone, err)
+			}
+			start, end := LocalDayBounds(tt.date, loc)
+			if got := start.Format(time.RFC3339); got != tt.start {
+				t.Errorf("start = %s, want %s", got, tt.start)
+			}
+			if got := end.Format(time.RFC3339); got != tt.end {
+				t.Errorf("end = %s, want %s", got, tt.end)
+			}
+			if got := end.Sub(start).Hours(); got != tt.hours {
+				t.Errorf("day is %v hours long, want %v", got, tt.hours)
+			}
+		})
+	}
+}
+
+func TestCalendarDayWindow(t *testing.T) {
+	date := time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)
+	start, end := CalendarDayWindow(date)
+
+	// The window must hold the local day in the furthest zones either way
+	for _, zone := range []string{"Pacific/Kiritimati", "Etc/GMT+12", "America/New_York", "Europe/London"} {
+		loc, err := LoadLocation(zone)
+		if err != nil {
+			t.Fatalf("LoadLocation(%q): %v", zone, err)
+		}
+		dayStart, dayEnd := LocalDayBounds(date, loc)
+		if dayStart.Before(start) || dayEnd.After(end) {
+			t.Errorf("%s day %s–%s falls outside window %s–%s", zone, dayStart, dayEnd, start, end)
+		}
+	}
+}
+
+func TestLoadLocationUnknown(t *testing.T) {
+	if _, err := LoadLocation("Mars/Olympus_Mons"); err == nil {
+		t.Fatal("expected an error for an unknown timezone")
+	}
+}
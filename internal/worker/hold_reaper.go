@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"fledge-restapi/internal/service"
+	"log"
+	"time"
+)
+
+// HoldReaper periodically expires unpaid booking holds and returns their
+// seats and rooms to inventory until its context is cancelled
+type HoldReaper struct {
+	bookingService *service.BookingService
+	interval       time.Duration
+}
+
+func NewHoldReaper(bookingService *service.BookingService, interval time.Duration) *HoldReaper {
+	return &HoldReaper{
+		bookingService: bookingService,
+		interval:       interval,
+	}
+}
+
+func (w *HoldReaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := w.bookingService.ReleaseExpiredHolds(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Releasing expired holds failed: %v", err)
+			}
+			if released > 0 {
+				log.Printf("Released %d expired booking holds", released)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// JobQueue runs short jobs, such as sending account emails, after the
+// request that queued them has been answered. It holds at most a fixed
+// number of jobs and runs a fixed number at once, so a flood of requests
+// can't start unlimited work. Once its context is cancelled it takes no
+// more jobs and finishes the queued ones, giving them up to the drain
+// timeout before their context is cancelled too.
+type JobQueue struct {
+	jobs    chan job
+	workers int
+	drain   time.Duration
+
+	mu     sync.Mutex
+	closed bool
+}
+
+type job struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func NewJobQueue(size, workers int, drain time.Duration) *JobQueue {
+	return &JobQueue{
+		jobs:    make(chan job, size),
+		workers: workers,
+		drain:   drain,
+	}
+}
+
+// Submit queues run, reporting false if the queue is full or stopped. Errors
+// from run are logged with name.
+func (q *JobQueue) Submit(name string, run func(ctx context.Context) error) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return false
+	}
+	select {
+	case q.jobs <- job{name: name, run: run}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *JobQueue) Run(ctx context.Context) {
+	// Jobs keep running past the cancellation until the queue has drained
+	// or the drain timeout passes
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+	stopDrain := context.AfterFunc(ctx, func() {
+		q.mu.Lock()
+		q.closed = true
+		close(q.jobs)
+		q.mu.Unlock()
+		time.AfterFunc(q.drain, cancelJobs)
+	})
+	defer stopDrain()
+
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range q.jobs {
+				if err := job.run(jobCtx); err != nil {
+					log.Printf("%s: %v", job.name, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
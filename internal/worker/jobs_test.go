@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJobQueueRefusesJobsWhenFull(t *testing.T) {
+	q := NewJobQueue(2, 1, time.Second)
+	noop := func(context.Context) error { return nil }
+
+	// Nothing runs the queue yet, so it fills up
+	for i, want := range []bool{true, true, false} {
+		if got := q.Submit("job", noop); got != want {
+			t.Errorf("Submit %d = %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestJobQueueDrainsOnCancel(t *testing.T) {
+	q := NewJobQueue(10, 2, 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	release := make(chan struct{})
+	var finished, cancelled atomic.Int32
+	for i := 0; i < 6; i++ {
+		q.Submit("job", func(ctx context.Context) error {
+			<-release
+			if ctx.Err() != nil {
+				cancelled.Add(1)
+			}
+			finished.Add(1)
+			return nil
+		})
+	}
+
+	cancel()
+	// Stopped queues take no more jobs
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Submit("late", func(context.Context) error { return nil }) {
+		if time.Now().After(deadline) {
+			t.Fatal("queue still takes jobs after its context was cancelled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("Run returned with jobs still queued")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return once the queue drained")
+	}
+	if finished.Load() != 6 || cancelled.Load() != 0 {
+		t.Errorf("%d jobs finished, %d with a cancelled context; want 6 and 0", finished.Load(), cancelled.Load())
+	}
+}
+
+func TestJobQueueCancelsJobsAfterDrainTimeout(t *testing.T) {
+	const drain = 100 * time.Millisecond
+	q := NewJobQueue(10, 1, drain)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	started := make(chan struct{})
+	q.Submit("stuck", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+
+	start := time.Now()
+	cancel()
+	select {
+	case <-done:
+		if elapsed := time.Since(start); elapsed < drain {
+			t.Errorf("stuck job cancelled after %s, before the %s drain timeout", elapsed, drain)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stuck job kept Run from returning past the drain timeout")
+	}
+}
@@ -0,0 +1,38 @@
+package worker
+
+import (
+	"context"
+	"fledge-restapi/internal/service"
+	"log"
+	"time"
+)
+
+// PriceAlertEvaluator periodically re-runs saved searches and raises price
+// alerts until its context is cancelled
+type PriceAlertEvaluator struct {
+	savedSearchService service.SavedSearchService
+	interval           time.Duration
+}
+
+func NewPriceAlertEvaluator(savedSearchService service.SavedSearchService, interval time.Duration) *PriceAlertEvaluator {
+	return &PriceAlertEvaluator{
+		savedSearchService: savedSearchService,
+		interval:           interval,
+	}
+}
+
+func (w *PriceAlertEvaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.savedSearchService.EvaluateDue(ctx, w.interval); err != nil && ctx.Err() == nil {
+				log.Printf("Price alert evaluation failed: %v", err)
+			}
+		}
+	}
+}
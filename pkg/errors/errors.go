@@ -1,19 +1,49 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"sort"
+	"strings"
+)
 
 var (
 	// Authentication errors
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrEmailAlreadyExists  = errors.New("email already exists")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrLoginThrottled      = errors.New("too many failed login attempts, try again later")
+	ErrEmailNotVerified    = errors.New("email address has not been verified")
+	ErrInvalidEmailToken   = errors.New("invalid or expired token")
+	ErrInvalidMFAToken     = errors.New("invalid or expired login challenge")
+	ErrInvalidMFACode      = errors.New("invalid authentication code")
+	ErrMFAEnabled          = errors.New("two-factor authentication is already enabled")
+	ErrMFANotSetUp         = errors.New("two-factor authentication has not been set up")
+	ErrProviderNotFound    = errors.New("unknown identity provider")
+	ErrInvalidOIDCState    = errors.New("invalid or expired sign-in attempt")
+	ErrOIDCLoginFailed     = errors.New("sign-in with the identity provider failed")
+	ErrProviderUnavailable = errors.New("identity provider unavailable")
+	ErrOIDCEmailMissing    = errors.New("identity provider did not confirm an email address")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has expired or been revoked")
+	ErrInvalidAPIKey       = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyScope         = errors.New("API key does not allow this operation")
+	ErrAPIKeyNotFound      = errors.New("API key not found")
 
 	// Flight errors
 	ErrFlightNotFound       = errors.New("flight not found")
 	ErrInvalidDepartureDate = errors.New("departure date must be in the future")
 	ErrInvalidReturnDate    = errors.New("return date must be after departure date")
 	ErrInsufficientSeats    = errors.New("insufficient seats available")
+	ErrFareNotFound         = errors.New("fare class not found")
+
+	// Seat errors
+	ErrSeatMapNotFound   = errors.New("flight has no seat map")
+	ErrSeatMapExists     = errors.New("flight already has a seat map")
+	ErrInvalidSeatLayout = errors.New("invalid seat layout")
+	ErrSeatNotFound      = errors.New("seat not found")
+	ErrSeatUnavailable   = errors.New("seat is not available")
+	ErrSeatCountMismatch = errors.New("number of seats does not match the guests needing one; infants sit on a lap")
 
 	// Hotel errors
 	ErrHotelNotFound       = errors.New("hotel not found")
@@ -27,6 +57,31 @@ var (
 	ErrInvalidBookingType   = errors.New("invalid booking type")
 	ErrBookingCancelled     = errors.New("booking already cancelled")
 	ErrInvalidBookingStatus = errors.New("invalid booking status")
+	ErrBookingAccessDenied  = errors.New("unauthorized access to booking")
+	ErrBookingNotUpdatable  = errors.New("cannot update cancelled booking")
+	ErrFieldNotUpdatable    = errors.New("only special_requests can be updated")
+	ErrCancellationClosed   = errors.New("booking cannot be cancelled within 24 hours of start date")
+	ErrBookingNotHeld       = errors.New("booking is not awaiting payment")
+	ErrHoldExpired          = errors.New("booking hold has expired")
+
+	// Traveller errors
+	ErrTravellerNotFound      = errors.New("traveller not found")
+	ErrTravellerCountMismatch = errors.New("number of travellers does not match number of guests")
+	ErrDateOfBirthRequired    = errors.New("date of birth is required for every flight passenger")
+	ErrPassengerTypeMismatch  = errors.New("passenger type does not match age at travel date")
+	ErrNoAdultTraveller       = errors.New("at least one adult traveller is required")
+	ErrTooManyInfants         = errors.New("each infant must travel with an adult")
+	ErrIncompletePassport     = errors.New("passport number, country and expiry must be given together")
+	ErrPassportExpired        = errors.New("passport expires before the travel date")
+
+	// Pricing errors
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+	ErrInvalidPricingRule  = errors.New("invalid pricing rule")
+
+	// Saved search errors
+	ErrSavedSearchNotFound   = errors.New("saved search not found")
+	ErrMissingSearchCriteria = errors.New("search criteria are required for the search type")
+	ErrPriceAlertNotFound    = errors.New("price alert not found")
 
 	// Payment errors
 	ErrPaymentFailed        = errors.New("payment failed")
@@ -41,7 +96,32 @@ var (
 
 // ErrorResponse represents the error response structure
 type ErrorResponse struct {
-	Error       string `json:"error"`
-	Code        string `json:"code,omitempty"`
-	Description string `json:"description,omitempty"`
+	Error       string            `json:"error"`
+	Code        string            `json:"code,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty"` // what is wrong with each invalid field
+}
+
+// ValidationError says what is wrong with each invalid field of a request,
+// keyed by the field's JSON name
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = name + " " + e.Fields[name]
+	}
+	return ErrInvalidInput.Error() + ": " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
 }